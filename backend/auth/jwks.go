@@ -0,0 +1,126 @@
+// Package auth verifies the JSON Web Tokens requests are authenticated with, against signing keys
+// read from a JSON Web Key Set (RFC 7517) file.
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// ErrInvalidJWKS is returned for a key set that cannot be read, or holds a key that cannot be used
+var ErrInvalidJWKS = errors.New("invalid JWKS")
+
+// key is a signing key from a key set, usable with the one algorithm its type allows
+type key struct {
+	id     string
+	alg    string
+	secret []byte
+	public *rsa.PublicKey
+}
+
+// KeySet holds the keys tokens may be signed with
+type KeySet struct {
+	keys []key
+}
+
+// jwk is a key as it appears in a key set file
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// LoadJWKS reads a key set file
+func LoadJWKS(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(data)
+}
+
+// ParseJWKS reads a key set. Symmetric ("oct") keys verify HS256 tokens and RSA keys verify RS256
+// tokens; a key naming another algorithm, or a use other than "sig", is refused.
+func ParseJWKS(data []byte) (*KeySet, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJWKS, err)
+	}
+	if len(set.Keys) == 0 {
+		return nil, fmt.Errorf("%w: no keys", ErrInvalidJWKS)
+	}
+
+	keys := &KeySet{}
+	for i, raw := range set.Keys {
+		k, err := parseKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %d: %v", ErrInvalidJWKS, i+1, err)
+		}
+		keys.keys = append(keys.keys, k)
+	}
+	return keys, nil
+}
+
+func parseKey(raw jwk) (key, error) {
+	if raw.Use != "" && raw.Use != "sig" {
+		return key{}, fmt.Errorf("use %q is not sig", raw.Use)
+	}
+
+	k := key{id: raw.Kid}
+	switch raw.Kty {
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(raw.K)
+		if err != nil || len(secret) == 0 {
+			return key{}, errors.New("k is not a base64url secret")
+		}
+		k.alg, k.secret = "HS256", secret
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(raw.N)
+		if err != nil || len(n) == 0 {
+			return key{}, errors.New("n is not a base64url modulus")
+		}
+		e, err := base64.RawURLEncoding.DecodeString(raw.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return key{}, errors.New("e is not a base64url exponent")
+		}
+		k.alg = "RS256"
+		k.public = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	default:
+		return key{}, fmt.Errorf("kty %q is not supported", raw.Kty)
+	}
+
+	if raw.Alg != "" && raw.Alg != k.alg {
+		return key{}, fmt.Errorf("alg %q cannot be used with a %s key", raw.Alg, raw.Kty)
+	}
+	return k, nil
+}
+
+// find returns the key a token signed with alg names by kid. A token without a kid may only be
+// verified by a set holding a single key for its algorithm.
+func (s *KeySet) find(kid, alg string) (key, bool) {
+	var found []key
+	for _, k := range s.keys {
+		if k.alg != alg {
+			continue
+		}
+		if kid != "" && k.id == kid {
+			return k, true
+		}
+		found = append(found, k)
+	}
+	if kid == "" && len(found) == 1 {
+		return found[0], true
+	}
+	return key{}, false
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidPolicy is returned for a policy that cannot be read, or grants a permission that does not exist
+var ErrInvalidPolicy = errors.New("invalid policy")
+
+// AllPermissions grants every permission in a policy
+const AllPermissions = "*"
+
+// Permissions are what policies grant, one for each thing the items API does
+var Permissions = []string{
+	"items.list",
+	"items.get",
+	"items.export",
+	"items.reversals",
+	"items.history",
+	"items.create",
+	"items.batch",
+	"items.import",
+	"items.update",
+	"items.transition",
+	"items.approve",
+	"items.reject",
+	"items.bacs",
+	"items.pain001",
+	"items.pacs002",
+	"items.delete",
+	"items.restore",
+	"items.purge",
+}
+
+// CollectionMethods are the custom methods on the item collection, such as POST /items:batch, each
+// of which needs the permission named after it (items.batch)
+var CollectionMethods = []string{"batch", "bacs", "pain001", "pacs002"}
+
+// Policy maps roles to the permissions they grant
+type Policy struct {
+	roles map[string]map[string]bool
+}
+
+// defaultPolicy gives viewers read access, operators the changes that create and send payments,
+// approvers the ones that decide them, and admins everything, including deleting items
+//
+//go:embed policy.yaml
+var defaultPolicy []byte
+
+// DefaultPolicy returns the policy used unless another is loaded
+func DefaultPolicy() *Policy {
+	policy, err := ParsePolicy(defaultPolicy)
+	if err != nil {
+		panic(fmt.Sprintf("auth: embedded policy: %v", err))
+	}
+	return policy
+}
+
+// LoadPolicy reads a policy file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePolicy(data)
+}
+
+// ParsePolicy reads a policy in YAML, or JSON, holding a roles object that lists the permissions
+// each role grants
+func ParsePolicy(data []byte) (*Policy, error) {
+	var file struct {
+		Roles map[string][]string `yaml:"roles"`
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPolicy, err)
+	}
+	if len(file.Roles) == 0 {
+		return nil, fmt.Errorf("%w: no roles", ErrInvalidPolicy)
+	}
+
+	policy := &Policy{roles: make(map[string]map[string]bool, len(file.Roles))}
+	for role, permissions := range file.Roles {
+		granted := make(map[string]bool, len(permissions))
+		for _, permission := range permissions {
+			if permission != AllPermissions && !slices.Contains(Permissions, permission) {
+				return nil, fmt.Errorf("%w: role %s grants unknown permission %q", ErrInvalidPolicy, role, permission)
+			}
+			granted[permission] = true
+		}
+		policy.roles[role] = granted
+	}
+	return policy, nil
+}
+
+// Allows reports whether any of roles grants permission
+func (p *Policy) Allows(roles []string, permission string) bool {
+	for _, role := range roles {
+		granted := p.roles[role]
+		if granted[permission] || granted[AllPermissions] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrInvalidToken is returned for a token that is malformed, unsigned by a known key or otherwise unacceptable
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrExpiredToken is returned for a token that is past its expiry time or not yet valid
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+// Claims are what a verified token says about who sent it
+type Claims struct {
+	jwt.RegisteredClaims
+	Name string `json:"name,omitempty"`
+	// Roles name the roles a Policy grants the sender permissions by
+	Roles []string `json:"roles,omitempty"`
+	// Tenant names the tenant whose items the sender can access; a token without one can only
+	// access the default tenant's
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// Options constrain which tokens a Verifier accepts beyond their signature
+type Options struct {
+	// Issuer, when set, must match the token's iss claim
+	Issuer string
+	// Audience, when set, must be among the token's aud claim
+	Audience string
+	// Leeway allows for clock skew when checking exp, nbf and iat
+	Leeway time.Duration
+}
+
+// Verifier checks tokens against a key set
+type Verifier struct {
+	keys   *KeySet
+	parser *jwt.Parser
+}
+
+// NewVerifier returns a verifier accepting HS256 and RS256 tokens signed by keys, which must
+// expire and name their subject
+func NewVerifier(keys *KeySet, options Options) *Verifier {
+	parserOptions := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"HS256", "RS256"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(options.Leeway),
+	}
+	if options.Issuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(options.Issuer))
+	}
+	if options.Audience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(options.Audience))
+	}
+	return &Verifier{keys: keys, parser: jwt.NewParser(parserOptions...)}
+}
+
+// Verify returns the claims of a signed token, or ErrExpiredToken or ErrInvalidToken
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := v.parser.ParseWithClaims(token, claims, v.keyFor)
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired), errors.Is(err, jwt.ErrTokenNotValidYet):
+		return nil, ErrExpiredToken
+	case err != nil:
+		return nil, ErrInvalidToken
+	case claims.Subject == "":
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// keyFor picks the key to check a token's signature with. Keys are bound to one algorithm, so a
+// token cannot have an RSA public key used as an HMAC secret.
+func (v *Verifier) keyFor(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	k, ok := v.keys.find(kid, token.Method.Alg())
+	if !ok {
+		return nil, errors.New("no key for token")
+	}
+	if k.public != nil {
+		return k.public, nil
+	}
+	return k.secret, nil
+}
@@ -0,0 +1,22 @@
+package bootstrap
+
+import (
+	"go-test/backend/domain/approval"
+	"log"
+	"os"
+)
+
+// NewApprovalRules loads the rules deciding how many approvers new items need from the file named
+// by APPROVAL_RULES_FILE, or returns the default rules when it is not set
+func NewApprovalRules() *approval.Rules {
+	path := os.Getenv("APPROVAL_RULES_FILE")
+	if path == "" {
+		return approval.Default()
+	}
+
+	rules, err := approval.Load(path)
+	if err != nil {
+		log.Fatal("Failed to load approval rules:", err)
+	}
+	return rules
+}
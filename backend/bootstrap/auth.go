@@ -0,0 +1,65 @@
+package bootstrap
+
+import (
+	"go-test/backend/auth"
+	"go-test/backend/middleware"
+	"log"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clockSkew is how far the clocks of token issuers may drift from ours
+const clockSkew = 30 * time.Second
+
+// NewAuthentication builds the middleware that authenticates item routes with bearer tokens signed
+// by the keys in the JWKS file named by AUTH_JWKS_FILE and, when AUTH_ISSUER or AUTH_AUDIENCE are
+// set, issued by and for them. Setting AUTH_DISABLED=true lets requests through unauthenticated
+// instead, for local development; the X-Actor header then names the actor audited.
+func NewAuthentication() gin.HandlerFunc {
+	if authDisabled() {
+		log.Println("WARNING: authentication is disabled; every request is trusted")
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	path := os.Getenv("AUTH_JWKS_FILE")
+	if path == "" {
+		log.Fatal("AUTH_JWKS_FILE must name the JWKS file tokens are verified with, or set AUTH_DISABLED=true")
+	}
+
+	keys, err := auth.LoadJWKS(path)
+	if err != nil {
+		log.Fatal("Failed to load JWKS:", err)
+	}
+	verifier := auth.NewVerifier(keys, auth.Options{
+		Issuer:   os.Getenv("AUTH_ISSUER"),
+		Audience: os.Getenv("AUTH_AUDIENCE"),
+		Leeway:   clockSkew,
+	})
+	return middleware.Authenticate(verifier)
+}
+
+// NewAuthorizer builds the authorizer that checks the roles of authenticated requests against the
+// policy file named by AUTH_POLICY_FILE, or the default policy when it is not set. With
+// authentication disabled every request is allowed.
+func NewAuthorizer() *middleware.Authorizer {
+	if authDisabled() {
+		return middleware.NewAuthorizer(nil)
+	}
+
+	path := os.Getenv("AUTH_POLICY_FILE")
+	if path == "" {
+		return middleware.NewAuthorizer(auth.DefaultPolicy())
+	}
+
+	policy, err := auth.LoadPolicy(path)
+	if err != nil {
+		log.Fatal("Failed to load access policy:", err)
+	}
+	return middleware.NewAuthorizer(policy)
+}
+
+func authDisabled() bool {
+	return os.Getenv("AUTH_DISABLED") == "true"
+}
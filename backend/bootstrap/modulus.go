@@ -0,0 +1,22 @@
+package bootstrap
+
+import (
+	"go-test/backend/domain/modulus"
+	"log"
+	"os"
+)
+
+// LoadModulusTables replaces the sample modulus checking tables with VocaLink's files when
+// MODULUS_WEIGHTS_PATH (valacdos.txt) and optionally MODULUS_SUBSTITUTIONS_PATH (scsubtab.txt) are set
+func LoadModulusTables() {
+	weightsPath := os.Getenv("MODULUS_WEIGHTS_PATH")
+	if weightsPath == "" {
+		return
+	}
+
+	table, err := modulus.Load(weightsPath, os.Getenv("MODULUS_SUBSTITUTIONS_PATH"))
+	if err != nil {
+		log.Fatal("Failed to load modulus checking tables:", err)
+	}
+	modulus.SetDefault(table)
+}
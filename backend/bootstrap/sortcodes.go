@@ -0,0 +1,22 @@
+package bootstrap
+
+import (
+	"go-test/backend/domain/sortcodes"
+	"log"
+	"os"
+)
+
+// LoadSortCodeDirectory replaces the sample sort code directory with the EISCD extract at
+// SORT_CODE_DIRECTORY_PATH when it is set
+func LoadSortCodeDirectory() {
+	path := os.Getenv("SORT_CODE_DIRECTORY_PATH")
+	if path == "" {
+		return
+	}
+
+	directory, err := sortcodes.Load(path)
+	if err != nil {
+		log.Fatal("Failed to load sort code directory:", err)
+	}
+	sortcodes.SetDefault(directory)
+}
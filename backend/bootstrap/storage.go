@@ -0,0 +1,40 @@
+package bootstrap
+
+import (
+	"go-test/backend/repository"
+	"log"
+	"os"
+)
+
+// NewStorage builds the items storage selected by the ITEMS_STORE environment variable.
+// "sqlite" persists items to the file named by SQLITE_PATH (default items.db),
+// "journal" keeps items in memory with a write-ahead log in JOURNAL_DIR (default data);
+// anything else falls back to the in-memory store.
+func NewStorage() repository.ItemsStorage {
+	switch os.Getenv("ITEMS_STORE") {
+	case "sqlite":
+		path := os.Getenv("SQLITE_PATH")
+		if path == "" {
+			path = "items.db"
+		}
+
+		s, err := repository.NewSQLiteStore(path)
+		if err != nil {
+			log.Fatal("Failed to open sqlite store:", err)
+		}
+		return s
+	case "journal":
+		dir := os.Getenv("JOURNAL_DIR")
+		if dir == "" {
+			dir = "data"
+		}
+
+		s, err := repository.NewDurableStore(dir, repository.DefaultSnapshotEvery)
+		if err != nil {
+			log.Fatal("Failed to open journal store:", err)
+		}
+		return s
+	default:
+		return repository.NewStore()
+	}
+}
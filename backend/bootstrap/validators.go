@@ -1,6 +1,8 @@
 package bootstrap
 
 import (
+	"go-test/backend/domain/dto"
+	"go-test/backend/domain/models"
 	"go-test/backend/domain/validators"
 	"log"
 
@@ -21,10 +23,84 @@ func RegisterCustomValidators() {
 			log.Fatal("Failed to register itemstatus validator:", err)
 		}
 
+		err = v.RegisterValidation("initialstatus", validators.ValidateInitialItemStatus)
+		if err != nil {
+			log.Fatal("Failed to register initialstatus validator:", err)
+		}
+
 		err = v.RegisterValidation("sortcode", validators.ValidateSortCode)
 		if err != nil {
 			log.Fatal("Failed to register sortcode validator:", err)
 		}
+
+		err = v.RegisterValidation("knownsortcode", validators.ValidateKnownSortCode)
+		if err != nil {
+			log.Fatal("Failed to register knownsortcode validator:", err)
+		}
+
+		err = v.RegisterValidation("accounttype", validators.ValidateAccountType)
+		if err != nil {
+			log.Fatal("Failed to register accounttype validator:", err)
+		}
+
+		err = v.RegisterValidation("requiredfor", validators.ValidateRequiredFor)
+		if err != nil {
+			log.Fatal("Failed to register requiredfor validator:", err)
+		}
+
+		err = v.RegisterValidation("onlyfor", validators.ValidateOnlyFor)
+		if err != nil {
+			log.Fatal("Failed to register onlyfor validator:", err)
+		}
+
+		err = v.RegisterValidation("iban", validators.ValidateIBAN)
+		if err != nil {
+			log.Fatal("Failed to register iban validator:", err)
+		}
+
+		err = v.RegisterValidation("bic", validators.ValidateBIC)
+		if err != nil {
+			log.Fatal("Failed to register bic validator:", err)
+		}
+
+		err = v.RegisterValidation("currency", validators.ValidateCurrency)
+		if err != nil {
+			log.Fatal("Failed to register currency validator:", err)
+		}
+
+		err = v.RegisterValidation("money", validators.ValidateMoney)
+		if err != nil {
+			log.Fatal("Failed to register money validator:", err)
+		}
+
+		err = v.RegisterValidation("positive", validators.ValidatePositive)
+		if err != nil {
+			log.Fatal("Failed to register positive validator:", err)
+		}
+
+		err = v.RegisterValidation("isodate", validators.ValidateISODate)
+		if err != nil {
+			log.Fatal("Failed to register isodate validator:", err)
+		}
+
+		err = v.RegisterValidation("itemsort", validators.ValidateItemSort)
+		if err != nil {
+			log.Fatal("Failed to register itemsort validator:", err)
+		}
+
+		err = v.RegisterValidation("serviceusernumber", validators.ValidateServiceUserNumber)
+		if err != nil {
+			log.Fatal("Failed to register serviceusernumber validator:", err)
+		}
+
+		err = v.RegisterValidation("bacsserial", validators.ValidateBacsSerial)
+		if err != nil {
+			log.Fatal("Failed to register bacsserial validator:", err)
+		}
+
+		v.RegisterStructValidation(validators.ValidateItemFilter, dto.ItemFilterDTO{})
+		v.RegisterStructValidation(validators.ValidateItemCreate, dto.ItemCreateDTO{})
+		v.RegisterStructValidation(validators.ValidateAccount, models.Account{})
 	} else {
 		log.Fatal("Failed to get validator engine")
 	}
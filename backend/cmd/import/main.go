@@ -0,0 +1,83 @@
+// Command import creates items from a CSV file in the store configured for the server, exactly as
+// POST /items/import does, and prints the import report as JSON. It exits with status 1 if any row
+// is invalid, in which case no items are created.
+//
+//	go run ./backend/cmd/import [-dry-run] [-tenant ID] items.csv
+//
+// Use - as the file name to read the CSV from standard input. Items are created for the named
+// tenant, or the default tenant if none is named.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go-test/backend/bootstrap"
+	"go-test/backend/handlers"
+	"go-test/backend/repository"
+	"io"
+	"log"
+	"os"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "validate the file without creating any items")
+	tenant := flag.String("tenant", repository.DefaultTenant, "the tenant to create the items for")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: import [-dry-run] [-tenant ID] FILE.csv")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load the reference data accounts are validated against
+	bootstrap.LoadModulusTables()
+	bootstrap.LoadSortCodeDirectory()
+
+	// Register custom validators
+	bootstrap.RegisterCustomValidators()
+
+	os.Exit(run(flag.Arg(0), *tenant, *dryRun))
+}
+
+// run imports the named file for tenant and returns the exit status
+func run(name, tenant string, dryRun bool) int {
+	var file io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			log.Fatal("Failed to open import file:", err)
+		}
+		defer f.Close()
+		file = f
+	}
+
+	s := bootstrap.NewStorage()
+	if closer, ok := s.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	// Imported items are audited as created by the import command
+	ctx := repository.WithTenant(context.Background(), tenant)
+	ctx = repository.WithChange(ctx, repository.Change{Actor: "import"})
+	report, err := handlers.NewItemsHandler(s, bootstrap.NewApprovalRules()).ImportItems(ctx, file, dryRun)
+	if err != nil {
+		log.Print("Failed to import items: ", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Print("Failed to write import report: ", err)
+		return 1
+	}
+	if report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
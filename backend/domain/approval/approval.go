@@ -0,0 +1,103 @@
+// Package approval decides how many approvers, other than the person who created it, must approve
+// a new item before it is released for processing, from declarative rules by amount.
+package approval
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"go-test/backend/domain/models"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidRules is returned for rules that cannot be read
+var ErrInvalidRules = errors.New("invalid approval rules")
+
+// Threshold requires Approvals approvers for amounts above Above, in its currency
+type Threshold struct {
+	Above     models.Money
+	Approvals int
+}
+
+// Rules give the approvals every new item needs, and more above thresholds
+type Rules struct {
+	approvals  int
+	thresholds []Threshold
+}
+
+// defaultRules require one approver for every item and two above 10,000 GBP, EUR or USD
+//
+//go:embed rules.yaml
+var defaultRules []byte
+
+// Default returns the rules used unless others are loaded
+func Default() *Rules {
+	rules, err := Parse(defaultRules)
+	if err != nil {
+		panic(fmt.Sprintf("approval: embedded rules: %v", err))
+	}
+	return rules
+}
+
+// None returns rules under which no item needs approval
+func None() *Rules {
+	return &Rules{}
+}
+
+// Load reads a rules file
+func Load(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse reads rules in YAML, or JSON, giving the approvals every item needs and a list of
+// thresholds, each requiring a number of approvals for amounts above a decimal amount in a currency
+func Parse(data []byte) (*Rules, error) {
+	var file struct {
+		Approvals  int `yaml:"approvals"`
+		Thresholds []struct {
+			Currency  string `yaml:"currency"`
+			Above     string `yaml:"above"`
+			Approvals int    `yaml:"approvals"`
+		} `yaml:"thresholds"`
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRules, err)
+	}
+	if file.Approvals < 0 {
+		return nil, fmt.Errorf("%w: approvals cannot be negative", ErrInvalidRules)
+	}
+
+	rules := &Rules{approvals: file.Approvals}
+	for n, threshold := range file.Thresholds {
+		above, err := models.ParseMoney(threshold.Above, threshold.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("%w: threshold %d: %v", ErrInvalidRules, n+1, err)
+		}
+		if threshold.Approvals < 1 {
+			return nil, fmt.Errorf("%w: threshold %d must require at least one approval", ErrInvalidRules, n+1)
+		}
+		rules.thresholds = append(rules.thresholds, Threshold{Above: above, Approvals: threshold.Approvals})
+	}
+	return rules, nil
+}
+
+// Required returns how many approvals an item for amount needs: the most required by any rule that
+// applies to it
+func (r *Rules) Required(amount models.Money) int {
+	required := r.approvals
+	for _, threshold := range r.thresholds {
+		if threshold.Above.CurrencyCode() == amount.CurrencyCode() && amount.Minor > threshold.Above.Minor {
+			required = max(required, threshold.Approvals)
+		}
+	}
+	return required
+}
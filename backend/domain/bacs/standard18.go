@@ -0,0 +1,365 @@
+// Package bacs writes payment files in the Bacs Standard 18 format: 80 character VOL1, HDR1, HDR2
+// and UHL1 labels, a 100 character detail record per payment with a contra record balancing each
+// originating account, and EOF1, EOF2 and UTL1 trailer labels.
+package bacs
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// Credit is the transaction code of a Bank Giro Credit, which pays the destination account
+	Credit = "99"
+	// Debit is the transaction code of a Direct Debit, which collects from the destination account
+	Debit = "17"
+
+	// MaxAmount is the largest payment Bacs accepts, in pence
+	MaxAmount = 20_000_000_00
+
+	labelLength  = 80
+	recordLength = 100
+)
+
+// transactionCodes maps each item type to the transaction code of its detail record. A reversal
+// pays the original debtor back, who is the reversal's beneficiary.
+var transactionCodes = map[enums.ItemType]string{
+	enums.SUBMISSION: Credit,
+	enums.ADMISSION:  Debit,
+	enums.REVERSAL:   Credit,
+}
+
+var (
+	ErrNotAccepted  = errors.New("only ACCEPTED items can be paid by Bacs")
+	ErrCurrency     = errors.New("Bacs payments must be in GBP")
+	ErrAccountType  = errors.New("Bacs payments need UK accounts")
+	ErrNotReachable = errors.New("branch does not take Bacs payments")
+	ErrAmountLimit  = errors.New("amount exceeds the Bacs limit")
+	ErrItemType     = errors.New("item type has no Bacs transaction code")
+)
+
+// Submission identifies a file to Bacs
+type Submission struct {
+	ServiceUserNumber string    // the six digit number Bacs issued to the service user
+	ServiceUserName   string    // printed on each payee's statement
+	SerialNumber      string    // six characters identifying the file, unique per service user
+	Created           time.Time // the day the file was written
+	ProcessingDate    time.Time // the day the payments should be processed
+}
+
+// TransactionCode returns the transaction code an item is paid with
+func TransactionCode(itemType enums.ItemType) (string, bool) {
+	code, ok := transactionCodes[itemType]
+	return code, ok
+}
+
+// Check returns why an item cannot be paid by Bacs, or nil if it can. Branches missing from the
+// sort code directory are assumed to take Bacs payments.
+func Check(item models.Item) error {
+	if item.Status != enums.ACCEPTED {
+		return ErrNotAccepted
+	}
+	if _, ok := TransactionCode(item.Type); !ok {
+		return ErrItemType
+	}
+	if item.Amount.CurrencyCode() != "GBP" {
+		return ErrCurrency
+	}
+	if item.Amount.Minor > MaxAmount {
+		return ErrAmountLimit
+	}
+	for _, account := range []models.Account{item.Attributes.Debtor.Account, item.Attributes.Beneficiary.Account} {
+		if !account.IsUK() {
+			return ErrAccountType
+		}
+		if account.Bank != nil && !slices.Contains(account.Bank.Schemes, enums.BACS) {
+			return ErrNotReachable
+		}
+	}
+	return nil
+}
+
+// payment is an item as a detail record: money moves between the destination account and the
+// originating account, in the direction given by the transaction code
+type payment struct {
+	code        string
+	destination models.Party
+	originating models.Party
+	amount      int64
+	reference   string
+}
+
+// newPayment orients an item by its transaction code: a credit pays the beneficiary from the
+// debtor's account, a debit collects from the debtor into the beneficiary's account
+func newPayment(item models.Item) payment {
+	code, _ := TransactionCode(item.Type)
+	p := payment{
+		code:        code,
+		destination: item.Attributes.Beneficiary,
+		originating: item.Attributes.Debtor,
+		amount:      item.Amount.Minor,
+		reference:   strings.ReplaceAll(item.GUID, "-", ""),
+	}
+	if code == Debit {
+		p.destination, p.originating = p.originating, p.destination
+	}
+	return p
+}
+
+// contraGroup collects the payments that one contra record balances: those in one direction
+// from one originating account
+type contraGroup struct {
+	code        string
+	originating models.Party
+	payments    []payment
+	total       int64
+}
+
+// contraCode is the transaction code of the contra record balancing payments with the given code
+func contraCode(code string) string {
+	if code == Credit {
+		return Debit
+	}
+	return Credit
+}
+
+// Write writes a Standard 18 file paying the given items, which must all pass Check. Detail records
+// are grouped by originating account and direction, in the order each group first appears, and each
+// group is followed by its contra record.
+func Write(w io.Writer, submission Submission, items []models.Item) error {
+	var groups []*contraGroup
+	for _, item := range items {
+		if err := Check(item); err != nil {
+			return fmt.Errorf("item %s: %w", item.GUID, err)
+		}
+
+		p := newPayment(item)
+		i := slices.IndexFunc(groups, func(g *contraGroup) bool {
+			return g.code == p.code && g.originating.Account.SameAs(p.originating.Account)
+		})
+		if i < 0 {
+			groups = append(groups, &contraGroup{code: p.code, originating: p.originating})
+			i = len(groups) - 1
+		}
+		groups[i].payments = append(groups[i].payments, p)
+		groups[i].total += p.amount
+	}
+
+	out := bufio.NewWriter(w)
+	fields := newLabelFields(submission)
+	for _, label := range [][]string{fields.vol1(), fields.header("HDR1"), hdr2("HDR2"), fields.uhl1()} {
+		writeRecord(out, labelLength, label)
+	}
+
+	var debits, credits totals
+	tally := func(code string, amount int64) {
+		if code == Debit {
+			debits.add(amount)
+		} else {
+			credits.add(amount)
+		}
+	}
+	for _, g := range groups {
+		for _, p := range g.payments {
+			writeRecord(out, recordLength, detailRecord(submission, p))
+			tally(p.code, p.amount)
+		}
+		contra := contraCode(g.code)
+		writeRecord(out, recordLength, contraRecord(submission, contra, g))
+		tally(contra, g.total)
+	}
+
+	for _, label := range [][]string{fields.header("EOF1"), hdr2("EOF2"), utl1(debits, credits)} {
+		writeRecord(out, labelLength, label)
+	}
+	return out.Flush()
+}
+
+// totals counts and sums the records in one direction, contras included, for the UTL1 label
+type totals struct {
+	count  int
+	amount int64
+}
+
+func (t *totals) add(amount int64) {
+	t.count++
+	t.amount += amount
+}
+
+// labelFields holds the values repeated across the labels of a file
+type labelFields struct {
+	sun     string
+	serial  string
+	created string
+	process string
+}
+
+func newLabelFields(s Submission) labelFields {
+	return labelFields{
+		sun:     s.ServiceUserNumber,
+		serial:  strings.ToUpper(s.SerialNumber),
+		created: julian(s.Created),
+		process: julian(s.ProcessingDate),
+	}
+}
+
+func (f labelFields) vol1() []string {
+	return []string{
+		"VOL1",
+		alpha(f.serial, 6), // volume serial number
+		" ",                // accessibility indicator
+		alpha("", 26),      // reserved
+		alpha("", 4),       // owner identification: blank, the service user number, blank
+		alpha(f.sun, 6),
+		alpha("", 4),
+		alpha("", 28), // reserved
+		"1",           // label standard level
+	}
+}
+
+// header is the HDR1 label, repeated as EOF1 at the end of the file
+func (f labelFields) header(name string) []string {
+	return []string{
+		name,
+		"A" + alpha(f.sun, 6) + "S" + alpha("", 2) + "1" + alpha(f.sun, 6), // file identifier
+		alpha(f.serial, 6), // file set identification
+		"0001",             // file section number
+		"0001",             // file sequence number
+		alpha("", 4),       // generation number
+		alpha("", 2),       // generation version number
+		f.created,          // creation date
+		f.created,          // expiration date
+		" ",                // accessibility indicator
+		"000000",           // block count
+		alpha("", 13),      // system code
+		alpha("", 7),       // reserved
+	}
+}
+
+// hdr2 is the HDR2 label, repeated as EOF2 at the end of the file
+func hdr2(name string) []string {
+	return []string{
+		name,
+		"F",           // record format: fixed length
+		"02000",       // block length
+		"00100",       // record length
+		alpha("", 35), // reserved
+		"00",          // buffer offset
+		alpha("", 28), // reserved
+	}
+}
+
+func (f labelFields) uhl1() []string {
+	return []string{
+		"UHL1",
+		f.process,           // processing date
+		alpha("999999", 10), // receiving party identifier
+		"00",                // currency code
+		"000000",            // country code
+		alpha("1 DAILY", 9), // work code: a single processing day
+		"001",               // file number
+		alpha("", 7),        // reserved
+		alpha("", 7),        // audit print identifier
+		alpha("", 26),       // reserved
+	}
+}
+
+func utl1(debits, credits totals) []string {
+	return []string{
+		"UTL1",
+		numeric(debits.amount, 13),
+		numeric(credits.amount, 13),
+		numeric(int64(debits.count), 7),
+		numeric(int64(credits.count), 7),
+		alpha("", 8),  // reserved
+		alpha("", 8),  // service user's own use
+		alpha("", 20), // reserved
+	}
+}
+
+func detailRecord(s Submission, p payment) []string {
+	return []string{
+		sortCode(p.destination.Account),
+		alpha(p.destination.Account.AccountNumber, 8),
+		"0", // destination account type
+		p.code,
+		sortCode(p.originating.Account),
+		alpha(p.originating.Account.AccountNumber, 8),
+		alpha("", 4), // free format
+		numeric(p.amount, 11),
+		alpha(s.ServiceUserName, 18),
+		alpha(p.reference, 18),
+		alpha(accountName(p.destination), 18),
+	}
+}
+
+func contraRecord(s Submission, code string, g *contraGroup) []string {
+	account := g.originating.Account
+	return []string{
+		sortCode(account),
+		alpha(account.AccountNumber, 8),
+		"0", // destination account type
+		code,
+		sortCode(account),
+		alpha(account.AccountNumber, 8),
+		alpha("", 4), // free format
+		numeric(g.total, 11),
+		alpha(s.ServiceUserName, 18),
+		alpha("CONTRA", 18),
+		alpha(accountName(g.originating), 18),
+	}
+}
+
+// writeRecord writes the fields of a record, which must add up to its length, on one line
+func writeRecord(w *bufio.Writer, length int, fields []string) {
+	record := strings.Join(fields, "")
+	if len(record) != length {
+		panic(fmt.Sprintf("bacs: %.4s record is %d characters, not %d", record, len(record), length))
+	}
+	w.WriteString(record)
+	w.WriteByte('\n')
+}
+
+// julian formats a date as a space followed by its two digit year and three digit day of the year
+func julian(t time.Time) string {
+	return fmt.Sprintf(" %02d%03d", t.Year()%100, t.YearDay())
+}
+
+// numeric right-aligns a number in a zero-filled field
+func numeric(n int64, width int) string {
+	return fmt.Sprintf("%0*d", width, n)
+}
+
+// alpha left-aligns a value in a space-filled field, in upper case and truncated to fit. Characters
+// outside the Bacs character set are replaced with spaces.
+func alpha(s string, width int) string {
+	field := make([]byte, 0, width)
+	for _, r := range strings.ToUpper(s) {
+		if len(field) == width {
+			break
+		}
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune(" .&/-", r) {
+			field = append(field, byte(r))
+		} else {
+			field = append(field, ' ')
+		}
+	}
+	return string(field) + strings.Repeat(" ", width-len(field))
+}
+
+// sortCode writes an account's sort code without its hyphens
+func sortCode(account models.Account) string {
+	return alpha(strings.ReplaceAll(account.SortCode, "-", ""), 6)
+}
+
+// accountName is the name a party's account is held in
+func accountName(party models.Party) string {
+	return party.FirstName + " " + party.LastName
+}
@@ -0,0 +1,11 @@
+package dto
+
+// ItemApprovalDTO approves an item awaiting approval, optionally saying why
+type ItemApprovalDTO struct {
+	Reason string `json:"reason"`
+}
+
+// ItemRejectionDTO rejects an item awaiting approval, saying why
+type ItemRejectionDTO struct {
+	Reason string `json:"reason" binding:"required"`
+}
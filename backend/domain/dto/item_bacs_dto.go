@@ -0,0 +1,11 @@
+package dto
+
+// ItemBacsDTO selects the items to pay in a Bacs Standard 18 file and identifies the file to Bacs.
+// The service user name is printed on payees' statements, truncated to 18 characters.
+type ItemBacsDTO struct {
+	GUIDs             []string `json:"guids" binding:"required,dive,required"`
+	ServiceUserNumber string   `json:"service_user_number" binding:"required,serviceusernumber"`
+	ServiceUserName   string   `json:"service_user_name" binding:"required"`
+	SerialNumber      string   `json:"serial_number" binding:"required,bacsserial"`
+	ProcessingDate    string   `json:"processing_date" binding:"required,datetime=2006-01-02"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+import "go-test/backend/domain/models"
+
+// ItemBatchResponse reports the outcome of creating each item of a batch, in request order
+type ItemBatchResponse struct {
+	Created int               `json:"created"`
+	Failed  int               `json:"failed"`
+	Results []ItemBatchResult `json:"results"`
+}
+
+// ItemBatchResult is the outcome of creating the item at Index in the batch. Status is the HTTP
+// status creating the item on its own would have returned, with the created item, the validation
+// errors in the same format as a single create, or any other reason the item was not created.
+type ItemBatchResult struct {
+	Index  int               `json:"index"`
+	Status int               `json:"status"`
+	Item   *models.Item      `json:"item,omitempty"`
+	Errors map[string]string `json:"errors,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
@@ -6,8 +6,12 @@ import (
 )
 
 type ItemCreateDTO struct {
-	Amount     float64           `json:"amount" binding:"required,gt=0"`
+	Amount     models.Decimal    `json:"amount" binding:"required,money,positive"`
+	Currency   string            `json:"currency" binding:"omitempty,currency"`
 	Type       enums.ItemType    `json:"type" binding:"required,itemtype"`
-	Status     enums.ItemStatus  `json:"status" binding:"required,itemstatus"`
+	Status     enums.ItemStatus  `json:"status" binding:"omitempty,itemstatus,initialstatus"`
 	Attributes models.Attributes `json:"attributes" binding:"required"`
+
+	// OriginalGUID is required for, and only allowed on, REVERSAL items
+	OriginalGUID string `json:"original_guid"`
 }
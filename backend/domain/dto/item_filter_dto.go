@@ -0,0 +1,24 @@
+package dto
+
+import "go-test/backend/domain/enums"
+
+// ItemFilterDTO is bound from the GET /items query string. Multi-valued filters
+// accept repeated parameters or comma-separated values (type=ADMISSION,REVERSAL).
+// Sort is a comma-separated list of keys, each prefixed with "-" for descending order.
+// Amount bounds are in the filtered currency when exactly one is given, otherwise in GBP.
+type ItemFilterDTO struct {
+	Query           string             `form:"query"`
+	Type            []enums.ItemType   `form:"type" collection_format:"csv" binding:"omitempty,dive,itemtype"`
+	Status          []enums.ItemStatus `form:"status" collection_format:"csv" binding:"omitempty,dive,itemstatus"`
+	Currency        []string           `form:"currency" collection_format:"csv" binding:"omitempty,dive,currency"`
+	AmountMin       string             `form:"amount_min" binding:"omitempty,numeric,money"`
+	AmountMax       string             `form:"amount_max" binding:"omitempty,numeric,money"`
+	CreatedFrom     string             `form:"created_from" binding:"omitempty,isodate"`
+	CreatedTo       string             `form:"created_to" binding:"omitempty,isodate"`
+	DebtorName      string             `form:"debtor_name"`
+	BeneficiaryName string             `form:"beneficiary_name"`
+	SortCode        string             `form:"sort_code" binding:"omitempty,sortcode"`
+	AccountNumber   string             `form:"account_number" binding:"omitempty,len=8,numeric"`
+	Sort            string             `form:"sort" binding:"omitempty,itemsort"`
+	IncludeDeleted  string             `form:"include_deleted" binding:"omitempty,boolean"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+import "go-test/backend/domain/models"
+
+// ItemHistoryResponse is the audit trail of an item, oldest change first
+type ItemHistoryResponse struct {
+	Data  []models.AuditEntry `json:"data"`
+	Total int                 `json:"total"`
+}
@@ -0,0 +1,20 @@
+package dto
+
+// ItemImportResponse reports the outcome of importing a CSV file of items. Items are only created
+// if every row is valid, and never on a dry run.
+type ItemImportResponse struct {
+	DryRun  bool              `json:"dry_run"`
+	Rows    int               `json:"rows"`
+	Valid   int               `json:"valid"`
+	Failed  int               `json:"failed"`
+	Created int               `json:"created"`
+	Errors  []ItemImportError `json:"errors"`
+}
+
+// ItemImportError describes why the row starting on Line of the file cannot be imported: the
+// validation errors in the same format as a single create, or any other problem with the row
+type ItemImportError struct {
+	Line   int               `json:"line"`
+	Errors map[string]string `json:"errors,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
@@ -0,0 +1,34 @@
+package dto
+
+import "go-test/backend/domain/models"
+
+// ItemPain001DTO selects the items to initiate as credit transfers in a pain.001 message and
+// identifies the message to the debtors' bank
+type ItemPain001DTO struct {
+	GUIDs                  []string `json:"guids" binding:"required,dive,required"`
+	MessageID              string   `json:"message_id" binding:"required,max=35"`
+	InitiatingParty        string   `json:"initiating_party" binding:"required,max=140"`
+	RequestedExecutionDate string   `json:"requested_execution_date" binding:"required,datetime=2006-01-02"`
+}
+
+// ItemStatusReportResponse reports the outcome of applying each transaction status of a pacs.002
+// status report, in document order
+type ItemStatusReportResponse struct {
+	MessageID string                   `json:"message_id"`
+	Updated   int                      `json:"updated"`
+	Unchanged int                      `json:"unchanged"`
+	Failed    int                      `json:"failed"`
+	Results   []ItemStatusReportResult `json:"results"`
+}
+
+// ItemStatusReportResult is the outcome of one transaction status. Status is the HTTP status moving
+// the item on its own would have returned, with the item as it now stands or the reason it was not
+// moved. Statuses that leave the item where it is, because the transaction is still in progress or
+// the item already has the reported status, are 200s that count as unchanged.
+type ItemStatusReportResult struct {
+	EndToEndID        string       `json:"end_to_end_id"`
+	TransactionStatus string       `json:"transaction_status"`
+	Status            int          `json:"status"`
+	Item              *models.Item `json:"item,omitempty"`
+	Error             string       `json:"error,omitempty"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"encoding/json"
+	"go-test/backend/domain/models"
+)
+
+type ItemListResponse struct {
+	Data       []models.Item   `json:"data"`
+	Total      int             `json:"total"`
+	Totals     []CurrencyTotal `json:"totals"`
+	NextCursor *string         `json:"next_cursor"`
+	PrevCursor *string         `json:"prev_cursor"`
+}
+
+// CurrencyTotal sums the amounts of every matching item in one currency, across all pages
+type CurrencyTotal struct {
+	Currency string       `json:"currency"`
+	Count    int          `json:"count"`
+	Amount   models.Money `json:"amount"`
+}
+
+// UnmarshalJSON reads the currency first so that the amount is parsed in it
+func (ct *CurrencyTotal) UnmarshalJSON(data []byte) error {
+	var head struct {
+		Currency string `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return err
+	}
+
+	type currencyTotal CurrencyTotal
+	wire := currencyTotal{Amount: models.Money{Currency: head.Currency}}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	*ct = CurrencyTotal(wire)
+	return nil
+}
@@ -0,0 +1,16 @@
+package dto
+
+import "time"
+
+// ItemPurgeDTO selects the soft-deleted items to purge: those deleted more than RetentionDays
+// days ago. Zero purges every deleted item.
+type ItemPurgeDTO struct {
+	RetentionDays *int `json:"retention_days" binding:"required,min=0"`
+}
+
+// ItemPurgeResponse lists the items a purge permanently removed, in index order
+type ItemPurgeResponse struct {
+	DeletedBefore time.Time `json:"deleted_before"`
+	Purged        int       `json:"purged"`
+	GUIDs         []string  `json:"guids"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+import "go-test/backend/domain/enums"
+
+// ItemTransitionDTO moves an item to a new status, recording why; who made the change is the caller
+type ItemTransitionDTO struct {
+	Status enums.ItemStatus `json:"status" binding:"required,itemstatus"`
+	Reason string           `json:"reason" binding:"required"`
+}
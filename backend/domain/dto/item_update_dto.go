@@ -6,8 +6,10 @@ import (
 )
 
 type ItemUpdateDTO struct {
-	Amount     *float64           `json:"amount,omitempty" binding:"omitempty,gt=0"`
+	Amount     *models.Decimal    `json:"amount,omitempty" binding:"omitempty,money,positive"`
+	Currency   *string            `json:"currency,omitempty" binding:"omitempty,currency"`
 	Type       *enums.ItemType    `json:"type,omitempty" binding:"omitempty,itemtype"`
 	Status     *enums.ItemStatus  `json:"status,omitempty" binding:"omitempty,itemstatus"`
 	Attributes *models.Attributes `json:"attributes,omitempty" binding:"omitempty"`
+	Reason     string             `json:"reason,omitempty"`
 }
@@ -0,0 +1,18 @@
+package enums
+
+// PaymentScheme is a UK payment scheme a bank branch can send and receive payments through
+type PaymentScheme string
+
+const (
+	BACS  PaymentScheme = "BACS"
+	FPS   PaymentScheme = "FPS"
+	CHAPS PaymentScheme = "CHAPS"
+)
+
+// AccountType is how an account is identified: by UK sort code and account number, or by IBAN
+type AccountType string
+
+const (
+	UKAccount   AccountType = "UK"
+	IBANAccount AccountType = "IBAN"
+)
@@ -0,0 +1,9 @@
+package enums
+
+// ApprovalDecision is what an approver decided about an item awaiting approval
+type ApprovalDecision string
+
+const (
+	APPROVED ApprovalDecision = "APPROVED"
+	REJECTED ApprovalDecision = "REJECTED"
+)
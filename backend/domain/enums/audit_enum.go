@@ -0,0 +1,12 @@
+package enums
+
+// AuditAction is the kind of change an audit entry records
+type AuditAction string
+
+const (
+	AuditCreate  AuditAction = "CREATE"
+	AuditUpdate  AuditAction = "UPDATE"
+	AuditDelete  AuditAction = "DELETE"  // a soft delete, which can be restored
+	AuditRestore AuditAction = "RESTORE" // the restore of a soft-deleted item
+	AuditPurge   AuditAction = "PURGE"   // the permanent removal of a soft-deleted item
+)
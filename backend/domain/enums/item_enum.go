@@ -10,6 +10,39 @@ const (
 )
 
 const (
-	ACCEPTED ItemStatus = "ACCEPTED"
-	DECLINED ItemStatus = "DECLINED"
+	PENDING_APPROVAL ItemStatus = "PENDING_APPROVAL"
+	PENDING          ItemStatus = "PENDING"
+	ACCEPTED         ItemStatus = "ACCEPTED"
+	DECLINED         ItemStatus = "DECLINED"
+	SETTLED          ItemStatus = "SETTLED"
+	RETURNED         ItemStatus = "RETURNED"
 )
+
+// statusTransitions is the item lifecycle: the statuses each status may move to next.
+// DECLINED and RETURNED are final. Items only leave PENDING_APPROVAL by being approved, to PENDING,
+// or rejected, to DECLINED, so it has no transitions here.
+var statusTransitions = map[ItemStatus][]ItemStatus{
+	PENDING:  {ACCEPTED, DECLINED},
+	ACCEPTED: {SETTLED, RETURNED},
+	SETTLED:  {RETURNED},
+}
+
+// CanTransitionTo reports whether an item may move directly from status s to next
+func (s ItemStatus) CanTransitionTo(next ItemStatus) bool {
+	for _, allowed := range statusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInitial reports whether an item may be created with status s; the rest are only reached by transitions
+func (s ItemStatus) IsInitial() bool {
+	return s == PENDING || s == ACCEPTED || s == DECLINED
+}
+
+// IsVoid reports whether an item in status s moved no money, having been declined or returned
+func (s ItemStatus) IsVoid() bool {
+	return s == DECLINED || s == RETURNED
+}
@@ -0,0 +1,14 @@
+package enums
+
+// SortField is a key the item list can be ordered by
+type SortField string
+
+const (
+	SortIndex           SortField = "index"
+	SortAmount          SortField = "amount"
+	SortCreated         SortField = "created"
+	SortType            SortField = "type"
+	SortStatus          SortField = "status"
+	SortDebtorName      SortField = "debtor_name"
+	SortBeneficiaryName SortField = "beneficiary_name"
+)
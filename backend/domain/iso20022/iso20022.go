@@ -0,0 +1,104 @@
+// Package iso20022 exchanges items with banks as ISO 20022 XML messages: it writes PENDING credit
+// transfers as a pain.001.001.09 customer credit transfer initiation, and reads the bank's answer
+// from a pacs.002.001.10 payment status report.
+//
+// The schemas in the xsd directory are trimmed copies of the published ISO 20022 message
+// definitions, keeping only the components this package reads and writes, in their published order.
+package iso20022
+
+import (
+	"go-test/backend/domain/models"
+	"strings"
+)
+
+// ukClearingSystem identifies UK sort codes in a ClrSysMmbId (the External Code Sets' GBDSC)
+const ukClearingSystem = "GBDSC"
+
+// notProvided stands in for an agent whose identity is unknown, as the message guidelines require
+const notProvided = "NOTPROVIDED"
+
+// maxText35 and maxText140 are the lengths of the Max35Text and Max140Text data types
+const (
+	maxText35  = 35
+	maxText140 = 140
+)
+
+// EndToEndID is the identifier an item travels under: its GUID without hyphens, which fits the 35
+// characters ISO 20022 allows and comes back unchanged in status reports
+func EndToEndID(guid string) string {
+	return truncate(strings.ReplaceAll(guid, "-", ""), maxText35)
+}
+
+// GUIDFromEndToEndID restores the GUID of an item from its EndToEndID. Identifiers this package did
+// not write are returned as they are, and will not match an item.
+func GUIDFromEndToEndID(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if len(id) != 32 || strings.Trim(id, "0123456789abcdef") != "" {
+		return id
+	}
+	return id[:8] + "-" + id[8:12] + "-" + id[12:16] + "-" + id[16:20] + "-" + id[20:]
+}
+
+// party is a PartyIdentification135
+type party struct {
+	Name string `xml:"Nm"`
+}
+
+// account is a CashAccount38, identified by IBAN or, for UK accounts, by account number
+type account struct {
+	IBAN  string        `xml:"Id>IBAN,omitempty"`
+	Other *otherAccount `xml:"Id>Othr,omitempty"`
+}
+
+// otherAccount is a GenericAccountIdentification1
+type otherAccount struct {
+	ID string `xml:"Id"`
+}
+
+// agent is a BranchAndFinancialInstitutionIdentification6: a BIC, a sort code, or NOTPROVIDED
+type agent struct {
+	BIC      string        `xml:"FinInstnId>BICFI,omitempty"`
+	Clearing *clearingID   `xml:"FinInstnId>ClrSysMmbId,omitempty"`
+	Other    *otherInstnID `xml:"FinInstnId>Othr,omitempty"`
+}
+
+// clearingID is a ClearingSystemMemberIdentification2
+type clearingID struct {
+	System   string `xml:"ClrSysId>Cd"`
+	MemberID string `xml:"MmbId"`
+}
+
+// otherInstnID is a GenericFinancialIdentification1
+type otherInstnID struct {
+	ID string `xml:"Id"`
+}
+
+func newParty(p models.Party) party {
+	return party{Name: truncate(strings.TrimSpace(p.FirstName+" "+p.LastName), maxText140)}
+}
+
+func newAccount(a models.Account) account {
+	if !a.IsUK() {
+		return account{IBAN: a.IBAN}
+	}
+	return account{Other: &otherAccount{ID: a.AccountNumber}}
+}
+
+func newAgent(a models.Account) agent {
+	switch {
+	case a.IsUK():
+		return agent{Clearing: &clearingID{System: ukClearingSystem, MemberID: strings.ReplaceAll(a.SortCode, "-", "")}}
+	case a.BIC != "":
+		return agent{BIC: a.BIC}
+	default:
+		return agent{Other: &otherInstnID{ID: notProvided}}
+	}
+}
+
+// truncate shortens text to at most n characters
+func truncate(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
@@ -0,0 +1,117 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"go-test/backend/domain/enums"
+	"io"
+	"strings"
+)
+
+// Pacs002Namespace is the namespace of an FI to FI payment status report
+const Pacs002Namespace = "urn:iso:std:iso:20022:tech:xsd:pacs.002.001.10"
+
+var (
+	ErrInvalidDocument = errors.New("not a pacs.002.001.10 payment status report")
+	ErrUnknownStatus   = errors.New("unknown transaction status")
+)
+
+// transactionStatuses maps the ExternalPaymentTransactionStatus1Code codes to the status they move
+// an item to. Codes mapped to "" report progress that does not settle the item either way.
+var transactionStatuses = map[string]enums.ItemStatus{
+	"ACCP": enums.ACCEPTED, // accepted customer profile
+	"ACSP": enums.ACCEPTED, // accepted settlement in process
+	"ACSC": enums.ACCEPTED, // accepted settlement completed on the debtor's account
+	"ACCC": enums.ACCEPTED, // accepted settlement completed on the creditor's account
+	"ACWC": enums.ACCEPTED, // accepted with change
+	"ACFC": enums.ACCEPTED, // accepted funds checked
+	"ACWP": enums.ACCEPTED, // accepted without posting
+	"RJCT": enums.DECLINED, // rejected
+	"ACTC": "",             // accepted technical validation
+	"RCVD": "",             // received
+	"PDNG": "",             // pending
+	"PART": "",             // partially accepted
+}
+
+// StatusReport is the part of a payment status report that concerns individual transactions
+type StatusReport struct {
+	MessageID    string
+	Transactions []TransactionStatus
+}
+
+// TransactionStatus is the status a bank reports for one transaction
+type TransactionStatus struct {
+	EndToEndID     string
+	Status         string   // the TxSts code, e.g. ACSC or RJCT
+	Reason         string   // the StsRsnInf reason code, e.g. AC04, if any
+	AdditionalInfo []string // free text explaining the status
+}
+
+// ItemStatus returns the status the transaction's item moves to, or "" if the transaction is still
+// in progress. It fails with ErrUnknownStatus for codes outside ExternalPaymentTransactionStatus1Code.
+func (t TransactionStatus) ItemStatus() (enums.ItemStatus, error) {
+	status, ok := transactionStatuses[t.Status]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownStatus, t.Status)
+	}
+	return status, nil
+}
+
+// Explanation describes the transaction's status as its status code, its reason code if any, and
+// any additional information, e.g. "RJCT AC04: Account closed"
+func (t TransactionStatus) Explanation() string {
+	explanation := strings.TrimSpace(t.Status + " " + t.Reason)
+	if len(t.AdditionalInfo) > 0 {
+		explanation += ": " + strings.Join(t.AdditionalInfo, " ")
+	}
+	return explanation
+}
+
+type pacs002Document struct {
+	XMLName xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:pacs.002.001.10 Document"`
+	Report  struct {
+		MessageID    string `xml:"GrpHdr>MsgId"`
+		Transactions []struct {
+			EndToEndID string `xml:"OrgnlEndToEndId"`
+			Status     string `xml:"TxSts"`
+			Reasons    []struct {
+				Code           string   `xml:"Rsn>Cd"`
+				Proprietary    string   `xml:"Rsn>Prtry"`
+				AdditionalInfo []string `xml:"AddtlInf"`
+			} `xml:"StsRsnInf"`
+		} `xml:"TxInfAndSts"`
+	} `xml:"FIToFIPmtStsRpt"`
+}
+
+// ReadPacs002 reads the transaction statuses of a payment status report. Statuses reported only for
+// the original message as a whole, in OrgnlGrpInfAndSts, are not read.
+func ReadPacs002(r io.Reader) (StatusReport, error) {
+	var doc pacs002Document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return StatusReport{}, fmt.Errorf("%w: %v", ErrInvalidDocument, err)
+	}
+	if strings.TrimSpace(doc.Report.MessageID) == "" {
+		return StatusReport{}, fmt.Errorf("%w: missing GrpHdr/MsgId", ErrInvalidDocument)
+	}
+
+	report := StatusReport{MessageID: strings.TrimSpace(doc.Report.MessageID)}
+	for _, tx := range doc.Report.Transactions {
+		status := TransactionStatus{
+			EndToEndID: strings.TrimSpace(tx.EndToEndID),
+			Status:     strings.ToUpper(strings.TrimSpace(tx.Status)),
+		}
+		for _, reason := range tx.Reasons {
+			if status.Reason == "" {
+				status.Reason = strings.TrimSpace(reason.Code + reason.Proprietary)
+			}
+			for _, info := range reason.AdditionalInfo {
+				if info = strings.TrimSpace(info); info != "" {
+					status.AdditionalInfo = append(status.AdditionalInfo, info)
+				}
+			}
+		}
+		report.Transactions = append(report.Transactions, status)
+	}
+	return report, nil
+}
@@ -0,0 +1,200 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"io"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pain001Namespace is the namespace of a customer credit transfer initiation
+const Pain001Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.09"
+
+var (
+	ErrNotPending = errors.New("only PENDING items can be initiated as credit transfers")
+	ErrItemType   = errors.New("item type is not a credit transfer")
+)
+
+// Initiation identifies a customer credit transfer initiation to the debtors' bank
+type Initiation struct {
+	MessageID              string    // unique per initiating party, at most 35 characters
+	InitiatingParty        string    // the name of the party sending the message
+	Created                time.Time // when the message was written
+	RequestedExecutionDate time.Time // the day the debtors' accounts should be debited
+}
+
+// CheckCreditTransfer returns why an item cannot be initiated as a credit transfer, or nil if it
+// can. Submissions and reversals pay their beneficiary from the debtor's account; admissions
+// collect from the debtor, which is a direct debit and not part of a pain.001.
+func CheckCreditTransfer(item models.Item) error {
+	if item.Status != enums.PENDING {
+		return ErrNotPending
+	}
+	if item.Type != enums.SUBMISSION && item.Type != enums.REVERSAL {
+		return ErrItemType
+	}
+	return nil
+}
+
+type pain001Document struct {
+	XMLName  xml.Name `xml:"Document"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Initiate struct {
+		GroupHeader groupHeader          `xml:"GrpHdr"`
+		Payments    []paymentInstruction `xml:"PmtInf"`
+	} `xml:"CstmrCdtTrfInitn"`
+}
+
+type groupHeader struct {
+	MessageID       string `xml:"MsgId"`
+	Created         string `xml:"CreDtTm"`
+	NumberOfTxs     int    `xml:"NbOfTxs"`
+	ControlSum      string `xml:"CtrlSum"`
+	InitiatingParty party  `xml:"InitgPty"`
+}
+
+// paymentInstruction is a PaymentInstruction30: the transfers debited from one account
+type paymentInstruction struct {
+	ID            string           `xml:"PmtInfId"`
+	Method        string           `xml:"PmtMtd"`
+	NumberOfTxs   int              `xml:"NbOfTxs"`
+	ControlSum    string           `xml:"CtrlSum"`
+	ExecutionDate string           `xml:"ReqdExctnDt>Dt"`
+	Debtor        party            `xml:"Dbtr"`
+	DebtorAccount account          `xml:"DbtrAcct"`
+	DebtorAgent   agent            `xml:"DbtrAgt"`
+	Transfers     []creditTransfer `xml:"CdtTrfTxInf"`
+
+	debtor   models.Account
+	controls controlSum
+}
+
+// creditTransfer is a CreditTransferTransaction34
+type creditTransfer struct {
+	InstructionID   string        `xml:"PmtId>InstrId"`
+	EndToEndID      string        `xml:"PmtId>EndToEndId"`
+	Amount          instructedAmt `xml:"Amt>InstdAmt"`
+	CreditorAgent   agent         `xml:"CdtrAgt"`
+	Creditor        party         `xml:"Cdtr"`
+	CreditorAccount account       `xml:"CdtrAcct"`
+	RemittanceInfo  *remittance   `xml:"RmtInf,omitempty"`
+}
+
+// remittance is a RemittanceInformation16 with a single unstructured line
+type remittance struct {
+	Unstructured string `xml:"Ustrd"`
+}
+
+type instructedAmt struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// controlSum adds up amounts by face value regardless of their currency, as a CtrlSum does, in
+// units of 10^-MaxCurrencyExponent. It is unbounded, since the amounts of a file may add up to
+// more than an int64 holds.
+type controlSum struct {
+	units big.Int
+}
+
+func (s *controlSum) add(m models.Money) {
+	s.units.Add(&s.units, big.NewInt(m.Scaled()))
+}
+
+func (s *controlSum) String() string {
+	whole := s.units.String()
+	if len(whole) <= models.MaxCurrencyExponent {
+		whole = strings.Repeat("0", models.MaxCurrencyExponent-len(whole)+1) + whole
+	}
+	point := len(whole) - models.MaxCurrencyExponent
+	frac := strings.TrimRight(whole[point:], "0")
+	if frac == "" {
+		return whole[:point]
+	}
+	return whole[:point] + "." + frac
+}
+
+// WritePain001 writes a customer credit transfer initiation of the given items, which must all pass
+// CheckCreditTransfer. Transfers are grouped into one payment instruction per debtor account, in
+// the order each account first appears; each transfer's EndToEndId is the EndToEndID of its item.
+func WritePain001(w io.Writer, initiation Initiation, items []models.Item) error {
+	doc := pain001Document{Xmlns: Pain001Namespace}
+	header := &doc.Initiate.GroupHeader
+	header.MessageID = initiation.MessageID
+	header.Created = initiation.Created.UTC().Truncate(time.Second).Format(time.RFC3339)
+	header.InitiatingParty = party{Name: truncate(initiation.InitiatingParty, maxText140)}
+
+	var payments []*paymentInstruction
+	var total controlSum
+	for _, item := range items {
+		if err := CheckCreditTransfer(item); err != nil {
+			return fmt.Errorf("item %s: %w", item.GUID, err)
+		}
+
+		debtor := item.Attributes.Debtor
+		i := slices.IndexFunc(payments, func(p *paymentInstruction) bool {
+			return p.debtor.SameAs(debtor.Account)
+		})
+		if i < 0 {
+			payments = append(payments, &paymentInstruction{
+				Method:        "TRF",
+				ExecutionDate: initiation.RequestedExecutionDate.Format(time.DateOnly),
+				Debtor:        newParty(debtor),
+				DebtorAccount: newAccount(debtor.Account),
+				DebtorAgent:   newAgent(debtor.Account),
+				debtor:        debtor.Account,
+			})
+			i = len(payments) - 1
+		}
+
+		p := payments[i]
+		beneficiary := item.Attributes.Beneficiary
+		p.Transfers = append(p.Transfers, creditTransfer{
+			InstructionID:   EndToEndID(item.GUID),
+			EndToEndID:      EndToEndID(item.GUID),
+			Amount:          instructedAmt{Currency: item.Amount.CurrencyCode(), Value: item.Amount.String()},
+			CreditorAgent:   newAgent(beneficiary.Account),
+			Creditor:        newParty(beneficiary),
+			CreditorAccount: newAccount(beneficiary.Account),
+			RemittanceInfo:  remittanceInfo(item),
+		})
+		p.controls.add(item.Amount)
+		total.add(item.Amount)
+	}
+
+	for n, p := range payments {
+		suffix := "-" + strconv.Itoa(n+1)
+		p.ID = truncate(initiation.MessageID, maxText35-len(suffix)) + suffix
+		p.NumberOfTxs = len(p.Transfers)
+		p.ControlSum = p.controls.String()
+		doc.Initiate.Payments = append(doc.Initiate.Payments, *p)
+	}
+	header.NumberOfTxs = len(items)
+	header.ControlSum = total.String()
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// remittanceInfo tells the beneficiary of a reversal which payment it reverses
+func remittanceInfo(item models.Item) *remittance {
+	if item.OriginalGUID == "" {
+		return nil
+	}
+	return &remittance{Unstructured: "Reversal of " + item.OriginalGUID}
+}
@@ -1,6 +1,69 @@
 package models
 
+import (
+	"encoding/json"
+	"go-test/backend/domain/enums"
+	"strings"
+)
+
+// Account identifies where a party's money is held. Its AccountType decides which fields it has:
+// a UK account has a SortCode and AccountNumber, an IBAN account an IBAN and optionally a BIC.
+// The sort code and account number of IBAN accounts are empty.
 type Account struct {
-	SortCode      string `json:"sort_code" binding:"required,sortcode"`
-	AccountNumber string `json:"account_number" binding:"required,len=8"`
+	AccountType   enums.AccountType `json:"type,omitempty" binding:"accounttype"`
+	SortCode      string            `json:"sort_code" binding:"requiredfor=UK,onlyfor=UK,omitempty,sortcode,knownsortcode"`
+	AccountNumber string            `json:"account_number" binding:"requiredfor=UK,onlyfor=UK,omitempty,len=8"`
+	IBAN          string            `json:"iban,omitempty" binding:"requiredfor=IBAN,onlyfor=IBAN,omitempty,iban"`
+	BIC           string            `json:"bic,omitempty" binding:"onlyfor=IBAN,omitempty,bic"`
+
+	// Bank is looked up from the sort code directory when a UK account is saved; any value sent by
+	// the client is replaced. Items saved before the directory existed have none.
+	Bank *Bank `json:"bank,omitempty" binding:"-"`
+}
+
+// Bank is the sort code directory's entry for the branch an account is held at
+type Bank struct {
+	Name    string                `json:"name"`
+	Branch  string                `json:"branch"`
+	Schemes []enums.PaymentScheme `json:"schemes"`
+}
+
+// UnmarshalJSON reads the account type case-insensitively; accounts without one, including every
+// account written before IBANs were supported, are UK accounts
+func (a *Account) UnmarshalJSON(data []byte) error {
+	type account Account
+	var wire account
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	*a = Account(wire)
+	a.AccountType = enums.AccountType(strings.ToUpper(string(a.AccountType)))
+	if a.AccountType == "" {
+		a.AccountType = enums.UKAccount
+	}
+	return nil
+}
+
+// IsUK reports whether the account is identified by sort code and account number
+func (a Account) IsUK() bool {
+	return a.AccountType == enums.UKAccount || a.AccountType == ""
+}
+
+// Normalize sets the account type of UK accounts built without one and writes IBANs and BICs in
+// upper case without spaces
+func (a *Account) Normalize() {
+	if a.AccountType == "" {
+		a.AccountType = enums.UKAccount
+	}
+	if a.IBAN != "" {
+		a.IBAN = NormalizeIBAN(a.IBAN)
+	}
+	a.BIC = strings.ToUpper(strings.TrimSpace(a.BIC))
+}
+
+// SameAs reports whether two accounts identify the same account, ignoring the bank details looked
+// up for them
+func (a Account) SameAs(b Account) bool {
+	return a.IsUK() == b.IsUK() && a.SortCode == b.SortCode && a.AccountNumber == b.AccountNumber &&
+		a.IBAN == b.IBAN && a.BIC == b.BIC
 }
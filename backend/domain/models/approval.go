@@ -0,0 +1,106 @@
+package models
+
+import (
+	"errors"
+	"go-test/backend/domain/enums"
+	"slices"
+	"time"
+)
+
+var (
+	ErrNotAwaitingApproval = errors.New("item is not awaiting approval")
+	ErrCreatorApproval     = errors.New("the creator of an item cannot approve it")
+	ErrChangerApproval     = errors.New("whoever changed an item cannot approve the change")
+	ErrAlreadyApproved     = errors.New("the approver has already approved the item")
+	ErrProcessed           = errors.New("item has been processed and cannot be held for approval")
+)
+
+// Approval records one decision on an item awaiting approval
+type Approval struct {
+	Actor    string                 `json:"actor"`
+	Decision enums.ApprovalDecision `json:"decision"`
+	Reason   string                 `json:"reason,omitempty"`
+	At       time.Time              `json:"at"`
+}
+
+// AwaitApproval holds a new item in PENDING_APPROVAL until required approvers other than its
+// creator have approved it. An item that needs no approval is left as it is.
+func (i *Item) AwaitApproval(required int) {
+	if required <= 0 {
+		return
+	}
+	i.Status = enums.PENDING_APPROVAL
+	i.RequiredApprovals = required
+}
+
+// AwaitReapproval holds an item whose amount, currency or parties actor has changed in
+// PENDING_APPROVAL again, discarding the approvals given for its old values, until required approvers
+// other than its creator and actor have approved it. An item that needs no approval is left as it is. It fails
+// with ErrProcessed if the item needs approval but has moved on from PENDING.
+func (i *Item) AwaitReapproval(required int, actor, reason string, at time.Time) error {
+	if required <= 0 {
+		return nil
+	}
+	if i.Status != enums.PENDING {
+		return ErrProcessed
+	}
+
+	i.Approvals = nil
+	i.RequiredApprovals = required
+	i.ChangedBy = actor
+	i.move(enums.PENDING_APPROVAL, actor, reason, at)
+	return nil
+}
+
+// Approved returns how many approvers have approved the item
+func (i Item) Approved() int {
+	n := 0
+	for _, approval := range i.Approvals {
+		if approval.Decision == enums.APPROVED {
+			n++
+		}
+	}
+	return n
+}
+
+// Approve records actor's approval of the item and, once it has as many approvals as it requires,
+// releases it to PENDING. It fails with ErrNotAwaitingApproval, ErrCreatorApproval, ErrChangerApproval
+// or ErrAlreadyApproved if actor may not approve the item.
+func (i *Item) Approve(actor, reason string, at time.Time) error {
+	switch {
+	case i.Status != enums.PENDING_APPROVAL:
+		return ErrNotAwaitingApproval
+	case actor == i.CreatedBy:
+		return ErrCreatorApproval
+	case actor == i.ChangedBy:
+		return ErrChangerApproval
+	case slices.ContainsFunc(i.Approvals, func(a Approval) bool { return a.Actor == actor && a.Decision == enums.APPROVED }):
+		return ErrAlreadyApproved
+	}
+
+	i.decide(Approval{Actor: actor, Decision: enums.APPROVED, Reason: reason, At: at})
+	if i.Approved() >= i.RequiredApprovals {
+		if reason == "" {
+			reason = "Approved"
+		}
+		i.move(enums.PENDING, actor, reason, at)
+	}
+	return nil
+}
+
+// Reject records actor's rejection of the item and declines it. Anyone may reject an item awaiting
+// approval, including its creator; it fails with ErrNotAwaitingApproval otherwise.
+func (i *Item) Reject(actor, reason string, at time.Time) error {
+	if i.Status != enums.PENDING_APPROVAL {
+		return ErrNotAwaitingApproval
+	}
+
+	i.decide(Approval{Actor: actor, Decision: enums.REJECTED, Reason: reason, At: at})
+	i.move(enums.DECLINED, actor, reason, at)
+	return nil
+}
+
+func (i *Item) decide(approval Approval) {
+	// Clip so that appending never writes into an array shared with another copy of the item
+	i.Approvals = append(slices.Clip(i.Approvals), approval)
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-test/backend/domain/enums"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// AuditEntry records one change to an item: who made it, when, in answer to which request, and
+// the value of every field it changed. Entries are only ever appended, and outlive the item.
+type AuditEntry struct {
+	GUID      string            `json:"guid"`
+	Seq       int               `json:"seq"` // position in the item's history, from 1
+	Action    enums.AuditAction `json:"action"`
+	Actor     string            `json:"actor"`
+	RequestID string            `json:"request_id,omitempty"`
+	At        time.Time         `json:"at"`
+	Version   int               `json:"version"` // the item's version after the change, or the version deleted
+	Changes   []FieldChange     `json:"changes"`
+}
+
+// FieldChange is one field's value before and after a change, as JSON. Field is the field's path in
+// the item's JSON, e.g. attributes.debtor.account.sort_code or transitions.0.to. A field that did
+// not exist on one side is null there.
+type FieldChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// unaudited are the fields left out of audit diffs: the version is recorded on the entry itself,
+// and the net amount is computed from other items, so it changes without the item changing
+var unaudited = []string{"version", "net_amount"}
+
+// DiffItems returns the fields that differ between two states of an item, in path order, down to
+// the fields of nested objects and arrays. A nil item has no fields, so the diff of a created item
+// lists every field it was created with and that of a deleted item every field it had.
+func DiffItems(before, after *Item) ([]FieldChange, error) {
+	b, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]FieldChange, 0)
+	err = diffValues("", b, a, &changes)
+	return changes, err
+}
+
+// auditFields decodes an item's JSON into generic values, keeping numbers exactly as written
+func auditFields(item *Item) (map[string]any, error) {
+	fields := make(map[string]any)
+	if item == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, err
+	}
+	for _, field := range unaudited {
+		delete(fields, field)
+	}
+	return fields, nil
+}
+
+// diffValues appends the changes between two JSON values at path. Objects and arrays are compared
+// member by member, a missing one counting as empty, so that every change is to a single field.
+func diffValues(path string, before, after any, changes *[]FieldChange) error {
+	beforeObject, beforeIsObject := before.(map[string]any)
+	afterObject, afterIsObject := after.(map[string]any)
+	if beforeIsObject && (afterIsObject || after == nil) || afterIsObject && before == nil {
+		keys := make([]string, 0, len(beforeObject)+len(afterObject))
+		for key := range beforeObject {
+			keys = append(keys, key)
+		}
+		for key := range afterObject {
+			if _, ok := beforeObject[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			if err := diffValues(join(path, key), beforeObject[key], afterObject[key], changes); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	beforeArray, beforeIsArray := before.([]any)
+	afterArray, afterIsArray := after.([]any)
+	if beforeIsArray && (afterIsArray || after == nil) || afterIsArray && before == nil {
+		for i := range max(len(beforeArray), len(afterArray)) {
+			var b, a any
+			if i < len(beforeArray) {
+				b = beforeArray[i]
+			}
+			if i < len(afterArray) {
+				a = afterArray[i]
+			}
+			if err := diffValues(join(path, strconv.Itoa(i)), b, a, changes); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if reflect.DeepEqual(before, after) {
+		return nil
+	}
+	b, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+	a, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+	*changes = append(*changes, FieldChange{Field: path, Before: b, After: a})
+	return nil
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
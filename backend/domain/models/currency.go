@@ -0,0 +1,50 @@
+package models
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxCurrencyExponent is the most decimal places any supported currency's minor unit has
+const MaxCurrencyExponent = 4
+
+// iso4217CSV lists the active ISO 4217 currencies that have a minor unit
+//
+//go:embed iso4217.csv
+var iso4217CSV string
+
+// Currency is an ISO 4217 currency
+type Currency struct {
+	Code     string // alphabetic code, e.g. "GBP"
+	Numeric  string // numeric code, e.g. "826"
+	Exponent int    // decimal places in the minor unit, e.g. 2 for pence
+}
+
+var currencies = loadCurrencies(iso4217CSV)
+
+// LookupCurrency returns the ISO 4217 currency with an alphabetic code, ignoring case
+func LookupCurrency(code string) (Currency, bool) {
+	currency, ok := currencies[strings.ToUpper(code)]
+	return currency, ok
+}
+
+// loadCurrencies parses the embedded table; it panics on malformed rows as the table ships with the binary
+func loadCurrencies(data string) map[string]Currency {
+	rows, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("iso4217.csv: %v", err))
+	}
+
+	table := make(map[string]Currency, len(rows))
+	for _, row := range rows[1:] {
+		exp, err := strconv.Atoi(row[2])
+		if err != nil || exp < 0 || exp > MaxCurrencyExponent {
+			panic(fmt.Sprintf("iso4217.csv: invalid minor unit for %s", row[0]))
+		}
+		table[row[0]] = Currency{Code: row[0], Numeric: row[1], Exponent: exp}
+	}
+	return table
+}
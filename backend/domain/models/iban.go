@@ -0,0 +1,67 @@
+package models
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ibanRegistryCSV lists the length of IBANs in each country of the SWIFT IBAN registry
+//
+//go:embed iban_registry.csv
+var ibanRegistryCSV string
+
+var ibanLengths = loadIBANLengths(ibanRegistryCSV)
+
+// NormalizeIBAN converts an IBAN from its print format ("GB82 WEST 1234 …") to its electronic format:
+// upper case with no spaces
+func NormalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(iban), ""))
+}
+
+// ValidIBAN reports whether an IBAN, in print or electronic format, has the length registered for its
+// country and a correct mod-97 check (ISO 13616)
+func ValidIBAN(iban string) bool {
+	iban = NormalizeIBAN(iban)
+	if len(iban) < 4 || len(iban) != ibanLengths[iban[:2]] {
+		return false
+	}
+	if iban[2] < '0' || iban[2] > '9' || iban[3] < '0' || iban[3] > '9' {
+		return false
+	}
+
+	// Move the country code and check digits to the end, read letters as 10 to 35, and take the
+	// remainder one digit at a time so the number never overflows
+	remainder := 0
+	for _, r := range iban[4:] + iban[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			remainder = (remainder*10 + int(r-'0')) % 97
+		case r >= 'A' && r <= 'Z':
+			remainder = (remainder*100 + int(r-'A') + 10) % 97
+		default:
+			return false
+		}
+	}
+	return remainder == 1
+}
+
+// loadIBANLengths parses the embedded registry; it panics on malformed rows as the registry ships with the binary
+func loadIBANLengths(data string) map[string]int {
+	rows, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("iban_registry.csv: %v", err))
+	}
+
+	lengths := make(map[string]int, len(rows))
+	for _, row := range rows[1:] {
+		length, err := strconv.Atoi(row[1])
+		if err != nil || length < 5 {
+			panic(fmt.Sprintf("iban_registry.csv: invalid length for %s", row[0]))
+		}
+		lengths[row[0]] = length
+	}
+	return lengths
+}
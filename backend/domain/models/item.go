@@ -1,19 +1,83 @@
 package models
 
 import (
+	"encoding/json"
 	"go-test/backend/domain/enums"
 	"time"
 )
 
 type Item struct {
-	GUID       string           `json:"guid"`
-	Index      int              `json:"index"`
-	Amount     float64          `json:"amount" binding:"required,gt=0"`
-	Type       enums.ItemType   `json:"type" binding:"required,itemtype"`
-	Status     enums.ItemStatus `json:"status" binding:"required,itemstatus"`
-	Created    time.Time        `json:"created"`
-	Attributes Attributes       `json:"attributes" binding:"required"`
+	GUID        string             `json:"guid"`
+	Index       int                `json:"index"`
+	Version     int                `json:"version"`
+	Amount      Money              `json:"amount"`
+	Type        enums.ItemType     `json:"type" binding:"required,itemtype"`
+	Status      enums.ItemStatus   `json:"status" binding:"required,itemstatus"`
+	Created     time.Time          `json:"created"`
+	Attributes  Attributes         `json:"attributes" binding:"required"`
+	Transitions []StatusTransition `json:"transitions,omitempty"`
+
+	// Tenant is the organisation the item belongs to, set by the store from the context the item is
+	// written with. No tenant can read or change another tenant's items.
+	Tenant string `json:"tenant,omitempty"`
+	// OriginalGUID is the item a REVERSAL reverses
+	OriginalGUID string `json:"original_guid,omitempty"`
+	// NetAmount is computed by the store on read for items other than reversals: the amount less
+	// every reversal of the item that has not been declined or returned. It is never stored.
+	NetAmount *Money `json:"net_amount,omitempty"`
+	// CreatedBy is the actor who created the item, who cannot approve it
+	CreatedBy string `json:"created_by,omitempty"`
+	// ChangedBy is the actor whose change to the amount, currency or parties last held the item for
+	// approval again, who cannot approve it either
+	ChangedBy string `json:"changed_by,omitempty"`
+	// RequiredApprovals is how many approvers other than its creator must approve the item before
+	// it leaves PENDING_APPROVAL; Approvals are their decisions, oldest first
+	RequiredApprovals int        `json:"required_approvals,omitempty"`
+	Approvals         []Approval `json:"approvals,omitempty"`
+	// DeletedAt is when the item was soft deleted. Deleted items are kept, and can be restored,
+	// until they are purged.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// IsDeleted reports whether the item has been soft deleted
+func (i Item) IsDeleted() bool {
+	return i.DeletedAt != nil
+}
+
+// itemJSON is an Item's wire format, which gives the amount's currency as a separate field
+type itemJSON struct {
+	itemFields
+	Currency string `json:"currency"`
+}
+
+type itemFields Item
+
+func (i Item) MarshalJSON() ([]byte, error) {
+	return json.Marshal(itemJSON{itemFields: itemFields(i), Currency: i.Amount.CurrencyCode()})
+}
+
+// UnmarshalJSON reads the currency first so that the amount is parsed in it; items written before
+// currencies existed are in DefaultCurrency
+func (i *Item) UnmarshalJSON(data []byte) error {
+	var head struct {
+		Currency  string          `json:"currency"`
+		NetAmount json.RawMessage `json:"net_amount"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return err
+	}
+
+	wire := itemJSON{itemFields: itemFields{Amount: Money{Currency: head.Currency}}}
+	if head.NetAmount != nil {
+		wire.NetAmount = &Money{Currency: head.Currency}
+	}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	*i = Item(wire.itemFields)
+	return nil
 }
+
 type Attributes struct {
 	Debtor      Party `json:"debtor" binding:"required"`
 	Beneficiary Party `json:"beneficiary" binding:"required"`
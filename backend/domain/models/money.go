@@ -0,0 +1,183 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency of amounts that do not name one
+const DefaultCurrency = "GBP"
+
+var ErrInvalidAmount = errors.New("invalid amount")
+
+var decimalRegex = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// Money is an exact amount of a currency held as an integer number of minor units (e.g. pence).
+// It is written to JSON as a decimal string such as "12.50".
+type Money struct {
+	Minor    int64
+	Currency string
+}
+
+// Decimal is a decimal number exactly as it was written in a request, whether as a JSON string or
+// number, so that it never passes through float64. It is validated and converted with ParseMoney.
+type Decimal string
+
+// NewMoney returns an amount of minor units of currency
+func NewMoney(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+// CurrencyExponent returns the number of decimal places in currency's minor unit
+func CurrencyExponent(currency string) (int, bool) {
+	c, ok := LookupCurrency(currency)
+	return c.Exponent, ok
+}
+
+// ParseMoney parses a decimal string such as "12.5" into minor units of currency.
+// It fails if the amount has more decimal places than the currency's minor unit, or is too large
+// to be Scaled.
+func ParseMoney(s, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	exp, ok := CurrencyExponent(currency)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: unknown currency %q", ErrInvalidAmount, currency)
+	}
+	minor, err := ParseDecimal(s, exp)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w for %s", err, currency)
+	}
+	if _, ok := scaleMinor(minor, exp); !ok {
+		return Money{}, fmt.Errorf("%w: %q is out of range for %s", ErrInvalidAmount, s, currency)
+	}
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// ParseDecimal parses a decimal string such as "12.5" into an integer count of 10^-places units.
+// It fails if the string has more than places decimal places.
+func ParseDecimal(s string, places int) (int64, error) {
+	if !decimalRegex.MatchString(s) {
+		return 0, fmt.Errorf("%w: %q is not a decimal number", ErrInvalidAmount, s)
+	}
+
+	whole, frac, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	if len(frac) > places {
+		return 0, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidAmount, s, places)
+	}
+
+	n, err := strconv.ParseInt(whole+frac+strings.Repeat("0", places-len(frac)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is out of range", ErrInvalidAmount, s)
+	}
+	if strings.HasPrefix(s, "-") {
+		n = -n
+	}
+	return n, nil
+}
+
+// String formats the amount as a decimal with the currency's number of decimal places
+func (m Money) String() string {
+	exp, ok := CurrencyExponent(m.CurrencyCode())
+	if !ok || exp == 0 {
+		return strconv.FormatInt(m.Minor, 10)
+	}
+
+	digits := strconv.FormatInt(m.Minor, 10)
+	sign := ""
+	if m.Minor < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// Scaled returns the amount in units of 10^-MaxCurrencyExponent of its major unit, which orders
+// amounts in different currencies by face value. It is exact for every amount ParseMoney accepts.
+func (m Money) Scaled() int64 {
+	exp, _ := CurrencyExponent(m.CurrencyCode())
+	scaled, _ := scaleMinor(m.Minor, exp)
+	return scaled
+}
+
+// scaleMinor converts minor units of a currency with exp decimal places into units of
+// 10^-MaxCurrencyExponent, reporting whether the result fits in an int64
+func scaleMinor(minor int64, exp int) (int64, bool) {
+	for ; exp < MaxCurrencyExponent; exp++ {
+		if minor > math.MaxInt64/10 || minor < math.MinInt64/10 {
+			return 0, false
+		}
+		minor *= 10
+	}
+	return minor, true
+}
+
+// IsPositive reports whether the amount is greater than zero
+func (m Money) IsPositive() bool {
+	return m.Minor > 0
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON reads a decimal string in m's currency (DefaultCurrency if unset). Plain JSON
+// numbers are also accepted for data written before amounts were exact, rounded to the minor unit.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	currency := m.CurrencyCode()
+
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		parsed, err := ParseMoney(s, currency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+
+	exp, ok := CurrencyExponent(currency)
+	r, isNumber := new(big.Rat).SetString(string(bytes.TrimSpace(data)))
+	if !ok || !isNumber {
+		return fmt.Errorf("%w: %s", ErrInvalidAmount, data)
+	}
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)))
+	rounded, err := strconv.ParseInt(scaled.FloatString(0), 10, 64)
+	if _, ok := scaleMinor(rounded, exp); err != nil || !ok {
+		return fmt.Errorf("%w: %s is out of range", ErrInvalidAmount, data)
+	}
+	*m = Money{Minor: rounded, Currency: strings.ToUpper(currency)}
+	return nil
+}
+
+// CurrencyCode returns the amount's currency, or DefaultCurrency if it has none
+func (m Money) CurrencyCode() string {
+	if m.Currency == "" {
+		return DefaultCurrency
+	}
+	return m.Currency
+}
+
+// UnmarshalJSON keeps a JSON string's contents or a JSON number's literal text; anything else is kept
+// verbatim so that validation, rather than decoding, reports it against the field
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*d = Decimal(strings.TrimSpace(s))
+		return nil
+	}
+	*d = Decimal(data)
+	return nil
+}
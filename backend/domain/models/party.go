@@ -5,3 +5,9 @@ type Party struct {
 	LastName  string  `json:"last_name" binding:"required"`
 	Account   Account `json:"account" binding:"required"`
 }
+
+// SameAs reports whether two parties have the same name and account, ignoring the bank details
+// looked up for the account
+func (p Party) SameAs(q Party) bool {
+	return p.FirstName == q.FirstName && p.LastName == q.LastName && p.Account.SameAs(q.Account)
+}
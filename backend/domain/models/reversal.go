@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"go-test/backend/domain/enums"
+)
+
+var (
+	ErrReversalOfReversal = errors.New("a reversal cannot itself be reversed")
+	ErrOriginalVoid       = errors.New("the original item was declined or returned")
+	ErrOriginalUnapproved = errors.New("the original item is awaiting approval")
+	ErrReversalCurrency   = errors.New("a reversal must be in the original item's currency")
+	ErrReversalParties    = errors.New("a reversal's parties must mirror the original item's")
+	ErrFullyReversed      = errors.New("the original item has already been fully reversed")
+	ErrReversalExceeds    = errors.New("a reversal cannot exceed the original item's unreversed amount")
+)
+
+// CheckReversal reports whether reversal may reverse original, whose amount not yet reversed by
+// other reversals is unreversed. A reversal moves money back, so its debtor must be the original's
+// beneficiary and vice versa, in the same currency and for no more than remains unreversed.
+func CheckReversal(original, reversal Item, unreversed Money) error {
+	switch {
+	case original.Type == enums.REVERSAL:
+		return ErrReversalOfReversal
+	case original.Status.IsVoid():
+		return ErrOriginalVoid
+	case original.Status == enums.PENDING_APPROVAL:
+		return ErrOriginalUnapproved
+	case reversal.Amount.CurrencyCode() != original.Amount.CurrencyCode():
+		return ErrReversalCurrency
+	case !reversal.Attributes.Debtor.SameAs(original.Attributes.Beneficiary) || !reversal.Attributes.Beneficiary.SameAs(original.Attributes.Debtor):
+		return ErrReversalParties
+	case unreversed.Minor <= 0:
+		return ErrFullyReversed
+	case reversal.Amount.Minor > unreversed.Minor:
+		return ErrReversalExceeds
+	}
+	return nil
+}
+
+// Unreversed returns the part of the item's amount not reversed by reversals other than exclude,
+// from the NetAmount computed by the store
+func (i Item) Unreversed(exclude *Item) Money {
+	unreversed := i.Amount
+	if i.NetAmount != nil {
+		unreversed = *i.NetAmount
+	}
+	if exclude != nil && exclude.OriginalGUID == i.GUID && !exclude.Status.IsVoid() {
+		unreversed.Minor += exclude.Amount.Minor
+	}
+	return unreversed
+}
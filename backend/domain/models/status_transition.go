@@ -0,0 +1,45 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"go-test/backend/domain/enums"
+	"slices"
+	"time"
+)
+
+var ErrIllegalTransition = errors.New("illegal status transition")
+
+// StatusTransition records one move of an item through its status lifecycle
+type StatusTransition struct {
+	From   enums.ItemStatus `json:"from"`
+	To     enums.ItemStatus `json:"to"`
+	Actor  string           `json:"actor,omitempty"`
+	Reason string           `json:"reason,omitempty"`
+	At     time.Time        `json:"at"`
+}
+
+// Transition moves the item to status to and appends the move to its history.
+// It fails with ErrIllegalTransition if the lifecycle does not allow the move.
+func (i *Item) Transition(to enums.ItemStatus, actor, reason string, at time.Time) error {
+	if !i.Status.CanTransitionTo(to) {
+		return fmt.Errorf("%w from %s to %s", ErrIllegalTransition, i.Status, to)
+	}
+
+	i.move(to, actor, reason, at)
+	return nil
+}
+
+// move moves the item to status to and appends the move to its history, whether or not the
+// lifecycle allows it
+func (i *Item) move(to enums.ItemStatus, actor, reason string, at time.Time) {
+	// Clip so that appending never writes into an array shared with another copy of the item
+	i.Transitions = append(slices.Clip(i.Transitions), StatusTransition{
+		From:   i.Status,
+		To:     to,
+		Actor:  actor,
+		Reason: reason,
+		At:     at,
+	})
+	i.Status = to
+}
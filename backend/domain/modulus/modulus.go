@@ -0,0 +1,324 @@
+// Package modulus implements the VocaLink modulus checks that detect mistyped UK sort code and
+// account number pairs, driven by VocaLink's weight table (valacdos.txt) and sort code
+// substitution table (scsubtab.txt).
+package modulus
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Method is the checking algorithm a weight table row applies
+type Method string
+
+const (
+	MOD10 Method = "MOD10"
+	MOD11 Method = "MOD11"
+	DBLAL Method = "DBLAL" // double alternate: sums the digits of each product
+)
+
+// Rule is one row of the weight table: the check applied to a range of sort codes
+type Rule struct {
+	Start     string // first sort code in the range, six digits
+	End       string // last sort code in the range, six digits
+	Method    Method
+	Weights   [14]int // for the digits u v w x y z (sort code) and a b c d e f g h (account number)
+	Exception int     // VocaLink exception number, or 0
+}
+
+// Table is a weight table and the sort code substitutions used by exception 5
+type Table struct {
+	rules         []Rule // ordered by Start, keeping each range's rows in file order
+	substitutions map[string]string
+}
+
+// defaultWeights and defaultSubstitutions are a small sample of rows for development and tests.
+// Production deployments should load VocaLink's current files with Load.
+//
+//go:embed valacdos.txt
+var defaultWeights string
+
+//go:embed scsubtab.txt
+var defaultSubstitutions string
+
+var current atomic.Pointer[Table]
+
+func init() {
+	table, err := Parse(strings.NewReader(defaultWeights), strings.NewReader(defaultSubstitutions))
+	if err != nil {
+		panic(fmt.Sprintf("modulus: embedded tables: %v", err))
+	}
+	current.Store(table)
+}
+
+// Default returns the table the validators check against
+func Default() *Table {
+	return current.Load()
+}
+
+// SetDefault replaces the table the validators check against
+func SetDefault(table *Table) {
+	current.Store(table)
+}
+
+// Load reads a weight table file and, if substitutionsPath is not empty, a substitution table file
+func Load(weightsPath, substitutionsPath string) (*Table, error) {
+	weights, err := os.Open(weightsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer weights.Close()
+
+	var substitutions io.Reader = strings.NewReader("")
+	if substitutionsPath != "" {
+		f, err := os.Open(substitutionsPath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		substitutions = f
+	}
+	return Parse(weights, substitutions)
+}
+
+// Parse reads a weight table with rows of "start end method w1 … w14 [exception]" and a substitution
+// table with rows of "sortcode substitute", both separated by whitespace
+func Parse(weights, substitutions io.Reader) (*Table, error) {
+	table := &Table{substitutions: make(map[string]string)}
+
+	err := eachRow(weights, func(line int, fields []string) error {
+		if len(fields) != 17 && len(fields) != 18 {
+			return fmt.Errorf("weight table line %d: expected 17 or 18 fields, got %d", line, len(fields))
+		}
+
+		rule := Rule{Start: fields[0], End: fields[1], Method: Method(strings.ToUpper(fields[2]))}
+		if !isDigits(rule.Start, 6) || !isDigits(rule.End, 6) || rule.Start > rule.End {
+			return fmt.Errorf("weight table line %d: invalid sort code range %s-%s", line, rule.Start, rule.End)
+		}
+		if rule.Method != MOD10 && rule.Method != MOD11 && rule.Method != DBLAL {
+			return fmt.Errorf("weight table line %d: unknown method %q", line, fields[2])
+		}
+		for i := range rule.Weights {
+			w, err := strconv.Atoi(fields[3+i])
+			if err != nil {
+				return fmt.Errorf("weight table line %d: invalid weight %q", line, fields[3+i])
+			}
+			rule.Weights[i] = w
+		}
+		if len(fields) == 18 {
+			ex, err := strconv.Atoi(fields[17])
+			if err != nil {
+				return fmt.Errorf("weight table line %d: invalid exception %q", line, fields[17])
+			}
+			rule.Exception = ex
+		}
+
+		table.rules = append(table.rules, rule)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = eachRow(substitutions, func(line int, fields []string) error {
+		if len(fields) != 2 || !isDigits(fields[0], 6) || !isDigits(fields[1], 6) {
+			return fmt.Errorf("substitution table line %d: expected two sort codes", line)
+		}
+		table.substitutions[fields[0]] = fields[1]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(table.rules, func(i, j int) bool {
+		return table.rules[i].Start < table.rules[j].Start
+	})
+	return table, nil
+}
+
+func eachRow(r io.Reader, fn func(line int, fields []string) error) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if err := fn(line, fields); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Valid reports whether an account number passes the modulus checks for its sort code, which may be
+// written with or without dashes. Sort codes the table does not cover cannot be checked and are
+// always valid.
+func (t *Table) Valid(sortCode, accountNumber string) bool {
+	sortCode = strings.ReplaceAll(sortCode, "-", "")
+	rules := t.rulesFor(sortCode)
+	if len(rules) == 0 {
+		return true
+	}
+	if !isDigits(sortCode, 6) || !isDigits(accountNumber, 8) {
+		return false
+	}
+
+	number := sortCode + accountNumber
+	// Exception 6: accounts in a foreign currency cannot be checked
+	for _, rule := range rules {
+		if rule.Exception == 6 && number[a] >= '4' && number[a] <= '8' && number[g] == number[h] {
+			return true
+		}
+	}
+
+	first := rules[0]
+	firstValid := t.check(first, number)
+	if len(rules) == 1 {
+		if !firstValid && first.Exception == 14 {
+			return t.checkShifted(first, number)
+		}
+		return firstValid
+	}
+
+	second := rules[1]
+	switch {
+	case first.Exception == 2 && second.Exception == 9:
+		// Exception 9: if the first check fails, check again as sort code 309634
+		return firstValid || t.check(second, "309634"+accountNumber)
+	case first.Exception == 10 && second.Exception == 11, first.Exception == 12 && second.Exception == 13:
+		return firstValid || t.check(second, number)
+	case second.Exception == 3 && (number[c] == '6' || number[c] == '9'):
+		return firstValid
+	}
+	return firstValid && t.check(second, number)
+}
+
+// Positions of the digits u v w x y z a b c d e f g h in a sort code followed by an account number
+const (
+	u = 0
+	a = 6
+	b = 7
+	c = 8
+	g = 12
+	h = 13
+)
+
+// rulesFor returns the (at most two) rows covering a sort code
+func (t *Table) rulesFor(sortCode string) []Rule {
+	var rules []Rule
+	for _, rule := range t.rules {
+		if rule.Start > sortCode {
+			break
+		}
+		if sortCode <= rule.End {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// check applies one row to the 14 digits of a sort code and account number
+func (t *Table) check(rule Rule, number string) bool {
+	weights := rule.Weights
+
+	switch rule.Exception {
+	case 2:
+		if number[a] != '0' {
+			if number[g] == '9' {
+				weights = [14]int{0, 0, 0, 0, 0, 0, 0, 0, 8, 7, 10, 9, 3, 1}
+			} else {
+				weights = [14]int{0, 0, 1, 2, 5, 3, 6, 4, 8, 7, 10, 9, 3, 1}
+			}
+		}
+	case 5:
+		if substitute, ok := t.substitutions[number[:a]]; ok {
+			number = substitute + number[a:]
+		}
+	case 7:
+		if number[g] == '9' {
+			zeroUToB(&weights)
+		}
+	case 8:
+		number = "090126" + number[a:]
+	case 10:
+		if ab := number[a : b+1]; (ab == "09" || ab == "99") && number[g] == '9' {
+			zeroUToB(&weights)
+		}
+	}
+
+	total := 0
+	for i, w := range weights {
+		product := int(number[i]-'0') * w
+		if rule.Method == DBLAL {
+			total += product/10 + product%10
+		} else {
+			total += product
+		}
+	}
+
+	switch rule.Method {
+	case DBLAL:
+		if rule.Exception == 1 {
+			total += 27
+		}
+		if rule.Exception == 5 {
+			return checkDigit(total%10, 10, number[h])
+		}
+		return total%10 == 0
+	case MOD11:
+		if rule.Exception == 4 {
+			gh, _ := strconv.Atoi(number[g:])
+			return total%11 == gh
+		}
+		if rule.Exception == 5 {
+			return total%11 != 1 && checkDigit(total%11, 11, number[g])
+		}
+		return total%11 == 0
+	default:
+		return total%10 == 0
+	}
+}
+
+// checkShifted applies exception 14 after a failed check: accounts ending in 0, 1 or 9 are checked
+// again with that digit removed and the rest shifted right behind a leading 0
+func (t *Table) checkShifted(rule Rule, number string) bool {
+	if !strings.ContainsRune("019", rune(number[h])) {
+		return false
+	}
+	return t.check(rule, number[:a]+"0"+number[a:h])
+}
+
+// checkDigit reports whether digit is the check digit for a remainder: 0 for no remainder,
+// otherwise modulus less the remainder
+func checkDigit(remainder, modulus int, digit byte) bool {
+	if remainder == 0 {
+		return digit == '0'
+	}
+	return modulus-remainder == int(digit-'0')
+}
+
+func zeroUToB(weights *[14]int) {
+	for i := u; i <= b; i++ {
+		weights[i] = 0
+	}
+}
+
+func isDigits(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,155 @@
+// Package sortcodes looks up the bank branch behind a UK sort code, and the payment schemes it
+// takes part in, from a comma-separated extract of the Extended Industry Sort Code Directory (EISCD).
+package sortcodes
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// columns are the directory columns Parse reads, named by the header row; any others are ignored
+var columns = []string{"sort_code", "bank_name", "branch_name", "bacs", "fps", "chaps"}
+
+// schemeColumns hold each scheme's EISCD status for the branch, such as M (member) or A (agency)
+var schemeColumns = []struct {
+	column string
+	scheme enums.PaymentScheme
+}{
+	{"bacs", enums.BACS},
+	{"fps", enums.FPS},
+	{"chaps", enums.CHAPS},
+}
+
+// Directory maps six-digit sort codes to the branches they identify
+type Directory struct {
+	branches map[string]models.Bank
+}
+
+// defaultDirectory is a small sample of branches for development and tests.
+// Production deployments should load a current EISCD extract with Load.
+//
+//go:embed eiscd.csv
+var defaultDirectory string
+
+var current atomic.Pointer[Directory]
+
+func init() {
+	directory, err := Parse(strings.NewReader(defaultDirectory))
+	if err != nil {
+		panic(fmt.Sprintf("sortcodes: embedded directory: %v", err))
+	}
+	current.Store(directory)
+}
+
+// Default returns the directory accounts are looked up in
+func Default() *Directory {
+	return current.Load()
+}
+
+// SetDefault replaces the directory accounts are looked up in
+func SetDefault(directory *Directory) {
+	current.Store(directory)
+}
+
+// Load reads a directory file
+func Load(path string) (*Directory, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads a directory with a header row naming its columns, followed by one row per sort code.
+// A scheme is supported unless its status is empty or N. Lines starting with # are skipped.
+func Parse(r io.Reader) (*Directory, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("directory: missing header row")
+	} else if err != nil {
+		return nil, err
+	}
+
+	positions := make(map[string]int, len(columns))
+	for i, name := range header {
+		positions[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, column := range columns {
+		if _, ok := positions[column]; !ok {
+			return nil, fmt.Errorf("directory: missing %s column", column)
+		}
+	}
+
+	directory := &Directory{branches: make(map[string]models.Bank)}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(column string) string {
+			if i := positions[column]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		sortCode := normalize(field("sort_code"))
+		if !isSortCode(sortCode) {
+			return nil, fmt.Errorf("directory line %d: invalid sort code %q", line, field("sort_code"))
+		}
+		if _, ok := directory.branches[sortCode]; ok {
+			return nil, fmt.Errorf("directory line %d: duplicate sort code %s", line, field("sort_code"))
+		}
+
+		bank := models.Bank{Name: field("bank_name"), Branch: field("branch_name"), Schemes: []enums.PaymentScheme{}}
+		for _, s := range schemeColumns {
+			if status := strings.ToUpper(field(s.column)); status != "" && status != "N" {
+				bank.Schemes = append(bank.Schemes, s.scheme)
+			}
+		}
+		directory.branches[sortCode] = bank
+	}
+	return directory, nil
+}
+
+// Lookup returns the branch a sort code, written with or without dashes, identifies
+func (d *Directory) Lookup(sortCode string) (models.Bank, bool) {
+	bank, ok := d.branches[normalize(sortCode)]
+	bank.Schemes = slices.Clone(bank.Schemes)
+	return bank, ok
+}
+
+func normalize(sortCode string) string {
+	return strings.ReplaceAll(sortCode, "-", "")
+}
+
+func isSortCode(s string) bool {
+	if len(s) != 6 {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -1,9 +1,15 @@
 package validators
 
 import (
+	"go-test/backend/domain/dto"
 	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"go-test/backend/domain/modulus"
+	"go-test/backend/domain/sortcodes"
+	"reflect"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -15,8 +21,27 @@ var validItemTypes = map[enums.ItemType]bool{
 }
 
 var validItemStatuses = map[enums.ItemStatus]bool{
-	enums.ACCEPTED: true,
-	enums.DECLINED: true,
+	enums.PENDING_APPROVAL: true,
+	enums.PENDING:          true,
+	enums.ACCEPTED:         true,
+	enums.DECLINED:         true,
+	enums.SETTLED:          true,
+	enums.RETURNED:         true,
+}
+
+var validAccountTypes = map[enums.AccountType]bool{
+	enums.UKAccount:   true,
+	enums.IBANAccount: true,
+}
+
+var validSortFields = map[enums.SortField]bool{
+	enums.SortIndex:           true,
+	enums.SortAmount:          true,
+	enums.SortCreated:         true,
+	enums.SortType:            true,
+	enums.SortStatus:          true,
+	enums.SortDebtorName:      true,
+	enums.SortBeneficiaryName: true,
 }
 
 func ValidateItemType(fl validator.FieldLevel) bool {
@@ -33,9 +58,207 @@ func ValidateItemStatus(fl validator.FieldLevel) bool {
 	return ok
 }
 
+// ValidateInitialItemStatus validates a status that an item may be created with, ignoring case
+func ValidateInitialItemStatus(fl validator.FieldLevel) bool {
+	return enums.ItemStatus(strings.ToUpper(fl.Field().String())).IsInitial()
+}
+
+var sortCodeRegex = regexp.MustCompile(`^\d{2}-\d{2}-\d{2}$`)
+
 // ValidateSortCode validates sort code format (00-00-00)
 func ValidateSortCode(fl validator.FieldLevel) bool {
 	sortCode := fl.Field().String()
-	sortCodeRegex := regexp.MustCompile(`^\d{2}-\d{2}-\d{2}$`)
 	return sortCodeRegex.MatchString(sortCode)
 }
+
+// ValidateKnownSortCode validates that a sort code is listed in the sort code directory
+func ValidateKnownSortCode(fl validator.FieldLevel) bool {
+	_, ok := sortcodes.Default().Lookup(fl.Field().String())
+	return ok
+}
+
+// ValidateAccountType validates an account type, ignoring case; accounts without one are UK accounts
+func ValidateAccountType(fl validator.FieldLevel) bool {
+	_, ok := validAccountTypes[enums.AccountType(strings.ToUpper(fl.Field().String()))]
+	return ok || fl.Field().String() == ""
+}
+
+// ValidateRequiredFor validates that a field is set when its account is of the type given as the
+// parameter, e.g. requiredfor=UK. Accounts of an unknown type are left to ValidateAccountType.
+func ValidateRequiredFor(fl validator.FieldLevel) bool {
+	return siblingAccountType(fl) != enums.AccountType(fl.Param()) || !fl.Field().IsZero()
+}
+
+// ValidateOnlyFor validates that a field is only set when its account is of the type given as the
+// parameter, e.g. onlyfor=IBAN. Accounts of an unknown type are left to ValidateAccountType.
+func ValidateOnlyFor(fl validator.FieldLevel) bool {
+	accountType := siblingAccountType(fl)
+	return !validAccountTypes[accountType] || accountType == enums.AccountType(fl.Param()) || fl.Field().IsZero()
+}
+
+// siblingAccountType is the type of the account a field belongs to, from its AccountType field
+func siblingAccountType(fl validator.FieldLevel) enums.AccountType {
+	accountType := enums.AccountType(strings.ToUpper(fl.Parent().FieldByName("AccountType").String()))
+	if accountType == "" {
+		return enums.UKAccount
+	}
+	return accountType
+}
+
+// ValidateIBAN validates an IBAN's length for its country and its mod-97 check digits, in print or
+// electronic format
+func ValidateIBAN(fl validator.FieldLevel) bool {
+	return models.ValidIBAN(fl.Field().String())
+}
+
+var bicRegex = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+
+// ValidateBIC validates a BIC (ISO 9362): a four letter institution code, two letter country code,
+// two character location code and optional three character branch code, ignoring case
+func ValidateBIC(fl validator.FieldLevel) bool {
+	return bicRegex.MatchString(strings.ToUpper(fl.Field().String()))
+}
+
+var serviceUserNumberRegex = regexp.MustCompile(`^\d{6}$`)
+
+// ValidateServiceUserNumber validates the six digit number Bacs identifies a service user by
+func ValidateServiceUserNumber(fl validator.FieldLevel) bool {
+	return serviceUserNumberRegex.MatchString(fl.Field().String())
+}
+
+var bacsSerialRegex = regexp.MustCompile(`^[A-Za-z0-9]{6}$`)
+
+// ValidateBacsSerial validates the six letters or digits a Bacs file is numbered with
+func ValidateBacsSerial(fl validator.FieldLevel) bool {
+	return bacsSerialRegex.MatchString(fl.Field().String())
+}
+
+// ValidateAccount runs the VocaLink modulus checks on a well-formed UK sort code and account number.
+// Malformed values and other types of account are left to the field validators to report.
+func ValidateAccount(sl validator.StructLevel) {
+	account := sl.Current().Interface().(models.Account)
+	if !account.IsUK() || !sortCodeRegex.MatchString(account.SortCode) || len(account.AccountNumber) != 8 {
+		return
+	}
+
+	if !modulus.Default().Valid(account.SortCode, account.AccountNumber) {
+		sl.ReportError(account.AccountNumber, "AccountNumber", "AccountNumber", "modulus", "")
+	}
+}
+
+// ValidateItemSort validates a sort spec such as "-amount,created": known keys, each used once
+func ValidateItemSort(fl validator.FieldLevel) bool {
+	seen := make(map[enums.SortField]bool)
+	for _, part := range strings.Split(fl.Field().String(), ",") {
+		field := enums.SortField(strings.ToLower(strings.TrimLeft(strings.TrimSpace(part), "+-")))
+		if !validSortFields[field] || seen[field] {
+			return false
+		}
+		seen[field] = true
+	}
+	return true
+}
+
+// ValidateCurrency validates an ISO 4217 alphabetic currency code, ignoring case
+func ValidateCurrency(fl validator.FieldLevel) bool {
+	_, ok := models.LookupCurrency(fl.Field().String())
+	return ok
+}
+
+// ValidateMoney validates a decimal amount with no more decimal places than the minor unit of the
+// currency in the sibling Currency field. When that currency is not known yet (a partial update that
+// leaves it unchanged) any supported number of decimal places is accepted.
+func ValidateMoney(fl validator.FieldLevel) bool {
+	currency, known := siblingCurrency(fl)
+	if !known {
+		_, err := models.ParseDecimal(fl.Field().String(), models.MaxCurrencyExponent)
+		return err == nil
+	}
+	_, err := models.ParseMoney(fl.Field().String(), currency)
+	return err == nil
+}
+
+// ValidatePositive validates that a decimal amount is greater than zero
+func ValidatePositive(fl validator.FieldLevel) bool {
+	amount, err := models.ParseDecimal(fl.Field().String(), models.MaxCurrencyExponent)
+	return err == nil && amount > 0
+}
+
+// siblingCurrency finds the currency an amount is given in from its struct's Currency field: a
+// single currency, or DefaultCurrency if none or several are given. A nil pointer means unknown.
+func siblingCurrency(fl validator.FieldLevel) (string, bool) {
+	field := fl.Parent().FieldByName("Currency")
+	switch field.Kind() {
+	case reflect.Pointer:
+		if field.IsNil() {
+			return "", false
+		}
+		field = field.Elem()
+	case reflect.Slice:
+		if field.Len() != 1 {
+			return models.DefaultCurrency, true
+		}
+		field = field.Index(0)
+	}
+	if field.Kind() == reflect.String && field.String() != "" {
+		return field.String(), true
+	}
+	return models.DefaultCurrency, true
+}
+
+// FilterCurrency is the currency a list filter's amount bounds are given in
+func FilterCurrency(filter dto.ItemFilterDTO) string {
+	if len(filter.Currency) == 1 {
+		return filter.Currency[0]
+	}
+	return models.DefaultCurrency
+}
+
+// ParseISODate parses either a calendar date (2006-01-02, as UTC midnight) or an RFC 3339 timestamp.
+// dateOnly reports which form was given so callers can widen a date to the whole day.
+func ParseISODate(value string) (t time.Time, dateOnly bool, err error) {
+	if t, err = time.Parse(time.DateOnly, value); err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+// ValidateISODate validates a date (2006-01-02) or RFC 3339 timestamp
+func ValidateISODate(fl validator.FieldLevel) bool {
+	_, _, err := ParseISODate(fl.Field().String())
+	return err == nil
+}
+
+// ValidateItemFilter checks that the amount and created ranges of a list filter are not inverted
+func ValidateItemFilter(sl validator.StructLevel) {
+	filter := sl.Current().Interface().(dto.ItemFilterDTO)
+
+	if filter.AmountMin != "" && filter.AmountMax != "" {
+		minAmount, errMin := models.ParseMoney(filter.AmountMin, FilterCurrency(filter))
+		maxAmount, errMax := models.ParseMoney(filter.AmountMax, FilterCurrency(filter))
+		if errMin == nil && errMax == nil && minAmount.Minor > maxAmount.Minor {
+			sl.ReportError(filter.AmountMax, "AmountMax", "AmountMax", "amountrange", "")
+		}
+	}
+
+	if filter.CreatedFrom != "" && filter.CreatedTo != "" {
+		from, _, errFrom := ParseISODate(filter.CreatedFrom)
+		to, _, errTo := ParseISODate(filter.CreatedTo)
+		if errFrom == nil && errTo == nil && from.After(to) {
+			sl.ReportError(filter.CreatedTo, "CreatedTo", "CreatedTo", "daterange", "")
+		}
+	}
+}
+
+// ValidateItemCreate checks that reversals, and only reversals, reference the item they reverse
+func ValidateItemCreate(sl validator.StructLevel) {
+	item := sl.Current().Interface().(dto.ItemCreateDTO)
+
+	isReversal := enums.ItemType(strings.ToUpper(string(item.Type))) == enums.REVERSAL
+	if isReversal && strings.TrimSpace(item.OriginalGUID) == "" {
+		sl.ReportError(item.OriginalGUID, "OriginalGUID", "OriginalGUID", "required", "")
+	} else if !isReversal && item.OriginalGUID != "" {
+		sl.ReportError(item.OriginalGUID, "OriginalGUID", "OriginalGUID", "reversalonly", "")
+	}
+}
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"go-test/backend/helpers"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Health answers liveness checks, which are not authenticated
+func Health(c *gin.Context) {
+	helpers.Respond(c, http.StatusOK, gin.H{"status": "ok"})
+}
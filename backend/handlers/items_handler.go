@@ -1,28 +1,65 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"go-test/backend/domain/approval"
+	"go-test/backend/domain/bacs"
 	"go-test/backend/domain/dto"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/iso20022"
+	"go-test/backend/domain/models"
 	"go-test/backend/helpers"
 	"go-test/backend/repository"
+	"io"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
+// maxBatchItems is the most items one batch request may create
+const maxBatchItems = 1000
+
+// maxImportRows is the most rows one CSV import may contain
+const maxImportRows = 100000
+
+// exportPageSize is how many items an export reads from the store at a time
+const exportPageSize = 500
+
+// reapprovalReason is recorded as the reason an update holds an item for approval again
+const reapprovalReason = "Amount, currency or parties changed"
+
 type ItemsHandler struct {
 	storage repository.ItemsStorage
+	// approvals decide how many approvers each new or changed item needs
+	approvals *approval.Rules
+	// reversals serialises writes that check an item's reversals against it, which the stores
+	// cannot check atomically themselves
+	reversals sync.Mutex
 }
 
-func NewItemsHandler(storage repository.ItemsStorage) *ItemsHandler {
+func NewItemsHandler(storage repository.ItemsStorage, approvals *approval.Rules) *ItemsHandler {
 	return &ItemsHandler{
-		storage: storage,
+		storage:   storage,
+		approvals: approvals,
 	}
 }
 
 func (h *ItemsHandler) GetAll(c *gin.Context) {
-	query := strings.TrimSpace(c.Query("query"))
+	var filterDTO dto.ItemFilterDTO
+	if err := c.ShouldBindQuery(&filterDTO); err != nil {
+		helpers.ValidationErrorResponse(c, err)
+		return
+	}
 
 	limit, err := helpers.ParseLimit(c.Query("limit"), 10)
 	if err != nil {
@@ -30,15 +67,88 @@ func (h *ItemsHandler) GetAll(c *gin.Context) {
 		return
 	}
 
-	items, err := h.storage.GetAllFiltered(query, limit)
+	cursor, err := repository.DecodeCursor(c.Query("cursor"))
 	if err != nil {
+		helpers.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := helpers.NewItemQueryFromDTO(filterDTO)
+	query.Limit = limit
+	query.Cursor = cursor
+
+	page, err := h.storage.GetAllFiltered(c.Request.Context(), query)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		helpers.Error(c, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
 		helpers.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	helpers.Respond(c, http.StatusOK, items)
+	helpers.Respond(c, http.StatusOK, dto.ItemListResponse{
+		Data:       page.Items,
+		Total:      page.Total,
+		Totals:     helpers.NewCurrencyTotals(page.Totals),
+		NextCursor: repository.EncodeCursor(page.NextCursor),
+		PrevCursor: repository.EncodeCursor(page.PrevCursor),
+	})
 }
 
+// Export streams every item matching the list filters, in the list order, as CSV, NDJSON or a JSON
+// array. Items are read from the store a page at a time and written as they are read, so the
+// response is never held in memory; an error after the first page can only truncate it.
+func (h *ItemsHandler) Export(c *gin.Context) {
+	var filterDTO dto.ItemFilterDTO
+	if err := c.ShouldBindQuery(&filterDTO); err != nil {
+		helpers.ValidationErrorResponse(c, err)
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", "json"))
+	encoder, ok := helpers.NewItemEncoder(format, c.Writer)
+	if !ok {
+		helpers.Error(c, http.StatusBadRequest, "invalid format parameter. Must be csv, ndjson or json")
+		return
+	}
+
+	query := helpers.NewItemQueryFromDTO(filterDTO)
+	query.Limit = exportPageSize
+
+	page, err := h.storage.GetAllFiltered(c.Request.Context(), query)
+	if err != nil {
+		helpers.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.Header("Content-Type", helpers.ExportContentTypes[format])
+	c.Header("Content-Disposition", `attachment; filename="items.`+format+`"`)
+	c.Status(http.StatusOK)
+
+	for {
+		for _, item := range page.Items {
+			if err := encoder.Encode(item); err != nil {
+				_ = c.Error(err)
+				return
+			}
+		}
+		c.Writer.Flush()
+
+		if page.NextCursor == nil {
+			break
+		}
+		query.Cursor = page.NextCursor
+		if page, err = h.storage.GetAllFiltered(c.Request.Context(), query); err != nil {
+			_ = c.Error(err)
+			return
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// GetByGUID fetches an item; a deleted item is only found with include_deleted=true
 func (h *ItemsHandler) GetByGUID(c *gin.Context) {
 	guid := c.Param("guid")
 
@@ -47,7 +157,19 @@ func (h *ItemsHandler) GetByGUID(c *gin.Context) {
 		return
 	}
 
-	item, err := h.storage.GetByGUID(guid)
+	includeDeleted := false
+	if value := c.Query("include_deleted"); value != "" {
+		var err error
+		if includeDeleted, err = strconv.ParseBool(value); err != nil {
+			helpers.Error(c, http.StatusBadRequest, "invalid include_deleted parameter")
+			return
+		}
+	}
+
+	item, err := h.storage.GetByGUID(c.Request.Context(), guid)
+	if errors.Is(err, repository.ErrNotFound) && includeDeleted {
+		item, err = h.storage.GetDeletedByGUID(c.Request.Context(), guid)
+	}
 	if errors.Is(err, repository.ErrNotFound) {
 		helpers.Error(c, http.StatusNotFound, "Item not found")
 		return
@@ -56,6 +178,7 @@ func (h *ItemsHandler) GetByGUID(c *gin.Context) {
 		return
 	}
 
+	helpers.SetETag(c, item.Version)
 	helpers.Respond(c, http.StatusOK, *item)
 }
 
@@ -68,27 +191,532 @@ func (h *ItemsHandler) Create(c *gin.Context) {
 		return
 	}
 
-	count, err := h.storage.Count()
+	item := helpers.NewItemFromDTO(createDTO)
+	ctx := helpers.ChangeContext(c)
+	if status, message := h.awaitApproval(ctx, item); status != 0 {
+		helpers.Error(c, status, message)
+		return
+	}
+
+	if item.OriginalGUID != "" {
+		h.reversals.Lock()
+		defer h.reversals.Unlock()
+
+		if status, message := h.newReversalProblem(ctx, *item, 0); status != 0 {
+			helpers.Error(c, status, message)
+			return
+		}
+	}
+
+	if err := h.storage.Create(ctx, item); err != nil {
+		helpers.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helpers.SetETag(c, item.Version)
+	helpers.Respond(c, http.StatusCreated, *item)
+}
+
+// CollectionMethod dispatches custom methods on the item collection, which are routed as
+// POST /items:method; the method name arrives with its leading colon
+func (h *ItemsHandler) CollectionMethod(c *gin.Context) {
+	switch c.Param("method") {
+	case ":batch":
+		h.Batch(c)
+	case ":bacs":
+		h.Bacs(c)
+	case ":pain001":
+		h.Pain001(c)
+	case ":pacs002":
+		h.Pacs002(c)
+	default:
+		helpers.Error(c, http.StatusNotFound, "Method not found")
+	}
+}
+
+// Batch creates several items from a JSON array, validating each exactly as Create does, and reports
+// the outcome of each at its position in the array. By default every valid item is created; with
+// atomic=true the items are only created if all of them can be, and none are otherwise.
+func (h *ItemsHandler) Batch(c *gin.Context) {
+	atomic := false
+	if value := c.Query("atomic"); value != "" {
+		var err error
+		if atomic, err = strconv.ParseBool(value); err != nil {
+			helpers.Error(c, http.StatusBadRequest, "invalid atomic parameter")
+			return
+		}
+	}
+
+	var rows []json.RawMessage
+	if err := c.ShouldBindJSON(&rows); err != nil {
+		helpers.Error(c, http.StatusBadRequest, "Request body must be a JSON array of items")
+		return
+	}
+	if len(rows) == 0 {
+		helpers.Error(c, http.StatusBadRequest, "Batch must contain at least one item")
+		return
+	}
+	if len(rows) > maxBatchItems {
+		helpers.Error(c, http.StatusBadRequest, "Batch cannot contain more than "+strconv.Itoa(maxBatchItems)+" items")
+		return
+	}
+
+	h.reversals.Lock()
+	defer h.reversals.Unlock()
+
+	ctx := helpers.ChangeContext(c)
+	response := dto.ItemBatchResponse{Results: make([]dto.ItemBatchResult, len(rows))}
+	items := make([]*models.Item, 0, len(rows))
+	positions := make([]int, 0, len(rows))
+	reversed := make(map[string]int64) // minor units reversed by earlier items of the batch, by original GUID
+	for i, row := range rows {
+		result := &response.Results[i]
+		result.Index = i
+
+		var createDTO dto.ItemCreateDTO
+		if err := binding.JSON.BindBody(row, &createDTO); err != nil {
+			result.Status, result.Errors = http.StatusBadRequest, helpers.ValidationErrors(err)
+			continue
+		}
+
+		item := helpers.NewItemFromDTO(createDTO)
+		if status, message := h.awaitApproval(ctx, item); status != 0 {
+			result.Status, result.Error = status, message
+			continue
+		}
+		if item.OriginalGUID != "" {
+			if status, message := h.newReversalProblem(ctx, *item, reversed[item.OriginalGUID]); status != 0 {
+				result.Status, result.Error = status, message
+				continue
+			}
+			reversed[item.OriginalGUID] += item.Amount.Minor
+		}
+		items = append(items, item)
+		positions = append(positions, i)
+	}
+
+	if atomic {
+		if len(items) < len(rows) {
+			for _, i := range positions {
+				response.Results[i].Status = http.StatusFailedDependency
+				response.Results[i].Error = "Not created because other items in the batch failed"
+			}
+			response.Failed = len(rows)
+			helpers.Respond(c, http.StatusUnprocessableEntity, response)
+			return
+		}
+		if err := h.storage.CreateAll(ctx, items); err != nil {
+			helpers.Error(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	for n, item := range items {
+		result := &response.Results[positions[n]]
+		if !atomic {
+			if err := h.storage.Create(ctx, item); err != nil {
+				result.Status, result.Error = http.StatusInternalServerError, err.Error()
+				continue
+			}
+		}
+		result.Status, result.Item = http.StatusCreated, item
+		response.Created++
+	}
+	response.Failed = len(rows) - response.Created
+
+	status := http.StatusCreated
+	if response.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	helpers.Respond(c, status, response)
+}
+
+// Bacs writes a Bacs Standard 18 file paying the selected items, which must all be ACCEPTED UK
+// payments in GBP. Nothing is written if any item cannot be paid; the problem with each such item
+// is reported by GUID instead. Writing the file does not change the items.
+func (h *ItemsHandler) Bacs(c *gin.Context) {
+	var bacsDTO dto.ItemBacsDTO
+	if err := c.ShouldBindJSON(&bacsDTO); err != nil {
+		helpers.ValidationErrorResponse(c, err)
+		return
+	}
+	if len(bacsDTO.GUIDs) == 0 {
+		helpers.Error(c, http.StatusBadRequest, "Select at least one item")
+		return
+	}
+	if len(bacsDTO.GUIDs) > maxBatchItems {
+		helpers.Error(c, http.StatusBadRequest, "Cannot pay more than "+strconv.Itoa(maxBatchItems)+" items in one file")
+		return
+	}
+
+	now := time.Now().UTC()
+	processingDate, _ := time.Parse(time.DateOnly, bacsDTO.ProcessingDate)
+	if beforeToday(processingDate, now) {
+		helpers.FieldErrorResponse(c, "processingdate", "pastdate")
+		return
+	}
+
+	items, problems, err := h.selectItems(c.Request.Context(), bacsDTO.GUIDs, bacs.Check, bacsProblem)
+	if err != nil {
+		helpers.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(problems) > 0 {
+		helpers.Respond(c, http.StatusUnprocessableEntity, helpers.ValidationError{Errors: problems})
+		return
+	}
+
+	var file bytes.Buffer
+	err = bacs.Write(&file, bacs.Submission{
+		ServiceUserNumber: bacsDTO.ServiceUserNumber,
+		ServiceUserName:   bacsDTO.ServiceUserName,
+		SerialNumber:      bacsDTO.SerialNumber,
+		Created:           now,
+		ProcessingDate:    processingDate,
+	}, items)
 	if err != nil {
 		helpers.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	item := helpers.NewItemFromDTO(createDTO, count+1)
+	c.Header("Content-Disposition", `attachment; filename="`+strings.ToUpper(bacsDTO.SerialNumber)+`.txt"`)
+	c.Data(http.StatusOK, "text/plain; charset=us-ascii", file.Bytes())
+}
+
+// bacsProblem describes why an item cannot be paid by Bacs
+func bacsProblem(err error) string {
+	switch {
+	case errors.Is(err, bacs.ErrNotAccepted):
+		return "Only ACCEPTED items can be paid by Bacs"
+	case errors.Is(err, bacs.ErrItemType):
+		return "Items of this type cannot be paid by Bacs"
+	case errors.Is(err, bacs.ErrCurrency):
+		return "Bacs payments must be in GBP"
+	case errors.Is(err, bacs.ErrAmountLimit):
+		return "Amount exceeds the Bacs limit of " + models.NewMoney(bacs.MaxAmount, "GBP").String() + " GBP"
+	case errors.Is(err, bacs.ErrAccountType):
+		return "Both parties must have UK accounts to be paid by Bacs"
+	case errors.Is(err, bacs.ErrNotReachable):
+		return "An account's branch does not take Bacs payments"
+	default:
+		return err.Error()
+	}
+}
 
-	if err := h.storage.Create(item); err != nil {
+// Pain001 writes an ISO 20022 pain.001 customer credit transfer initiation of the selected items,
+// which must all be PENDING submissions or reversals. As with Bacs, nothing is written if any item
+// cannot be initiated, and writing the message does not change the items.
+func (h *ItemsHandler) Pain001(c *gin.Context) {
+	var painDTO dto.ItemPain001DTO
+	if err := c.ShouldBindJSON(&painDTO); err != nil {
+		helpers.ValidationErrorResponse(c, err)
+		return
+	}
+	if len(painDTO.GUIDs) == 0 {
+		helpers.Error(c, http.StatusBadRequest, "Select at least one item")
+		return
+	}
+	if len(painDTO.GUIDs) > maxBatchItems {
+		helpers.Error(c, http.StatusBadRequest, "Cannot initiate more than "+strconv.Itoa(maxBatchItems)+" items in one message")
+		return
+	}
+
+	now := time.Now().UTC()
+	executionDate, _ := time.Parse(time.DateOnly, painDTO.RequestedExecutionDate)
+	if beforeToday(executionDate, now) {
+		helpers.FieldErrorResponse(c, "requestedexecutiondate", "pastdate")
+		return
+	}
+
+	items, problems, err := h.selectItems(c.Request.Context(), painDTO.GUIDs, iso20022.CheckCreditTransfer, pain001Problem)
+	if err != nil {
 		helpers.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if len(problems) > 0 {
+		helpers.Respond(c, http.StatusUnprocessableEntity, helpers.ValidationError{Errors: problems})
+		return
+	}
 
-	helpers.Respond(c, http.StatusCreated, *item)
+	var message bytes.Buffer
+	err = iso20022.WritePain001(&message, iso20022.Initiation{
+		MessageID:              painDTO.MessageID,
+		InitiatingParty:        painDTO.InitiatingParty,
+		Created:                now,
+		RequestedExecutionDate: executionDate,
+	}, items)
+	if err != nil {
+		helpers.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+painDTO.MessageID+`.xml"`)
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", message.Bytes())
+}
+
+// pain001Problem describes why an item cannot be initiated as a credit transfer
+func pain001Problem(err error) string {
+	switch {
+	case errors.Is(err, iso20022.ErrNotPending):
+		return "Only PENDING items can be initiated as credit transfers"
+	case errors.Is(err, iso20022.ErrItemType):
+		return "Only SUBMISSION and REVERSAL items are credit transfers"
+	default:
+		return err.Error()
+	}
 }
 
-// Update updates an existing item
+// Pacs002 applies an ISO 20022 pacs.002 payment status report sent as the request body, moving the
+// item of each transaction to ACCEPTED or DECLINED as reported. Each transaction is applied on its
+// own and its outcome reported in document order; the change is recorded against the report's
+// message identification, with the status and reason codes as the reason.
+func (h *ItemsHandler) Pacs002(c *gin.Context) {
+	report, err := iso20022.ReadPacs002(c.Request.Body)
+	if err != nil {
+		helpers.Error(c, http.StatusBadRequest, "Request body must be a pacs.002.001.10 payment status report")
+		return
+	}
+	if len(report.Transactions) == 0 {
+		helpers.Error(c, http.StatusUnprocessableEntity, "Status report has no transaction statuses")
+		return
+	}
+
+	actor := "pacs.002 " + report.MessageID
+	ctx := helpers.ChangeContext(c)
+	response := dto.ItemStatusReportResponse{
+		MessageID: report.MessageID,
+		Results:   make([]dto.ItemStatusReportResult, len(report.Transactions)),
+	}
+	for i, tx := range report.Transactions {
+		result := &response.Results[i]
+		result.EndToEndID, result.TransactionStatus = tx.EndToEndID, tx.Status
+		item, changed, status, message := h.applyTransactionStatus(ctx, tx, actor)
+		result.Item, result.Status, result.Error = item, status, message
+		switch {
+		case status != http.StatusOK:
+			response.Failed++
+		case changed:
+			response.Updated++
+		default:
+			response.Unchanged++
+		}
+	}
+
+	status := http.StatusOK
+	if response.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	helpers.Respond(c, status, response)
+}
+
+// applyTransactionStatus moves the item of a reported transaction to the status reported for it.
+// It returns the item as it now stands and whether it changed, or the status and message to report
+// if the item could not be moved.
+func (h *ItemsHandler) applyTransactionStatus(ctx context.Context, tx iso20022.TransactionStatus, actor string) (*models.Item, bool, int, string) {
+	to, err := tx.ItemStatus()
+	if err != nil {
+		return nil, false, http.StatusUnprocessableEntity, "Unknown transaction status " + strconv.Quote(tx.Status)
+	}
+
+	item, err := h.storage.GetByGUID(ctx, iso20022.GUIDFromEndToEndID(tx.EndToEndID))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, false, http.StatusNotFound, "Item not found"
+	} else if err != nil {
+		return nil, false, http.StatusInternalServerError, err.Error()
+	}
+	if to == "" || item.Status == to {
+		return item, false, http.StatusOK, ""
+	}
+
+	from := item.Status
+	if err := item.Transition(to, actor, tx.Explanation(), time.Now()); err != nil {
+		return nil, false, http.StatusConflict, "Cannot transition item from " + string(from) + " to " + string(to)
+	}
+	err = h.storage.Update(ctx, item)
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return nil, false, http.StatusPreconditionFailed, "Item has been modified"
+	} else if err != nil {
+		return nil, false, http.StatusInternalServerError, err.Error()
+	}
+	return item, true, http.StatusOK, ""
+}
+
+// selectItems reads the items selected by GUID for a payment file, in the order selected, checking
+// each with check. The problem with each item that cannot be included is returned by GUID,
+// described by describe if check rejected it.
+func (h *ItemsHandler) selectItems(ctx context.Context, guids []string, check func(models.Item) error, describe func(error) string) ([]models.Item, map[string]string, error) {
+	items := make([]models.Item, 0, len(guids))
+	problems := make(map[string]string)
+	selected := make(map[string]bool, len(guids))
+	for _, guid := range guids {
+		if selected[guid] {
+			problems[guid] = "Item is selected more than once"
+			continue
+		}
+		selected[guid] = true
+
+		item, err := h.storage.GetByGUID(ctx, guid)
+		if errors.Is(err, repository.ErrNotFound) {
+			problems[guid] = "Item not found"
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		if err := check(*item); err != nil {
+			problems[guid] = describe(err)
+			continue
+		}
+		items = append(items, *item)
+	}
+	return items, problems, nil
+}
+
+// beforeToday reports whether a date is earlier than the UTC day of now
+func beforeToday(date, now time.Time) bool {
+	return date.Before(now.UTC().Truncate(24 * time.Hour))
+}
+
+// Import creates items from a CSV file, sent as the request body or as the "file" field of a
+// multipart form, if every row of it is valid; with dry_run=true it only validates the file
+func (h *ItemsHandler) Import(c *gin.Context) {
+	dryRun := false
+	if value := c.Query("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			helpers.Error(c, http.StatusBadRequest, "invalid dry_run parameter")
+			return
+		}
+	}
+
+	file, err := importFile(c)
+	if err != nil {
+		helpers.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, err := h.ImportItems(helpers.ChangeContext(c), file, dryRun)
+	if errors.Is(err, helpers.ErrInvalidCSV) {
+		helpers.Error(c, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		helpers.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	status := http.StatusCreated
+	if response.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	} else if dryRun {
+		status = http.StatusOK
+	}
+	helpers.Respond(c, status, response)
+}
+
+// ImportItems reads a CSV file of items one row at a time, validating each row as Create validates
+// its body, and creates all of the items together only if every row is valid and this is not a dry
+// run. It fails with helpers.ErrInvalidCSV if the file cannot be read as items at all.
+func (h *ItemsHandler) ImportItems(ctx context.Context, r io.Reader, dryRun bool) (dto.ItemImportResponse, error) {
+	response := dto.ItemImportResponse{DryRun: dryRun, Errors: []dto.ItemImportError{}}
+
+	reader, err := helpers.NewItemCSVReader(r)
+	if err != nil {
+		return response, err
+	}
+
+	var items []*models.Item
+	var lines []int
+	for {
+		line, createDTO, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if line == 0 {
+			return response, err
+		}
+
+		response.Rows++
+		if response.Rows > maxImportRows {
+			return response, fmt.Errorf("%w: the file has more than %d rows", helpers.ErrInvalidCSV, maxImportRows)
+		}
+		if err != nil {
+			rowError := dto.ItemImportError{Line: line, Errors: helpers.ValidationErrors(err)}
+			if len(rowError.Errors) == 0 {
+				rowError.Errors, rowError.Error = nil, err.Error()
+			}
+			response.Errors = append(response.Errors, rowError)
+			continue
+		}
+		items = append(items, helpers.NewItemFromDTO(createDTO))
+		lines = append(lines, line)
+	}
+	if response.Rows == 0 {
+		return response, fmt.Errorf("%w: the file has no rows", helpers.ErrInvalidCSV)
+	}
+
+	h.reversals.Lock()
+	defer h.reversals.Unlock()
+
+	valid := make([]*models.Item, 0, len(items))
+	reversed := make(map[string]int64) // minor units reversed by earlier rows of the file, by original GUID
+	for n, item := range items {
+		if status, message := h.awaitApproval(ctx, item); status != 0 {
+			response.Errors = append(response.Errors, dto.ItemImportError{Line: lines[n], Error: message})
+			continue
+		}
+		if item.OriginalGUID != "" {
+			if status, message := h.newReversalProblem(ctx, *item, reversed[item.OriginalGUID]); status != 0 {
+				response.Errors = append(response.Errors, dto.ItemImportError{Line: lines[n], Error: message})
+				continue
+			}
+			reversed[item.OriginalGUID] += item.Amount.Minor
+		}
+		valid = append(valid, item)
+	}
+	sort.Slice(response.Errors, func(i, j int) bool {
+		return response.Errors[i].Line < response.Errors[j].Line
+	})
+	response.Valid = len(valid)
+	response.Failed = len(response.Errors)
+
+	if dryRun || response.Failed > 0 {
+		return response, nil
+	}
+	if err := h.storage.CreateAll(ctx, valid); err != nil {
+		return response, err
+	}
+	response.Created = len(valid)
+	return response, nil
+}
+
+// importFile returns the CSV file sent to Import, streamed from the "file" field of a multipart
+// form or otherwise from the request body
+func importFile(c *gin.Context) (io.Reader, error) {
+	if c.ContentType() != "multipart/form-data" {
+		return c.Request.Body, nil
+	}
+
+	form, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := form.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("Form must have a file field")
+		} else if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+	}
+}
+
+// Update updates an existing item. An If-Match header makes the update conditional on the item's
+// ETag; without one, the update still fails if the item changes between reading and writing it.
 func (h *ItemsHandler) Update(c *gin.Context) {
 	guid := c.Param("guid")
 
-	existingItem, err := h.storage.GetByGUID(guid)
+	existingItem, err := h.storage.GetByGUID(c.Request.Context(), guid)
 	if errors.Is(err, repository.ErrNotFound) {
 		helpers.Error(c, http.StatusNotFound, "Item not found")
 		return
@@ -97,24 +725,341 @@ func (h *ItemsHandler) Update(c *gin.Context) {
 		return
 	}
 
+	if !helpers.IfMatch(c.GetHeader("If-Match"), existingItem.Version) {
+		helpers.Error(c, http.StatusPreconditionFailed, "Item has been modified")
+		return
+	}
+
 	var updateDTO dto.ItemUpdateDTO
 	if err := c.ShouldBindJSON(&updateDTO); err != nil {
 		helpers.ValidationErrorResponse(c, err)
 		return
 	}
+	if existingItem.Status == enums.PENDING_APPROVAL {
+		helpers.Error(c, http.StatusConflict, "Item is awaiting approval and cannot be changed")
+		return
+	}
+	// Changing the status is a transition, whichever route makes it
+	if updateDTO.Status != nil && enums.ItemStatus(strings.ToUpper(string(*updateDTO.Status))) != existingItem.Status &&
+		!helpers.Allows(c, "items.transition") {
+		helpers.Error(c, http.StatusForbidden, "Requires the items.transition permission")
+		return
+	}
 
-	helpers.ApplyUpdate(existingItem, updateDTO)
+	h.reversals.Lock()
+	defer h.reversals.Unlock()
 
-	// Update the item
-	if err := h.storage.Update(existingItem); err != nil {
+	previous := *existingItem
+	if err := helpers.ApplyUpdate(existingItem, updateDTO, helpers.Actor(c)); errors.Is(err, models.ErrIllegalTransition) {
+		illegalTransition(c, previous.Status, *updateDTO.Status)
+		return
+	} else if err != nil {
+		helpers.FieldErrorResponse(c, "amount", "money")
+		return
+	}
+
+	if (previous.Type == enums.REVERSAL) != (existingItem.Type == enums.REVERSAL) {
+		helpers.FieldErrorResponse(c, "type", "reversaltype")
+		return
+	}
+	if !helpers.SameMoneyAndParties(previous, *existingItem) {
+		if previous.Unreversed(nil).Minor != previous.Amount.Minor {
+			helpers.Error(c, http.StatusConflict, "Cannot change the amount, currency or parties of an item that has been reversed")
+			return
+		}
+		if !h.checkReversal(c, previous, *existingItem) {
+			return
+		}
+
+		// What was approved has changed, so the item needs approving again
+		required := h.approvals.Required(existingItem.Amount)
+		if err := existingItem.AwaitReapproval(required, helpers.Actor(c), reapprovalReason, time.Now()); errors.Is(err, models.ErrProcessed) {
+			helpers.Error(c, http.StatusConflict, "Cannot change the amount, currency or parties of an item needing approval once it has left PENDING")
+			return
+		}
+	}
+
+	// Update the item, provided nobody else has since the read above
+	err = h.storage.Update(helpers.ChangeContext(c), existingItem)
+	if errors.Is(err, repository.ErrVersionConflict) {
+		helpers.Error(c, http.StatusPreconditionFailed, "Item has been modified")
+		return
+	} else if err != nil {
 		helpers.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	helpers.SetETag(c, existingItem.Version)
 	helpers.Respond(c, http.StatusOK, *existingItem)
 }
 
-// Delete deletes an item
+// Transition moves an item to a new status, recording the caller as who made the change and why. Like Update,
+// it honours If-Match and fails if the item changes between reading and writing it.
+func (h *ItemsHandler) Transition(c *gin.Context) {
+	guid := c.Param("guid")
+
+	existingItem, err := h.storage.GetByGUID(c.Request.Context(), guid)
+	if errors.Is(err, repository.ErrNotFound) {
+		helpers.Error(c, http.StatusNotFound, "Item not found")
+		return
+	} else if err != nil {
+		helpers.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if !helpers.IfMatch(c.GetHeader("If-Match"), existingItem.Version) {
+		helpers.Error(c, http.StatusPreconditionFailed, "Item has been modified")
+		return
+	}
+
+	var transitionDTO dto.ItemTransitionDTO
+	if err := c.ShouldBindJSON(&transitionDTO); err != nil {
+		helpers.ValidationErrorResponse(c, err)
+		return
+	}
+
+	previousStatus := existingItem.Status
+	status := enums.ItemStatus(strings.ToUpper(string(transitionDTO.Status)))
+	if err := existingItem.Transition(status, helpers.Actor(c), strings.TrimSpace(transitionDTO.Reason), time.Now()); err != nil {
+		illegalTransition(c, previousStatus, status)
+		return
+	}
+
+	err = h.storage.Update(helpers.ChangeContext(c), existingItem)
+	if errors.Is(err, repository.ErrVersionConflict) {
+		helpers.Error(c, http.StatusPreconditionFailed, "Item has been modified")
+		return
+	} else if err != nil {
+		helpers.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helpers.SetETag(c, existingItem.Version)
+	helpers.Respond(c, http.StatusOK, *existingItem)
+}
+
+// Approve records the caller's approval of an item awaiting approval, releasing it to PENDING once
+// enough approvers have approved it. The creator of an item cannot approve it, and nobody can
+// approve it twice. Like Transition, it honours If-Match.
+func (h *ItemsHandler) Approve(c *gin.Context) {
+	var approvalDTO dto.ItemApprovalDTO
+	if err := c.ShouldBindJSON(&approvalDTO); err != nil && !errors.Is(err, io.EOF) {
+		helpers.ValidationErrorResponse(c, err)
+		return
+	}
+
+	h.decide(c, func(item *models.Item, actor string, at time.Time) error {
+		return item.Approve(actor, strings.TrimSpace(approvalDTO.Reason), at)
+	})
+}
+
+// Reject records the caller's rejection of an item awaiting approval, which declines it. Like
+// Transition, it honours If-Match.
+func (h *ItemsHandler) Reject(c *gin.Context) {
+	var rejectionDTO dto.ItemRejectionDTO
+	if err := c.ShouldBindJSON(&rejectionDTO); err != nil {
+		helpers.ValidationErrorResponse(c, err)
+		return
+	}
+
+	h.decide(c, func(item *models.Item, actor string, at time.Time) error {
+		return item.Reject(actor, strings.TrimSpace(rejectionDTO.Reason), at)
+	})
+}
+
+// decide applies the caller's decision on an item awaiting approval and saves the item
+func (h *ItemsHandler) decide(c *gin.Context, decision func(item *models.Item, actor string, at time.Time) error) {
+	guid := c.Param("guid")
+
+	existingItem, err := h.storage.GetByGUID(c.Request.Context(), guid)
+	if errors.Is(err, repository.ErrNotFound) {
+		helpers.Error(c, http.StatusNotFound, "Item not found")
+		return
+	} else if err != nil {
+		helpers.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if !helpers.IfMatch(c.GetHeader("If-Match"), existingItem.Version) {
+		helpers.Error(c, http.StatusPreconditionFailed, "Item has been modified")
+		return
+	}
+
+	actor := helpers.Actor(c)
+	switch err := decision(existingItem, actor, time.Now()); {
+	case errors.Is(err, models.ErrNotAwaitingApproval):
+		helpers.Error(c, http.StatusConflict, "Item is not awaiting approval")
+		return
+	case errors.Is(err, models.ErrCreatorApproval):
+		helpers.Error(c, http.StatusForbidden, "The creator of an item cannot approve it")
+		return
+	case errors.Is(err, models.ErrChangerApproval):
+		helpers.Error(c, http.StatusForbidden, "Whoever changed the amount, currency or parties of an item cannot approve it")
+		return
+	case errors.Is(err, models.ErrAlreadyApproved):
+		helpers.Error(c, http.StatusConflict, "Item has already been approved by "+actor)
+		return
+	case err != nil:
+		helpers.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = h.storage.Update(helpers.ChangeContext(c), existingItem)
+	if errors.Is(err, repository.ErrVersionConflict) {
+		helpers.Error(c, http.StatusPreconditionFailed, "Item has been modified")
+		return
+	} else if err != nil {
+		helpers.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helpers.SetETag(c, existingItem.Version)
+	helpers.Respond(c, http.StatusOK, *existingItem)
+}
+
+// awaitApproval records who is creating a new item and holds it for approval if the rules say it
+// needs any. Items needing approval can only be created PENDING, which approval releases them to.
+// It returns the status and message to report if the item may not be created, or a zero status.
+func (h *ItemsHandler) awaitApproval(ctx context.Context, item *models.Item) (int, string) {
+	item.CreatedBy = repository.ChangeFrom(ctx).Actor
+
+	required := h.approvals.Required(item.Amount)
+	if required > 0 && item.Status != enums.PENDING {
+		return http.StatusUnprocessableEntity, "Items needing approval must be created PENDING, not " + string(item.Status)
+	}
+	item.AwaitApproval(required)
+	return 0, ""
+}
+
+// Reversals lists the reversals of an item in index order, with their totals
+func (h *ItemsHandler) Reversals(c *gin.Context) {
+	guid := c.Param("guid")
+
+	if _, err := h.storage.GetByGUID(c.Request.Context(), guid); errors.Is(err, repository.ErrNotFound) {
+		helpers.Error(c, http.StatusNotFound, "Item not found")
+		return
+	} else if err != nil {
+		helpers.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	page, err := h.storage.GetAllFiltered(c.Request.Context(), repository.ItemQuery{OriginalGUID: guid})
+	if err != nil {
+		helpers.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helpers.Respond(c, http.StatusOK, dto.ItemListResponse{
+		Data:   page.Items,
+		Total:  page.Total,
+		Totals: helpers.NewCurrencyTotals(page.Totals),
+	})
+}
+
+// History lists the audit trail of an item, oldest change first. The trail of a deleted item is
+// still listed; an item created before auditing began has an empty trail until it is next changed.
+func (h *ItemsHandler) History(c *gin.Context) {
+	guid := c.Param("guid")
+
+	entries, err := h.storage.History(c.Request.Context(), guid)
+	if err != nil {
+		helpers.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(entries) == 0 {
+		if _, err := h.storage.GetByGUID(c.Request.Context(), guid); errors.Is(err, repository.ErrNotFound) {
+			helpers.Error(c, http.StatusNotFound, "Item not found")
+			return
+		} else if err != nil {
+			helpers.Error(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	helpers.Respond(c, http.StatusOK, dto.ItemHistoryResponse{
+		Data:  append([]models.AuditEntry{}, entries...),
+		Total: len(entries),
+	})
+}
+
+// checkReversal checks an updated reversal against its original, not counting the reversal's
+// previous state, and reports whether it may be saved; it writes the error response if not
+func (h *ItemsHandler) checkReversal(c *gin.Context, previous, reversal models.Item) bool {
+	if reversal.OriginalGUID == "" {
+		return true
+	}
+
+	original, err := h.storage.GetByGUID(c.Request.Context(), reversal.OriginalGUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		helpers.Error(c, http.StatusUnprocessableEntity, "Original item not found")
+		return false
+	} else if err != nil {
+		helpers.Error(c, http.StatusInternalServerError, err.Error())
+		return false
+	}
+
+	unreversed := original.Unreversed(&previous)
+	if err := models.CheckReversal(*original, reversal, unreversed); err != nil {
+		reversalError(c, err, unreversed)
+		return false
+	}
+	return true
+}
+
+// newReversalProblem checks a reversal about to be created against its original, of which pending
+// minor units are being reversed by other items of the same request. It returns the status and
+// message to report if the reversal may not be created, or a zero status if it may.
+func (h *ItemsHandler) newReversalProblem(ctx context.Context, reversal models.Item, pending int64) (int, string) {
+	original, err := h.storage.GetByGUID(ctx, reversal.OriginalGUID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return http.StatusUnprocessableEntity, "Original item not found"
+	} else if err != nil {
+		return http.StatusInternalServerError, err.Error()
+	}
+
+	unreversed := original.Unreversed(nil)
+	unreversed.Minor -= pending
+	if err := models.CheckReversal(*original, reversal, unreversed); err != nil {
+		return reversalProblem(err, unreversed)
+	}
+	return 0, ""
+}
+
+// reversalError reports a reversal that its original item does not allow
+func reversalError(c *gin.Context, err error, unreversed models.Money) {
+	status, message := reversalProblem(err, unreversed)
+	helpers.Error(c, status, message)
+}
+
+// reversalProblem describes a reversal that its original item does not allow
+func reversalProblem(err error, unreversed models.Money) (int, string) {
+	switch {
+	case errors.Is(err, models.ErrFullyReversed):
+		return http.StatusConflict, "Original item has already been fully reversed"
+	case errors.Is(err, models.ErrReversalExceeds):
+		return http.StatusUnprocessableEntity, "Reversal amount exceeds the unreversed " + unreversed.String() + " " + unreversed.CurrencyCode() + " of the original item"
+	case errors.Is(err, models.ErrReversalOfReversal):
+		return http.StatusUnprocessableEntity, "A reversal cannot be reversed"
+	case errors.Is(err, models.ErrOriginalVoid):
+		return http.StatusUnprocessableEntity, "Original item was declined or returned and cannot be reversed"
+	case errors.Is(err, models.ErrOriginalUnapproved):
+		return http.StatusUnprocessableEntity, "Original item is awaiting approval and cannot be reversed"
+	case errors.Is(err, models.ErrReversalCurrency):
+		return http.StatusUnprocessableEntity, "Reversal currency must match the original item"
+	case errors.Is(err, models.ErrReversalParties):
+		return http.StatusUnprocessableEntity, "Reversal debtor and beneficiary must be the original item's beneficiary and debtor"
+	default:
+		return http.StatusInternalServerError, err.Error()
+	}
+}
+
+// illegalTransition reports a status change the item lifecycle does not allow
+func illegalTransition(c *gin.Context, from, to enums.ItemStatus) {
+	to = enums.ItemStatus(strings.ToUpper(string(to)))
+	helpers.Error(c, http.StatusConflict, "Cannot transition item from "+string(from)+" to "+string(to))
+}
+
+// Delete deletes an item, conditionally on its ETag when an If-Match header is sent
 func (h *ItemsHandler) Delete(c *gin.Context) {
 	guid := c.Param("guid")
 
@@ -123,10 +1068,44 @@ func (h *ItemsHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	err := h.storage.Delete(guid)
+	h.reversals.Lock()
+	defer h.reversals.Unlock()
+
+	reversals, err := h.storage.GetAllFiltered(c.Request.Context(), repository.ItemQuery{OriginalGUID: guid, Limit: 1})
+	if err != nil {
+		helpers.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if reversals.Total > 0 {
+		helpers.Error(c, http.StatusConflict, "Cannot delete an item that has reversals")
+		return
+	}
+
+	version := 0
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		existingItem, err := h.storage.GetByGUID(c.Request.Context(), guid)
+		if errors.Is(err, repository.ErrNotFound) {
+			helpers.Error(c, http.StatusNotFound, "Item not found")
+			return
+		} else if err != nil {
+			helpers.Error(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if !helpers.IfMatch(ifMatch, existingItem.Version) {
+			helpers.Error(c, http.StatusPreconditionFailed, "Item has been modified")
+			return
+		}
+		version = existingItem.Version
+	}
+
+	err = h.storage.Delete(helpers.ChangeContext(c), guid, version)
 	if errors.Is(err, repository.ErrNotFound) {
 		helpers.Error(c, http.StatusNotFound, "Item not found")
 		return
+	} else if errors.Is(err, repository.ErrVersionConflict) {
+		helpers.Error(c, http.StatusPreconditionFailed, "Item has been modified")
+		return
 	} else if err != nil {
 		helpers.Error(c, http.StatusInternalServerError, err.Error())
 		return
@@ -134,3 +1113,83 @@ func (h *ItemsHandler) Delete(c *gin.Context) {
 
 	helpers.NoContent(c, http.StatusNoContent)
 }
+
+// Restore undoes the soft delete of an item, conditionally on its ETag when an If-Match header is
+// sent. A reversal that has not been declined or returned is checked against its original again,
+// as the original may have been reversed further, or deleted, since.
+func (h *ItemsHandler) Restore(c *gin.Context) {
+	guid := c.Param("guid")
+
+	if strings.TrimSpace(guid) == "" {
+		helpers.Error(c, http.StatusUnprocessableEntity, "GUID is required")
+		return
+	}
+
+	h.reversals.Lock()
+	defer h.reversals.Unlock()
+
+	deleted, err := h.storage.GetDeletedByGUID(c.Request.Context(), guid)
+	if errors.Is(err, repository.ErrNotFound) {
+		if _, err := h.storage.GetByGUID(c.Request.Context(), guid); err == nil {
+			helpers.Error(c, http.StatusConflict, "Item is not deleted")
+			return
+		}
+		helpers.Error(c, http.StatusNotFound, "Item not found")
+		return
+	} else if err != nil {
+		helpers.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" && !helpers.IfMatch(ifMatch, deleted.Version) {
+		helpers.Error(c, http.StatusPreconditionFailed, "Item has been modified")
+		return
+	}
+	if deleted.OriginalGUID != "" && !deleted.Status.IsVoid() {
+		if status, message := h.newReversalProblem(c.Request.Context(), *deleted, 0); status != 0 {
+			helpers.Error(c, status, message)
+			return
+		}
+	}
+
+	item, err := h.storage.Restore(helpers.ChangeContext(c), guid, deleted.Version)
+	if errors.Is(err, repository.ErrNotFound) {
+		helpers.Error(c, http.StatusNotFound, "Item not found")
+		return
+	} else if errors.Is(err, repository.ErrNotDeleted) {
+		helpers.Error(c, http.StatusConflict, "Item is not deleted")
+		return
+	} else if errors.Is(err, repository.ErrVersionConflict) {
+		helpers.Error(c, http.StatusPreconditionFailed, "Item has been modified")
+		return
+	} else if err != nil {
+		helpers.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helpers.SetETag(c, item.Version)
+	helpers.Respond(c, http.StatusOK, *item)
+}
+
+// Purge permanently removes the items deleted more than the given number of days ago. Their audit
+// trails are kept, but they can no longer be restored.
+func (h *ItemsHandler) Purge(c *gin.Context) {
+	var purgeDTO dto.ItemPurgeDTO
+	if err := c.ShouldBindJSON(&purgeDTO); err != nil {
+		helpers.ValidationErrorResponse(c, err)
+		return
+	}
+
+	deletedBefore := time.Now().UTC().AddDate(0, 0, -*purgeDTO.RetentionDays)
+	guids, err := h.storage.Purge(helpers.ChangeContext(c), deletedBefore)
+	if err != nil {
+		helpers.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helpers.Respond(c, http.StatusOK, dto.ItemPurgeResponse{
+		DeletedBefore: deletedBefore,
+		Purged:        len(guids),
+		GUIDs:         guids,
+	})
+}
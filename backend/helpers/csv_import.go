@@ -0,0 +1,141 @@
+package helpers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-test/backend/domain/dto"
+	"io"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+// ErrInvalidCSV is returned for an import file that cannot be read as items at all, as opposed to
+// one with invalid rows
+var ErrInvalidCSV = errors.New("invalid CSV")
+
+// itemCSVColumns maps each column an item import may have to the field of the create request it
+// fills, as a path of JSON names
+var itemCSVColumns = map[string][]string{
+	"amount":                     {"amount"},
+	"currency":                   {"currency"},
+	"type":                       {"type"},
+	"status":                     {"status"},
+	"original_guid":              {"original_guid"},
+	"debtor_first_name":          {"attributes", "debtor", "first_name"},
+	"debtor_last_name":           {"attributes", "debtor", "last_name"},
+	"debtor_account_type":        {"attributes", "debtor", "account", "type"},
+	"debtor_sort_code":           {"attributes", "debtor", "account", "sort_code"},
+	"debtor_account_number":      {"attributes", "debtor", "account", "account_number"},
+	"debtor_iban":                {"attributes", "debtor", "account", "iban"},
+	"debtor_bic":                 {"attributes", "debtor", "account", "bic"},
+	"beneficiary_first_name":     {"attributes", "beneficiary", "first_name"},
+	"beneficiary_last_name":      {"attributes", "beneficiary", "last_name"},
+	"beneficiary_account_type":   {"attributes", "beneficiary", "account", "type"},
+	"beneficiary_sort_code":      {"attributes", "beneficiary", "account", "sort_code"},
+	"beneficiary_account_number": {"attributes", "beneficiary", "account", "account_number"},
+	"beneficiary_iban":           {"attributes", "beneficiary", "account", "iban"},
+	"beneficiary_bic":            {"attributes", "beneficiary", "account", "bic"},
+}
+
+// requiredCSVColumns must be in the header of every import, so a file that is not an item import
+// at all is rejected outright rather than row by row
+var requiredCSVColumns = []string{"amount", "type"}
+
+// ItemCSVReader reads create requests from a CSV file one row at a time. The first row is a header
+// naming the columns, in any order and case; empty cells are treated as absent fields.
+type ItemCSVReader struct {
+	reader  *csv.Reader
+	columns [][]string // field path of each column in the file
+}
+
+// NewItemCSVReader reads the header of a CSV file of items. It fails with ErrInvalidCSV if the
+// header is missing, names an unknown column or the same column twice, or lacks a required column.
+func NewItemCSVReader(r io.Reader) (*ItemCSVReader, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidCSV)
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+	}
+
+	columns := make([][]string, len(header))
+	seen := make(map[string]bool, len(header))
+	for i, name := range header {
+		if i == 0 {
+			// Spreadsheets often save CSV files with a UTF-8 byte order mark
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		path, ok := itemCSVColumns[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidCSV, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: duplicate column %q", ErrInvalidCSV, name)
+		}
+		seen[name] = true
+		columns[i] = path
+	}
+	for _, name := range requiredCSVColumns {
+		if !seen[name] {
+			return nil, fmt.Errorf("%w: missing column %q", ErrInvalidCSV, name)
+		}
+	}
+
+	return &ItemCSVReader{reader: reader, columns: columns}, nil
+}
+
+// Next reads the next row and validates it exactly as POST /items validates its body. It returns
+// the line the row starts on, and io.EOF once every row has been read. A row that is malformed or
+// invalid returns a non-nil error, after which the following rows can still be read; its
+// validation errors can be described with ValidationErrors. Errors reading the file itself are
+// returned with line 0 and end the import.
+func (r *ItemCSVReader) Next() (int, dto.ItemCreateDTO, error) {
+	var createDTO dto.ItemCreateDTO
+
+	record, err := r.reader.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.StartLine, createDTO, parseErr.Err
+	} else if err != nil {
+		return 0, createDTO, err
+	}
+	line, _ := r.reader.FieldPos(0)
+
+	body, err := json.Marshal(r.fields(record))
+	if err != nil {
+		return line, createDTO, err
+	}
+	return line, createDTO, binding.JSON.BindBody(body, &createDTO)
+}
+
+// fields nests the non-empty cells of a row as the JSON body of a create request
+func (r *ItemCSVReader) fields(record []string) map[string]any {
+	fields := make(map[string]any)
+	for i, value := range record {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		path := r.columns[i]
+		parent := fields
+		for _, key := range path[:len(path)-1] {
+			child, ok := parent[key].(map[string]any)
+			if !ok {
+				child = make(map[string]any)
+				parent[key] = child
+			}
+			parent = child
+		}
+		parent[path[len(path)-1]] = value
+	}
+	return fields
+}
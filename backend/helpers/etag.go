@@ -0,0 +1,35 @@
+package helpers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag formats an item version as a strong entity tag
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// SetETag sets the ETag response header for an item version
+func SetETag(c *gin.Context, version int) {
+	c.Header("ETag", ETag(version))
+}
+
+// IfMatch reports whether an If-Match header is satisfied by the current version.
+// A missing header or "*" matches any version; weak tags never match, as If-Match uses strong comparison.
+func IfMatch(header string, version int) bool {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return true
+	}
+
+	current := ETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == current {
+			return true
+		}
+	}
+	return false
+}
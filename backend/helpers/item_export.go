@@ -0,0 +1,167 @@
+package helpers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ExportContentTypes maps each format items can be exported in to its content type
+var ExportContentTypes = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"ndjson": "application/x-ndjson",
+	"json":   "application/json; charset=utf-8",
+}
+
+// ItemEncoder writes items to a stream one at a time, so an export never holds more than the
+// item being written. Close finishes the stream and must be called even if no items were written.
+type ItemEncoder interface {
+	Encode(item models.Item) error
+	Close() error
+}
+
+// NewItemEncoder returns an encoder writing to w in one of the ExportContentTypes formats, or
+// false if the format is not one of them
+func NewItemEncoder(format string, w io.Writer) (ItemEncoder, bool) {
+	switch format {
+	case "csv":
+		return &csvItemEncoder{writer: csv.NewWriter(w)}, true
+	case "ndjson":
+		return ndjsonItemEncoder{encoder: json.NewEncoder(w)}, true
+	case "json":
+		return &jsonItemEncoder{writer: w}, true
+	default:
+		return nil, false
+	}
+}
+
+// ndjsonItemEncoder writes each item as a JSON object on its own line
+type ndjsonItemEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e ndjsonItemEncoder) Encode(item models.Item) error {
+	return e.encoder.Encode(item)
+}
+
+func (e ndjsonItemEncoder) Close() error {
+	return nil
+}
+
+// jsonItemEncoder writes the items as a single JSON array, one item per line
+type jsonItemEncoder struct {
+	writer  io.Writer
+	written bool
+}
+
+func (e *jsonItemEncoder) Encode(item models.Item) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n"
+	if !e.written {
+		separator, e.written = "[\n", true
+	}
+	if _, err := io.WriteString(e.writer, separator); err != nil {
+		return err
+	}
+	_, err = e.writer.Write(data)
+	return err
+}
+
+func (e *jsonItemEncoder) Close() error {
+	end := "\n]\n"
+	if !e.written {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.writer, end)
+	return err
+}
+
+// itemCSVHeader names the columns of a CSV export. The party columns share their names with the
+// columns of a CSV import; the history of status transitions is left out.
+var itemCSVHeader = []string{
+	"guid", "index", "version", "created", "type", "status", "amount", "currency", "net_amount", "original_guid",
+	"debtor_first_name", "debtor_last_name", "debtor_account_type", "debtor_sort_code", "debtor_account_number",
+	"debtor_iban", "debtor_bic", "debtor_bank_name", "debtor_branch_name",
+	"beneficiary_first_name", "beneficiary_last_name", "beneficiary_account_type", "beneficiary_sort_code", "beneficiary_account_number",
+	"beneficiary_iban", "beneficiary_bic", "beneficiary_bank_name", "beneficiary_branch_name",
+}
+
+// csvItemEncoder writes the items as CSV rows under itemCSVHeader, flattening their attributes
+type csvItemEncoder struct {
+	writer  *csv.Writer
+	started bool
+}
+
+func (e *csvItemEncoder) Encode(item models.Item) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	netAmount := ""
+	if item.NetAmount != nil {
+		netAmount = item.NetAmount.String()
+	}
+	record := []string{
+		item.GUID,
+		strconv.Itoa(item.Index),
+		strconv.Itoa(item.Version),
+		item.Created.UTC().Format(time.RFC3339),
+		string(item.Type),
+		string(item.Status),
+		item.Amount.String(),
+		item.Amount.CurrencyCode(),
+		netAmount,
+		item.OriginalGUID,
+	}
+	record = appendPartyColumns(record, item.Attributes.Debtor)
+	record = appendPartyColumns(record, item.Attributes.Beneficiary)
+
+	return e.write(record)
+}
+
+func (e *csvItemEncoder) Close() error {
+	return e.start()
+}
+
+// start writes the header before the first row
+func (e *csvItemEncoder) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	return e.write(itemCSVHeader)
+}
+
+// write writes a row straight through to the underlying writer
+func (e *csvItemEncoder) write(record []string) error {
+	if err := e.writer.Write(record); err != nil {
+		return err
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// appendPartyColumns appends the columns describing a party in itemCSVHeader order
+func appendPartyColumns(record []string, party models.Party) []string {
+	account := party.Account
+	accountType := account.AccountType
+	if account.IsUK() {
+		accountType = enums.UKAccount
+	}
+	bankName, branchName := "", ""
+	if account.Bank != nil {
+		bankName, branchName = account.Bank.Name, account.Bank.Branch
+	}
+	return append(record,
+		party.FirstName, party.LastName, string(accountType), account.SortCode, account.AccountNumber,
+		account.IBAN, account.BIC, bankName, branchName,
+	)
+}
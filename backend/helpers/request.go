@@ -0,0 +1,77 @@
+package helpers
+
+import (
+	"context"
+	"go-test/backend/auth"
+	"go-test/backend/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RequestIDHeader carries the ID a request is traced and audited under
+	RequestIDHeader = "X-Request-ID"
+	// ActorHeader names who is making a request
+	ActorHeader = "X-Actor"
+	// TenantHeader names the tenant an unauthenticated request acts for
+	TenantHeader = "X-Tenant-ID"
+
+	// RequestIDKey, ActorKey, ClaimsKey and PolicyKey are where the request ID, actor, token claims
+	// and enforced role policy are kept in the gin context
+	RequestIDKey = "request_id"
+	ActorKey     = "actor"
+	ClaimsKey    = "claims"
+	PolicyKey    = "policy"
+
+	// AnonymousActor is recorded as the actor of requests that do not name one
+	AnonymousActor = "anonymous"
+)
+
+// RequestID returns the ID of the request, as set by middleware.RequestID
+func RequestID(c *gin.Context) string {
+	return c.GetString(RequestIDKey)
+}
+
+// Claims returns the claims of the token the request was authenticated with, as set by
+// middleware.Authenticate, or nil when it was not authenticated
+func Claims(c *gin.Context) *auth.Claims {
+	claims, _ := c.Get(ClaimsKey)
+	verified, _ := claims.(*auth.Claims)
+	return verified
+}
+
+// Allows reports whether the roles of the request's token grant permission under the policy set by
+// middleware.Authorizer, for handlers that need a further permission for some of the changes a
+// request can make. Every permission is granted when no policy is enforced.
+func Allows(c *gin.Context, permission string) bool {
+	policy, _ := c.Get(PolicyKey)
+	enforced, _ := policy.(*auth.Policy)
+	if enforced == nil {
+		return true
+	}
+
+	var roles []string
+	if claims := Claims(c); claims != nil {
+		roles = claims.Roles
+	}
+	return enforced.Allows(roles, permission)
+}
+
+// Actor returns who is making the request: the actor set in the context, or else the one named by
+// the X-Actor header, or AnonymousActor
+func Actor(c *gin.Context) string {
+	if actor := c.GetString(ActorKey); actor != "" {
+		return actor
+	}
+	if actor := c.GetHeader(ActorHeader); actor != "" {
+		return actor
+	}
+	return AnonymousActor
+}
+
+// ChangeContext returns the request's context, which carries its tenant (see middleware.Tenant),
+// carrying as well the change it makes, for the storage methods that write items to record in the
+// audit trail
+func ChangeContext(c *gin.Context) context.Context {
+	return repository.WithChange(c.Request.Context(), repository.Change{Actor: Actor(c), RequestID: RequestID(c)})
+}
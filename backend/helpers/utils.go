@@ -5,7 +5,9 @@ import (
 	"go-test/backend/domain/dto"
 	"go-test/backend/domain/enums"
 	"go-test/backend/domain/models"
-	"sort"
+	"go-test/backend/domain/sortcodes"
+	"go-test/backend/domain/validators"
+	"go-test/backend/repository"
 	"strconv"
 	"strings"
 	"time"
@@ -30,50 +32,162 @@ func ParseLimit(limitStr string, defaultLimit int) (int, error) {
 	return limit, nil
 }
 
-func NewItemFromDTO(dto dto.ItemCreateDTO, index int) *models.Item {
-	return &models.Item{
+// NewItemFromDTO builds a new item, PENDING unless another status is given; its Index is assigned
+// by the store on Create
+func NewItemFromDTO(dto dto.ItemCreateDTO) *models.Item {
+	status := enums.PENDING
+	if dto.Status != "" {
+		status = enums.ItemStatus(strings.ToUpper(string(dto.Status)))
+	}
+
+	item := &models.Item{
 		GUID:       uuid.New().String(),
-		Amount:     dto.Amount,
+		Amount:     parseAmount(dto.Amount, dto.Currency),
 		Type:       enums.ItemType(strings.ToUpper(string(dto.Type))),
-		Status:     enums.ItemStatus(strings.ToUpper(string(dto.Status))),
+		Status:     status,
 		Attributes: dto.Attributes,
 		Created:    time.Now(),
-		Index:      index,
+
+		OriginalGUID: strings.TrimSpace(dto.OriginalGUID),
 	}
+	EnrichAttributes(&item.Attributes)
+	return item
 }
 
-func ApplyUpdate(item *models.Item, dto dto.ItemUpdateDTO) {
-	if dto.Amount != nil {
-		item.Amount = *dto.Amount
+// UpdateReason is recorded as the reason for a status change made by an update that gives none
+const UpdateReason = "Updated"
+
+// ApplyUpdate applies a partial update made by actor to an item. The amount is re-read in the
+// resulting currency, so it fails with models.ErrInvalidAmount if the amount has more decimal places
+// than that currency. A status change is recorded as a transition by actor, for the update's reason or
+// else UpdateReason, and fails with models.ErrIllegalTransition if the lifecycle does not allow it;
+// sending the current status leaves it unchanged.
+func ApplyUpdate(item *models.Item, dto dto.ItemUpdateDTO, actor string) error {
+	if dto.Amount != nil || dto.Currency != nil {
+		amount, currency := models.Decimal(item.Amount.String()), item.Amount.CurrencyCode()
+		if dto.Amount != nil {
+			amount = *dto.Amount
+		}
+		if dto.Currency != nil {
+			currency = *dto.Currency
+		}
+
+		money, err := models.ParseMoney(string(amount), currency)
+		if err != nil {
+			return err
+		}
+		item.Amount = money
 	}
 	if dto.Type != nil {
 		item.Type = enums.ItemType(strings.ToUpper(string(*dto.Type)))
 	}
 	if dto.Status != nil {
-		item.Status = enums.ItemStatus(strings.ToUpper(string(*dto.Status)))
+		status := enums.ItemStatus(strings.ToUpper(string(*dto.Status)))
+		if status != item.Status {
+			reason := strings.TrimSpace(dto.Reason)
+			if reason == "" {
+				reason = UpdateReason
+			}
+			if err := item.Transition(status, actor, reason, time.Now()); err != nil {
+				return err
+			}
+		}
 	}
 	if dto.Attributes != nil {
 		item.Attributes = *dto.Attributes
+		EnrichAttributes(&item.Attributes)
+	}
+	return nil
+}
+
+// EnrichAttributes normalises both parties' accounts and sets the bank details of UK accounts from
+// the sort code directory, replacing any sent by the client
+func EnrichAttributes(attributes *models.Attributes) {
+	for _, account := range []*models.Account{&attributes.Debtor.Account, &attributes.Beneficiary.Account} {
+		account.Normalize()
+		account.Bank = nil
+		if !account.IsUK() {
+			continue
+		}
+		if bank, ok := sortcodes.Default().Lookup(account.SortCode); ok {
+			account.Bank = &bank
+		}
 	}
 }
 
-func ApplyLimit(items []models.Item, limit int) []models.Item {
-	if limit <= 0 || limit >= len(items) {
-		return items
+// SameMoneyAndParties reports whether two states of an item have the same amount, currency and parties
+func SameMoneyAndParties(a, b models.Item) bool {
+	return a.Amount.Minor == b.Amount.Minor && a.Amount.CurrencyCode() == b.Amount.CurrencyCode() &&
+		a.Attributes.Debtor.SameAs(b.Attributes.Debtor) && a.Attributes.Beneficiary.SameAs(b.Attributes.Beneficiary)
+}
+
+// NewCurrencyTotals converts the repository's per-currency totals for a response
+func NewCurrencyTotals(totals []repository.CurrencyTotal) []dto.CurrencyTotal {
+	converted := make([]dto.CurrencyTotal, 0, len(totals))
+	for _, t := range totals {
+		converted = append(converted, dto.CurrencyTotal{Currency: t.Amount.CurrencyCode(), Count: t.Count, Amount: t.Amount})
 	}
-	return items[:limit]
+	return converted
 }
 
-func CopyItems(m map[string]models.Item) []models.Item {
-	items := make([]models.Item, 0, len(m))
-	for _, item := range m {
-		items = append(items, item)
+// NewItemQueryFromDTO converts a validated list filter into a repository query.
+// A date-only created_to covers the whole of that day.
+func NewItemQueryFromDTO(filter dto.ItemFilterDTO) repository.ItemQuery {
+	query := repository.ItemQuery{
+		Search:          strings.TrimSpace(filter.Query),
+		DebtorName:      strings.TrimSpace(filter.DebtorName),
+		BeneficiaryName: strings.TrimSpace(filter.BeneficiaryName),
+		SortCode:        filter.SortCode,
+		AccountNumber:   filter.AccountNumber,
+	}
+	query.IncludeDeleted, _ = strconv.ParseBool(filter.IncludeDeleted)
+
+	for _, t := range filter.Type {
+		query.Types = append(query.Types, enums.ItemType(strings.ToUpper(string(t))))
+	}
+	for _, s := range filter.Status {
+		query.Statuses = append(query.Statuses, enums.ItemStatus(strings.ToUpper(string(s))))
+	}
+
+	for _, currency := range filter.Currency {
+		query.Currencies = append(query.Currencies, strings.ToUpper(currency))
+	}
+	if amount, err := models.ParseMoney(filter.AmountMin, validators.FilterCurrency(filter)); err == nil {
+		query.AmountMin = &amount
+	}
+	if amount, err := models.ParseMoney(filter.AmountMax, validators.FilterCurrency(filter)); err == nil {
+		query.AmountMax = &amount
+	}
+
+	if filter.Sort != "" {
+		for _, part := range strings.Split(filter.Sort, ",") {
+			part = strings.TrimSpace(part)
+			query.Sort = append(query.Sort, repository.SortKey{
+				Field: enums.SortField(strings.ToLower(strings.TrimLeft(part, "+-"))),
+				Desc:  strings.HasPrefix(part, "-"),
+			})
+		}
+	}
+
+	if from, _, err := validators.ParseISODate(filter.CreatedFrom); err == nil {
+		query.CreatedFrom = &from
+	}
+	if to, dateOnly, err := validators.ParseISODate(filter.CreatedTo); err == nil {
+		if dateOnly {
+			to = to.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		query.CreatedTo = &to
 	}
 
-	// Sort by Index for consistency on the FE
-	sort.Slice(items, func(i, j int) bool {
-		return items[i].Index < items[j].Index
-	})
+	return query
+}
 
-	return items
+// parseAmount converts a request amount that has already passed the money validator; an empty
+// currency means DefaultCurrency
+func parseAmount(amount models.Decimal, currency string) models.Money {
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	money, _ := models.ParseMoney(string(amount), currency)
+	return money
 }
@@ -15,9 +15,12 @@ type ValidationError struct {
 }
 
 func ValidationErrorResponse(c *gin.Context, err error) {
-	validationErrors := ValidationError{
-		Errors: make(map[string]string),
-	}
+	c.JSON(http.StatusBadRequest, ValidationError{Errors: ValidationErrors(err)})
+}
+
+// ValidationErrors describes a binding error as a map of field names to messages
+func ValidationErrors(err error) map[string]string {
+	errs := make(map[string]string)
 
 	// Handle JSON parsing (type) errors
 	var jsonTypeErr *json.UnmarshalTypeError
@@ -26,9 +29,9 @@ func ValidationErrorResponse(c *gin.Context, err error) {
 		expected := jsonTypeErr.Type.String()
 		switch expected {
 		case "float64":
-			validationErrors.Errors[fieldPath] = "This field must be a number"
+			errs[fieldPath] = "This field must be a number"
 		default:
-			validationErrors.Errors[fieldPath] = "Invalid data type"
+			errs[fieldPath] = "Invalid data type"
 		}
 	}
 
@@ -36,29 +39,86 @@ func ValidationErrorResponse(c *gin.Context, err error) {
 	var validationErr validator.ValidationErrors
 	if errors.As(err, &validationErr) {
 		for _, fieldErr := range validationErr {
-			fieldPath := strings.ToLower(fieldErr.Field())
+			// Report errors on slice elements (e.g. type[1]) against the field itself
+			fieldPath, _, _ := strings.Cut(strings.ToLower(fieldErr.Field()), "[")
 
-			var message string
-			switch fieldErr.Tag() {
-			case "required":
-				message = "This field is required"
-			case "gt":
-				message = "Value must be greater than 0"
-			case "len":
-				message = "Must be exactly 8 digits"
-			case "sortcode":
-				message = "Sort code must be in the format 00-00-00"
-			case "itemtype":
-				message = "Invalid item type. Must be ADMISSION, SUBMISSION, or REVERSAL"
-			case "itemstatus":
-				message = "Invalid item status. Must be ACCEPTED or DECLINED"
-			default:
-				message = "Invalid value"
-			}
-
-			validationErrors.Errors[fieldPath] = message
+			errs[fieldPath] = validationMessage(fieldErr.Tag())
 		}
 	}
 
-	c.JSON(http.StatusBadRequest, validationErrors)
+	return errs
+}
+
+// FieldErrorResponse reports a single field that failed a validation rule outside of binding,
+// in the same format as ValidationErrorResponse
+func FieldErrorResponse(c *gin.Context, field, tag string) {
+	c.JSON(http.StatusBadRequest, ValidationError{
+		Errors: map[string]string{field: validationMessage(tag)},
+	})
+}
+
+// validationMessage describes a failed validation tag to the client
+func validationMessage(tag string) string {
+	switch tag {
+	case "required", "requiredfor":
+		return "This field is required"
+	case "gt", "positive":
+		return "Value must be greater than 0"
+	case "money":
+		return "Must be a decimal amount with no more decimal places than its currency allows"
+	case "len":
+		return "Must be exactly 8 digits"
+	case "max":
+		return "Value is too long"
+	case "min":
+		return "Value is too small"
+	case "reversalonly":
+		return "Only REVERSAL items may reference an original item"
+	case "reversaltype":
+		return "Items cannot be changed to or from REVERSAL"
+	case "modulus":
+		return "Account number is not valid for this sort code"
+	case "sortcode":
+		return "Sort code must be in the format 00-00-00"
+	case "accounttype":
+		return "Invalid account type. Must be UK or IBAN"
+	case "onlyfor":
+		return "This field is not allowed for this type of account"
+	case "iban":
+		return "Must be a valid IBAN"
+	case "bic":
+		return "Must be a valid 8 or 11 character BIC"
+	case "knownsortcode":
+		return "Sort code is not in the sort code directory"
+	case "itemtype":
+		return "Invalid item type. Must be ADMISSION, SUBMISSION, or REVERSAL"
+	case "itemstatus":
+		return "Invalid item status. Must be PENDING_APPROVAL, PENDING, ACCEPTED, DECLINED, SETTLED or RETURNED"
+	case "initialstatus":
+		return "Items must be created as PENDING, ACCEPTED or DECLINED"
+	case "currency":
+		return "Must be an ISO 4217 currency code, e.g. GBP"
+	case "numeric":
+		return "This field must be a number"
+	case "boolean":
+		return "Must be true or false"
+	case "isodate":
+		return "Must be a date (YYYY-MM-DD) or an RFC 3339 timestamp"
+	case "itemsort":
+		return "Invalid sort. Use a comma-separated list of index, amount, created, type, status, debtor_name or beneficiary_name, each optionally prefixed with -"
+	case "serviceusernumber":
+		return "Must be a six digit service user number"
+	case "bacsserial":
+		return "Must be six letters or digits"
+	case "datetime":
+		return "Must be a date (YYYY-MM-DD)"
+	case "pastdate":
+		return "Must not be in the past"
+	case "amountrange":
+		return "Must be greater than or equal to amount_min"
+	case "daterange":
+		return "Must be on or after created_from"
+	default:
+		return "Invalid value"
+	}
 }
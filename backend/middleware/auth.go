@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"errors"
+	"go-test/backend/auth"
+	"go-test/backend/helpers"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authenticate refuses requests without a valid bearer token with 401. Authenticated requests are
+// made by the token's subject, which replaces any X-Actor header as the actor audited.
+func Authenticate(verifier *auth.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, token, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+		token = strings.TrimSpace(token)
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			c.Header("WWW-Authenticate", `Bearer`)
+			helpers.Error(c, http.StatusUnauthorized, "Missing bearer token")
+			c.Abort()
+			return
+		}
+
+		claims, err := verifier.Verify(token)
+		if err != nil {
+			msg := "Invalid token"
+			if errors.Is(err, auth.ErrExpiredToken) {
+				msg = "Token has expired"
+			}
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			helpers.Error(c, http.StatusUnauthorized, msg)
+			c.Abort()
+			return
+		}
+
+		c.Set(helpers.ClaimsKey, claims)
+		c.Set(helpers.ActorKey, claims.Subject)
+		c.Next()
+	}
+}
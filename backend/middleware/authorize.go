@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"go-test/backend/auth"
+	"go-test/backend/helpers"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authorizer checks that the roles of authenticated requests grant the permission their route needs
+type Authorizer struct {
+	policy *auth.Policy
+}
+
+// NewAuthorizer returns an authorizer enforcing policy. A nil policy allows every request, for when
+// authentication is disabled.
+func NewAuthorizer(policy *auth.Policy) *Authorizer {
+	return &Authorizer{policy: policy}
+}
+
+// Require refuses requests whose roles do not grant permission with 403. It must run after Authenticate.
+func (a *Authorizer) Require(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		a.authorize(c, permission)
+	}
+}
+
+// RequireCollectionMethod is Require for the custom methods on the item collection, such as
+// POST /items:batch, each of which needs the permission named after it (items.batch). Methods that
+// do not exist are refused with 404 whatever the caller's roles.
+func (a *Authorizer) RequireCollectionMethod() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := strings.TrimPrefix(c.Param("method"), ":")
+		if !slices.Contains(auth.CollectionMethods, method) {
+			helpers.Error(c, http.StatusNotFound, "Method not found")
+			c.Abort()
+			return
+		}
+		a.authorize(c, "items."+method)
+	}
+}
+
+func (a *Authorizer) authorize(c *gin.Context, permission string) {
+	if a.policy == nil {
+		c.Next()
+		return
+	}
+
+	c.Set(helpers.PolicyKey, a.policy)
+	if !helpers.Allows(c, permission) {
+		helpers.Error(c, http.StatusForbidden, "Requires the "+permission+" permission")
+		c.Abort()
+		return
+	}
+	c.Next()
+}
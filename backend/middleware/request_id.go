@@ -0,0 +1,27 @@
+// Package middleware holds the gin middleware shared by every item route
+package middleware
+
+import (
+	"go-test/backend/helpers"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// requestIDRegex accepts the IDs proxies and clients commonly send, without room for log injection
+var requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID gives every request an ID, taken from its X-Request-ID header when that is a plausible
+// ID and generated otherwise, and echoes it in the response's X-Request-ID header
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(helpers.RequestIDHeader)
+		if !requestIDRegex.MatchString(id) {
+			id = uuid.New().String()
+		}
+		c.Set(helpers.RequestIDKey, id)
+		c.Header(helpers.RequestIDHeader, id)
+		c.Next()
+	}
+}
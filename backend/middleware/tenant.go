@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"go-test/backend/helpers"
+	"go-test/backend/repository"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tenantIDRegex accepts tenant IDs that are safe to log and to key stored items by
+var tenantIDRegex = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Tenant scopes every request to the tenant it acts for, whose items are the only ones it can read
+// or write. An authenticated request acts for the tenant named by its token's tenant claim, and is
+// refused with 403 if its X-Tenant-ID header names another; without authentication the header names
+// the tenant. Requests naming no tenant act for repository.DefaultTenant.
+func Tenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requested := c.GetHeader(helpers.TenantHeader)
+		if requested != "" && !tenantIDRegex.MatchString(requested) {
+			helpers.Error(c, http.StatusBadRequest, "Invalid "+helpers.TenantHeader+" header")
+			c.Abort()
+			return
+		}
+
+		tenant := requested
+		if claims := helpers.Claims(c); claims != nil {
+			tenant = claims.Tenant
+			if tenant == "" {
+				tenant = repository.DefaultTenant
+			}
+			if requested != "" && requested != tenant {
+				helpers.Error(c, http.StatusForbidden, "Token does not grant access to tenant "+requested)
+				c.Abort()
+				return
+			}
+		}
+		if tenant == "" {
+			tenant = repository.DefaultTenant
+		}
+
+		c.Request = c.Request.WithContext(repository.WithTenant(c.Request.Context(), tenant))
+		c.Next()
+	}
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"time"
+)
+
+// SystemActor is recorded as the actor of changes made without one, such as items seeded at startup
+const SystemActor = "system"
+
+// Change describes who is making a change and in answer to which request. The stores record it in
+// the audit trail of each item the change touches, in the same transaction as the change itself.
+type Change struct {
+	Actor     string
+	RequestID string
+	At        time.Time // when the change was made; the time it is written if zero
+}
+
+type changeKey struct{}
+
+// WithChange returns a context carrying change to the storage methods that write items
+func WithChange(ctx context.Context, change Change) context.Context {
+	return context.WithValue(ctx, changeKey{}, change)
+}
+
+// ChangeFrom returns the change carried by ctx, with SystemActor as its actor if it has none and
+// the current time if it has no time
+func ChangeFrom(ctx context.Context) Change {
+	change, _ := ctx.Value(changeKey{}).(Change)
+	if change.Actor == "" {
+		change.Actor = SystemActor
+	}
+	if change.At.IsZero() {
+		change.At = time.Now()
+	}
+	return change
+}
+
+// newAuditEntry records the change carried by ctx from before to after as the seq'th entry of an
+// item's history. Before is nil for an item being created and after nil for one being deleted.
+func newAuditEntry(ctx context.Context, action enums.AuditAction, seq int, before, after *models.Item) (models.AuditEntry, error) {
+	changes, err := models.DiffItems(before, after)
+	if err != nil {
+		return models.AuditEntry{}, err
+	}
+
+	change := ChangeFrom(ctx)
+	entry := models.AuditEntry{
+		Seq:       seq,
+		Action:    action,
+		Actor:     change.Actor,
+		RequestID: change.RequestID,
+		At:        change.At.UTC(),
+		Changes:   changes,
+	}
+	if after != nil {
+		entry.GUID, entry.Version = after.GUID, after.Version
+	} else {
+		entry.GUID, entry.Version = before.GUID, before.Version
+	}
+	return entry, nil
+}
@@ -1,100 +1,296 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"go-test/backend/domain/enums"
 	"go-test/backend/domain/models"
-	"go-test/backend/helpers"
-	"strings"
+	"log"
+	"slices"
+	"sort"
 	"sync"
+	"time"
 )
 
 var ErrNotFound = errors.New("item not found")
 
+// ErrVersionConflict is returned when an item has changed since the version the caller read
+var ErrVersionConflict = errors.New("item version conflict")
+
+// ErrNotDeleted is returned when restoring an item that has not been deleted
+var ErrNotDeleted = errors.New("item is not deleted")
+
+// ItemsStorage stores items. Every method takes a context carrying the tenant it acts for (see
+// WithTenant), and reads and writes only that tenant's items: to every other tenant they do not
+// exist. Each tenant has its own Index sequence. The methods that write items also take from the
+// context the Change being made (see WithChange), which they record in each item's audit trail as
+// part of the same write. Deletes are soft: a deleted item is hidden from every read except
+// GetDeletedByGUID and queries with IncludeDeleted until it is restored or purged.
 type ItemsStorage interface {
-	GetAll() ([]models.Item, error)
-	GetAllFiltered(query string, limit int) ([]models.Item, error)
-	GetByGUID(guid string) (*models.Item, error)
-	Count() (int, error)
-	Create(item *models.Item) error
-	Update(item *models.Item) error
-	Delete(guid string) error
+	GetAll(ctx context.Context) ([]models.Item, error)
+	GetAllFiltered(ctx context.Context, query ItemQuery) (ItemPage, error)
+	GetByGUID(ctx context.Context, guid string) (*models.Item, error)
+	GetDeletedByGUID(ctx context.Context, guid string) (*models.Item, error)
+	Count(ctx context.Context) (int, error)
+	History(ctx context.Context, guid string) ([]models.AuditEntry, error)
+	Create(ctx context.Context, item *models.Item) error
+	CreateAll(ctx context.Context, items []*models.Item) error
+	Update(ctx context.Context, item *models.Item) error
+	Delete(ctx context.Context, guid string, version int) error
+	Restore(ctx context.Context, guid string, version int) (*models.Item, error)
+	Purge(ctx context.Context, deletedBefore time.Time) ([]string, error)
 }
 
 type ItemsStore struct {
-	items map[string]models.Item
-	mutex sync.RWMutex
+	tenants map[string]*tenantItems // the items of each tenant that has any
+	mutex   sync.RWMutex
+	journal *journal
+}
+
+// tenantItems are the items of one tenant, with the indexes kept over them
+type tenantItems struct {
+	name      string
+	items     map[string]models.Item
+	order     []itemKey                      // keys of items sorted by (Index, GUID), kept in step with items
+	reversals map[string]map[string]bool     // GUIDs of the reversals of each original item, kept in step with items
+	history   map[string][]models.AuditEntry // audit trail of each item, kept after the item is deleted
+	seq       int                            // last Index allocated by Create
+}
+
+func newTenantItems(name string) *tenantItems {
+	return &tenantItems{
+		name:      name,
+		items:     make(map[string]models.Item),
+		reversals: make(map[string]map[string]bool),
+		history:   make(map[string][]models.AuditEntry),
+	}
 }
 
 // NewStore creates a new, thread-safe in-memory item store
 func NewStore() *ItemsStore {
-	return &ItemsStore{
-		items: make(map[string]models.Item),
+	return &ItemsStore{tenants: make(map[string]*tenantItems)}
+}
+
+// NewDurableStore creates an in-memory item store backed by a write-ahead log in dir.
+// Existing items are recovered from the latest snapshot plus the log, and the log is
+// compacted into a new snapshot every snapshotEvery writes (DefaultSnapshotEvery if <= 0).
+func NewDurableStore(dir string, snapshotEvery int) (*ItemsStore, error) {
+	j, state, err := openJournal(dir, snapshotEvery)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range state.tenants {
+		t.order = make([]itemKey, 0, len(t.items))
+		for _, item := range t.items {
+			t.order = append(t.order, keyOf(item))
+			t.link(item)
+		}
+		sort.Slice(t.order, func(i, j int) bool {
+			return t.order[i].less(t.order[j])
+		})
 	}
+	return &ItemsStore{tenants: state.tenants, journal: j}, nil
 }
 
-// GetAll returns all items
-func (is *ItemsStore) GetAll() ([]models.Item, error) {
+// Close releases the write-ahead log, if any
+func (is *ItemsStore) Close() error {
+	is.mutex.Lock()
+	defer is.mutex.Unlock()
+
+	if is.journal == nil {
+		return nil
+	}
+	return is.journal.close()
+}
+
+// GetAll returns all items of the tenant that have not been deleted
+func (is *ItemsStore) GetAll(ctx context.Context) ([]models.Item, error) {
 	is.mutex.RLock()
 	defer is.mutex.RUnlock()
 
-	items := make([]models.Item, 0, len(is.items))
-	for _, item := range is.items {
-		items = append(items, item)
+	t := is.tenant(ctx)
+	items := make([]models.Item, 0, len(t.items))
+	for _, item := range t.items {
+		if !item.IsDeleted() {
+			items = append(items, t.withNetAmount(item))
+		}
 	}
 	return items, nil
 }
 
-// GetAllFiltered returns filtered and limited items
-func (is *ItemsStore) GetAllFiltered(query string, limit int) ([]models.Item, error) {
+// GetAllFiltered returns one page of the tenant's filtered items. The default (Index, GUID) order
+// walks the ordered keys from the cursor; any other sort orders the matching items before paging.
+func (is *ItemsStore) GetAllFiltered(ctx context.Context, query ItemQuery) (ItemPage, error) {
+	if query.Cursor != nil {
+		if _, err := query.cursorPosition(); err != nil {
+			return ItemPage{}, err
+		}
+	}
+
 	is.mutex.RLock()
 	defer is.mutex.RUnlock()
 
-	items := helpers.CopyItems(is.items)
+	t := is.tenant(ctx)
+	if len(query.Sort) > 0 {
+		return t.withNetAmounts(t.sortedPage(query)), nil
+	}
+	return t.withNetAmounts(t.page(query)), nil
+}
 
-	if query != "" {
-		filtered := make([]models.Item, 0)
-		queryLower := strings.ToLower(query)
-		for _, item := range items {
-			guidLower := strings.ToLower(item.GUID)
-			typeLower := strings.ToLower(string(item.Type))
-			statusLower := strings.ToLower(string(item.Status))
+// page returns one page of the items matching the query in (Index, GUID) order; callers must hold
+// the read lock and have validated the cursor
+func (t *tenantItems) page(query ItemQuery) ItemPage {
+	var page ItemPage
+	page.Totals, page.Total = t.summarize(query)
 
-			matches := strings.Contains(guidLower, queryLower) ||
-				strings.Contains(typeLower, queryLower) ||
-				strings.Contains(statusLower, queryLower)
+	forward := query.Cursor == nil || !query.Cursor.Before
+	start, step := 0, 1
+	if query.Cursor != nil {
+		at := itemKey{index: query.Cursor.Index, guid: query.Cursor.GUID}
+		if forward {
+			start = t.searchAfter(at)
+		} else {
+			start, step = t.searchBefore(at)-1, -1
+		}
+	}
 
-			if matches {
-				filtered = append(filtered, item)
-			}
+	fetch := 0
+	if query.Limit > 0 {
+		fetch = query.Limit + 1
+	}
+	items, _ := t.scan(start, step, query, fetch)
+	more := query.Limit > 0 && len(items) > query.Limit
+	if more {
+		items = items[:query.Limit]
+	}
+	if !forward {
+		slices.Reverse(items)
+	}
+	page.Items = items
+
+	if query.Limit > 0 && len(items) > 0 {
+		first, last := items[0], items[len(items)-1]
+
+		hasBefore, hasAfter := more, more
+		if forward {
+			_, hasBefore = t.scan(t.searchBefore(keyOf(first))-1, -1, query, 1)
+		} else {
+			_, hasAfter = t.scan(t.searchAfter(keyOf(last)), 1, query, 1)
+		}
+
+		if hasBefore {
+			page.PrevCursor = query.cursorFor(first, true)
+		}
+		if hasAfter {
+			page.NextCursor = query.cursorFor(last, false)
+		}
+	}
+	return page
+}
+
+// sortedPage orders every matching item by the query's sort keys and slices out the page around the cursor;
+// callers must hold the read lock and have validated the cursor
+func (t *tenantItems) sortedPage(query ItemQuery) ItemPage {
+	matched, _ := t.scan(0, 1, query, 0)
+	positions := make(map[string]sortPosition, len(matched))
+	for _, item := range matched {
+		positions[item.GUID] = query.position(item)
+	}
+	slices.SortFunc(matched, func(a, b models.Item) int {
+		return query.compare(positions[a.GUID], positions[b.GUID])
+	})
+
+	forward := query.Cursor == nil || !query.Cursor.Before
+	start, end := 0, len(matched)
+	if query.Cursor != nil {
+		at, _ := query.cursorPosition()
+		if forward {
+			start = sort.Search(len(matched), func(i int) bool {
+				return query.compare(positions[matched[i].GUID], at) > 0
+			})
+		} else {
+			end = sort.Search(len(matched), func(i int) bool {
+				return query.compare(positions[matched[i].GUID], at) >= 0
+			})
+		}
+	}
+	if query.Limit > 0 && end-start > query.Limit {
+		if forward {
+			end = start + query.Limit
+		} else {
+			start = end - query.Limit
+		}
+	}
+
+	totals := currencyTotals{}
+	for _, item := range matched {
+		totals.add(item)
+	}
+
+	page := ItemPage{Items: slices.Clone(matched[start:end])}
+	page.Totals, page.Total = totals.list()
+	if query.Limit > 0 && start < end {
+		if start > 0 {
+			page.PrevCursor = query.cursorFor(matched[start], true)
+		}
+		if end < len(matched) {
+			page.NextCursor = query.cursorFor(matched[end-1], false)
 		}
-		items = filtered
 	}
+	return page
+}
+
+// GetByGUID returns one of the tenant's items by GUID, unless it has been deleted
+func (is *ItemsStore) GetByGUID(ctx context.Context, guid string) (*models.Item, error) {
+	return is.get(ctx, guid, false)
+}
 
-	return helpers.ApplyLimit(items, limit), nil
+// GetDeletedByGUID returns one of the tenant's soft-deleted items by GUID, or ErrNotFound if no
+// deleted item of the tenant has it
+func (is *ItemsStore) GetDeletedByGUID(ctx context.Context, guid string) (*models.Item, error) {
+	return is.get(ctx, guid, true)
 }
 
-// GetByGUID returns an item by GUID
-func (is *ItemsStore) GetByGUID(guid string) (*models.Item, error) {
+func (is *ItemsStore) get(ctx context.Context, guid string, deleted bool) (*models.Item, error) {
 	is.mutex.RLock()
 	defer is.mutex.RUnlock()
 
-	item, exists := is.items[guid]
-	if !exists {
+	t := is.tenant(ctx)
+	item, exists := t.items[guid]
+	if !exists || item.IsDeleted() != deleted {
 		return nil, ErrNotFound
 	}
+	item = t.withNetAmount(item)
 	return &item, nil
 }
 
-// Count returns the total number of items
-func (is *ItemsStore) Count() (int, error) {
+// Count returns the number of the tenant's items that have not been deleted
+func (is *ItemsStore) Count(ctx context.Context) (int, error) {
 	is.mutex.RLock()
 	defer is.mutex.RUnlock()
 
-	return len(is.items), nil
+	count := 0
+	for _, item := range is.tenant(ctx).items {
+		if !item.IsDeleted() {
+			count++
+		}
+	}
+	return count, nil
 }
 
-// Create adds a new item
-func (is *ItemsStore) Create(item *models.Item) error {
+// History returns the audit trail of one of the tenant's items, oldest change first, even if the
+// item has been deleted
+func (is *ItemsStore) History(ctx context.Context, guid string) ([]models.AuditEntry, error) {
+	is.mutex.RLock()
+	defer is.mutex.RUnlock()
+
+	return append([]models.AuditEntry{}, is.tenant(ctx).history[guid]...), nil
+}
+
+// Create adds a new item to the tenant at version 1, assigning it the next Index in the tenant's
+// sequence. Indexes are never reused, even after the item holding one is deleted.
+func (is *ItemsStore) Create(ctx context.Context, item *models.Item) error {
 	if item == nil {
 		return errors.New("item cannot be nil")
 	}
@@ -105,12 +301,101 @@ func (is *ItemsStore) Create(item *models.Item) error {
 	is.mutex.Lock()
 	defer is.mutex.Unlock()
 
-	is.items[item.GUID] = *item
+	if is.heldElsewhere(ctx, item.GUID) {
+		return ErrGUIDInUse
+	}
+	t := is.claim(ctx)
+	item.Tenant = t.name
+	item.Index = t.seq + 1
+	item.Version = 1
+	item.NetAmount = nil
+	item.DeletedAt = nil
+	entry, err := t.auditEntry(ctx, enums.AuditCreate, item.GUID, item)
+	if err != nil {
+		return err
+	}
+	if err := is.persist(journalRecord{Op: opPut, Tenant: t.name, GUID: item.GUID, Item: item, Seq: item.Index, Audit: []models.AuditEntry{entry}}); err != nil {
+		return err
+	}
+
+	t.seq = item.Index
+	t.put(*item)
+	t.record(entry)
+	is.compact()
+	*item = t.withNetAmount(*item)
+	return nil
+}
+
+// CreateAll adds several items as Create does, all or nothing: if any cannot be created, none are
+func (is *ItemsStore) CreateAll(ctx context.Context, items []*models.Item) error {
+	for _, item := range items {
+		if item == nil {
+			return errors.New("item cannot be nil")
+		}
+		if item.GUID == "" {
+			return errors.New("item GUID cannot be empty")
+		}
+	}
+
+	is.mutex.Lock()
+	defer is.mutex.Unlock()
+
+	for _, item := range items {
+		if is.heldElsewhere(ctx, item.GUID) {
+			return ErrGUIDInUse
+		}
+	}
+	t := is.claim(ctx)
+
+	created := make([]*models.Item, len(items))
+	entries := make([]models.AuditEntry, len(items))
+	earlier := make(map[string]*models.Item) // items created earlier in the batch, which a repeated GUID replaces
+	next := make(map[string]int)             // the next position in the history of those items
+	for i, item := range items {
+		c := *item
+		c.Tenant = t.name
+		c.Index = t.seq + 1 + i
+		c.Version = 1
+		c.NetAmount = nil
+		c.DeletedAt = nil
+		created[i] = &c
+
+		before, seq := earlier[c.GUID], next[c.GUID]
+		if before == nil {
+			seq = len(t.history[c.GUID]) + 1
+			if existing, exists := t.items[c.GUID]; exists {
+				before = &existing
+			}
+		}
+		entry, err := newAuditEntry(ctx, enums.AuditCreate, seq, before, &c)
+		if err != nil {
+			return err
+		}
+		entries[i] = entry
+		earlier[c.GUID], next[c.GUID] = &c, seq+1
+	}
+	if err := is.persist(journalRecord{Op: opPutAll, Tenant: t.name, Items: created, Seq: t.seq + len(items), Audit: entries}); err != nil {
+		return err
+	}
+
+	t.seq += len(items)
+	for _, item := range created {
+		t.put(*item)
+	}
+	for _, entry := range entries {
+		t.record(entry)
+	}
+	is.compact()
+	for i, item := range created {
+		*items[i] = t.withNetAmount(*item)
+	}
 	return nil
 }
 
-// Update updates an item by a given GUID
-func (is *ItemsStore) Update(item *models.Item) error {
+// Update replaces one of the tenant's items by a given GUID if it is still at item.Version, then
+// bumps item.Version. It returns ErrVersionConflict if the item has been changed since that version
+// was read.
+func (is *ItemsStore) Update(ctx context.Context, item *models.Item) error {
 	if item == nil {
 		return errors.New("item cannot be nil")
 	}
@@ -121,24 +406,305 @@ func (is *ItemsStore) Update(item *models.Item) error {
 	is.mutex.Lock()
 	defer is.mutex.Unlock()
 
-	_, exists := is.items[item.GUID]
-	if !exists {
+	t := is.tenant(ctx)
+	existing, exists := t.items[item.GUID]
+	if !exists || existing.IsDeleted() {
 		return ErrNotFound
 	}
+	if existing.Version != item.Version {
+		return ErrVersionConflict
+	}
 
-	is.items[item.GUID] = *item
+	updated := *item
+	updated.Tenant = t.name
+	updated.Version++
+	updated.NetAmount = nil
+	updated.DeletedAt = nil
+	if err := is.replace(ctx, t, enums.AuditUpdate, &updated); err != nil {
+		return err
+	}
+	*item = t.withNetAmount(updated)
 	return nil
 }
 
-// Delete removes an item by GUID
-func (is *ItemsStore) Delete(guid string) error {
+// Delete soft deletes one of the tenant's items by GUID, marking it deleted at the time of the
+// change and bumping its version. A non-zero version makes the delete conditional on the item still
+// being at that version, returning ErrVersionConflict otherwise.
+func (is *ItemsStore) Delete(ctx context.Context, guid string, version int) error {
 	is.mutex.Lock()
 	defer is.mutex.Unlock()
 
-	_, exists := is.items[guid]
-	if !exists {
+	t := is.tenant(ctx)
+	existing, exists := t.items[guid]
+	if !exists || existing.IsDeleted() {
 		return ErrNotFound
 	}
-	delete(is.items, guid)
+	if version != 0 && existing.Version != version {
+		return ErrVersionConflict
+	}
+
+	change := ChangeFrom(ctx)
+	deletedAt := change.At.UTC()
+	deleted := existing
+	deleted.Version++
+	deleted.DeletedAt = &deletedAt
+	return is.replace(WithChange(ctx, change), t, enums.AuditDelete, &deleted)
+}
+
+// Restore undoes the soft delete of one of the tenant's items by GUID and bumps its version,
+// returning the item as restored. It returns ErrNotDeleted if the item has not been deleted, and a
+// non-zero version makes the restore conditional on the item still being at that version.
+func (is *ItemsStore) Restore(ctx context.Context, guid string, version int) (*models.Item, error) {
+	is.mutex.Lock()
+	defer is.mutex.Unlock()
+
+	t := is.tenant(ctx)
+	existing, exists := t.items[guid]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	if !existing.IsDeleted() {
+		return nil, ErrNotDeleted
+	}
+	if version != 0 && existing.Version != version {
+		return nil, ErrVersionConflict
+	}
+
+	restored := existing
+	restored.Version++
+	restored.DeletedAt = nil
+	if err := is.replace(ctx, t, enums.AuditRestore, &restored); err != nil {
+		return nil, err
+	}
+	restored = t.withNetAmount(restored)
+	return &restored, nil
+}
+
+// Purge permanently removes every item of the tenant soft deleted before deletedBefore, all or
+// nothing, and returns their GUIDs in index order. Their audit trails are kept.
+func (is *ItemsStore) Purge(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	is.mutex.Lock()
+	defer is.mutex.Unlock()
+
+	t := is.tenant(ctx)
+	guids := make([]string, 0)
+	var entries []models.AuditEntry
+	for _, key := range t.order {
+		item := t.items[key.guid]
+		if !item.IsDeleted() || !item.DeletedAt.Before(deletedBefore) {
+			continue
+		}
+		entry, err := t.auditEntry(ctx, enums.AuditPurge, item.GUID, nil)
+		if err != nil {
+			return nil, err
+		}
+		guids = append(guids, item.GUID)
+		entries = append(entries, entry)
+	}
+	if len(guids) == 0 {
+		return guids, nil
+	}
+	if err := is.persist(journalRecord{Op: opPurge, Tenant: t.name, GUIDs: guids, Audit: entries}); err != nil {
+		return nil, err
+	}
+
+	for _, guid := range guids {
+		t.remove(guid)
+	}
+	for _, entry := range entries {
+		t.record(entry)
+	}
+	is.compact()
+	return guids, nil
+}
+
+// tenant returns the items of the tenant carried by ctx, which are empty if it has none yet;
+// callers must hold the read lock
+func (is *ItemsStore) tenant(ctx context.Context) *tenantItems {
+	name := TenantFrom(ctx)
+	if t, exists := is.tenants[name]; exists {
+		return t
+	}
+	return newTenantItems(name)
+}
+
+// claim returns the items of the tenant carried by ctx, adding the tenant if it has none yet;
+// callers must hold the write lock
+func (is *ItemsStore) claim(ctx context.Context) *tenantItems {
+	name := TenantFrom(ctx)
+	if _, exists := is.tenants[name]; !exists {
+		is.tenants[name] = newTenantItems(name)
+	}
+	return is.tenants[name]
+}
+
+// heldElsewhere reports whether a tenant other than the one carried by ctx has an item with guid;
+// callers must hold the read lock
+func (is *ItemsStore) heldElsewhere(ctx context.Context, guid string) bool {
+	name := TenantFrom(ctx)
+	for other, t := range is.tenants {
+		if _, exists := t.items[guid]; exists && other != name {
+			return true
+		}
+	}
+	return false
+}
+
+// replace writes a new state of one of the tenant's existing items, recording it in the item's
+// audit trail as action; callers must hold the write lock
+func (is *ItemsStore) replace(ctx context.Context, t *tenantItems, action enums.AuditAction, item *models.Item) error {
+	entry, err := t.auditEntry(ctx, action, item.GUID, item)
+	if err != nil {
+		return err
+	}
+	if err := is.persist(journalRecord{Op: opPut, Tenant: t.name, GUID: item.GUID, Item: item, Audit: []models.AuditEntry{entry}}); err != nil {
+		return err
+	}
+
+	t.put(*item)
+	t.record(entry)
+	is.compact()
 	return nil
 }
+
+// put stores an item and keeps the ordered keys and reversals in step; callers must hold the write lock
+func (t *tenantItems) put(item models.Item) {
+	t.remove(item.GUID)
+	t.items[item.GUID] = item
+	t.link(item)
+
+	key := keyOf(item)
+	t.order = slices.Insert(t.order, t.searchAfter(key), key)
+}
+
+// remove deletes an item, its ordered key and its reversal link; callers must hold the write lock
+func (t *tenantItems) remove(guid string) {
+	existing, exists := t.items[guid]
+	if !exists {
+		return
+	}
+	delete(t.items, guid)
+	if links := t.reversals[existing.OriginalGUID]; links != nil {
+		delete(links, guid)
+		if len(links) == 0 {
+			delete(t.reversals, existing.OriginalGUID)
+		}
+	}
+
+	key := keyOf(existing)
+	if i := t.searchBefore(key); i < len(t.order) && t.order[i] == key {
+		t.order = slices.Delete(t.order, i, i+1)
+	}
+}
+
+// auditEntry records the change carried by ctx to the item with guid, replacing it with after, or
+// deleting it if after is nil; callers must hold the write lock
+func (t *tenantItems) auditEntry(ctx context.Context, action enums.AuditAction, guid string, after *models.Item) (models.AuditEntry, error) {
+	var before *models.Item
+	if existing, exists := t.items[guid]; exists {
+		before = &existing
+	}
+	return newAuditEntry(ctx, action, len(t.history[guid])+1, before, after)
+}
+
+// record appends an entry to its item's audit trail; callers must hold the write lock
+func (t *tenantItems) record(entry models.AuditEntry) {
+	t.history[entry.GUID] = append(t.history[entry.GUID], entry)
+}
+
+// link records a reversal against the item it reverses; callers must hold the write lock
+func (t *tenantItems) link(item models.Item) {
+	if item.OriginalGUID == "" {
+		return
+	}
+	if t.reversals[item.OriginalGUID] == nil {
+		t.reversals[item.OriginalGUID] = make(map[string]bool)
+	}
+	t.reversals[item.OriginalGUID][item.GUID] = true
+}
+
+// withNetAmount sets the NetAmount of an item other than a reversal; callers must hold the read lock
+func (t *tenantItems) withNetAmount(item models.Item) models.Item {
+	item.NetAmount = nil
+	if item.Type == enums.REVERSAL {
+		return item
+	}
+
+	net := item.Amount
+	for guid := range t.reversals[item.GUID] {
+		if reversal := t.items[guid]; !reversal.Status.IsVoid() && !reversal.IsDeleted() {
+			net.Minor -= reversal.Amount.Minor
+		}
+	}
+	item.NetAmount = &net
+	return item
+}
+
+// withNetAmounts sets the NetAmount of each item on a page; callers must hold the read lock
+func (t *tenantItems) withNetAmounts(page ItemPage) ItemPage {
+	for i, item := range page.Items {
+		page.Items[i] = t.withNetAmount(item)
+	}
+	return page
+}
+
+// searchBefore returns the position of the first key that is not less than key
+func (t *tenantItems) searchBefore(key itemKey) int {
+	return sort.Search(len(t.order), func(i int) bool {
+		return !t.order[i].less(key)
+	})
+}
+
+// searchAfter returns the position of the first key greater than key
+func (t *tenantItems) searchAfter(key itemKey) int {
+	return sort.Search(len(t.order), func(i int) bool {
+		return key.less(t.order[i])
+	})
+}
+
+// scan walks the ordered keys from start in direction step collecting items that match the query.
+// It stops once max items are found (max <= 0 means no limit) and reports whether any matched.
+func (t *tenantItems) scan(start, step int, query ItemQuery, max int) ([]models.Item, bool) {
+	items := make([]models.Item, 0)
+	for i := start; i >= 0 && i < len(t.order); i += step {
+		item := t.items[t.order[i].guid]
+		if !query.matches(item) {
+			continue
+		}
+		items = append(items, item)
+		if max > 0 && len(items) >= max {
+			break
+		}
+	}
+	return items, len(items) > 0
+}
+
+// summarize counts and sums every item that matches the query, per currency
+func (t *tenantItems) summarize(query ItemQuery) ([]CurrencyTotal, int) {
+	totals := currencyTotals{}
+	for _, item := range t.items {
+		if query.matches(item) {
+			totals.add(item)
+		}
+	}
+	return totals.list()
+}
+
+// persist writes a mutation to the log before it is applied; callers must hold the write lock
+func (is *ItemsStore) persist(rec journalRecord) error {
+	if is.journal == nil {
+		return nil
+	}
+	return is.journal.append(rec)
+}
+
+// compact snapshots the items once enough records have been logged; callers must hold the write lock.
+// A failed snapshot is not fatal as every mutation is already durable in the log.
+func (is *ItemsStore) compact() {
+	if is.journal == nil || !is.journal.shouldSnapshot() {
+		return
+	}
+	if err := is.journal.snapshot(journalState{tenants: is.tenants}); err != nil {
+		log.Println("Failed to snapshot items journal:", err)
+	}
+}
@@ -0,0 +1,456 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-test/backend/domain/models"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	journalLogFile      = "items.log"
+	journalSnapshotFile = "items.snapshot"
+
+	// DefaultSnapshotEvery is how many log records are written before the log is compacted into a snapshot
+	DefaultSnapshotEvery = 1000
+
+	// Every record is framed as [length uint32][crc32c of payload uint32][crc32c of the first 8 bytes uint32][payload],
+	// so that a damaged length is detected before it is trusted
+	recordHeaderSize = 12
+
+	// Logs and snapshots written before the header had its own checksum have no file header and
+	// frame records as [length uint32][crc32c of payload uint32][payload]
+	legacyHeaderSize = 8
+)
+
+// journalMagic starts every log and snapshot whose records carry a header checksum
+var journalMagic = []byte("ITEMJRN\x02")
+
+const (
+	opPut    = "put"
+	opPutAll = "put_all"
+	opDelete = "delete" // a hard delete, written before deletes became soft
+	opPurge  = "purge"
+)
+
+// ErrJournalCorrupt is returned when a record header fails its checksum, when a record fails its
+// checksum somewhere other than the tail of the log, or when the snapshot itself is damaged. Unlike a
+// torn final write this cannot be repaired automatically.
+var ErrJournalCorrupt = errors.New("journal is corrupt")
+
+// ErrJournalUnusable is returned by every write after the log could not be cut back to its last good
+// record, since a record appended after a torn one would be lost with it on the next start
+var ErrJournalUnusable = errors.New("journal is unusable")
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type journalRecord struct {
+	Op     string         `json:"op"`
+	Tenant string         `json:"tenant,omitempty"` // owner of the items written; DefaultTenant if empty, as in logs written before tenants
+	GUID   string         `json:"guid"`
+	Item   *models.Item   `json:"item,omitempty"`
+	Items  []*models.Item `json:"items,omitempty"` // every item of a put_all, which is applied all or nothing
+	Seq    int            `json:"seq,omitempty"`   // the tenant's index sequence after this record, when it allocated one
+	GUIDs  []string       `json:"guids,omitempty"` // every item a purge removes
+	// Audit holds the audit entries of the change, so that they are written with it or not at all
+	Audit []models.AuditEntry `json:"audit,omitempty"`
+}
+
+// snapshot holds the items of every tenant. Snapshots taken before tenants existed hold
+// DefaultTenant's items in Items, History and Seq instead.
+type snapshot struct {
+	Items   []models.Item       `json:"items,omitempty"`
+	History []models.AuditEntry `json:"history,omitempty"`
+	Seq     int                 `json:"seq,omitempty"`
+	Tenants []tenantSnapshot    `json:"tenants,omitempty"`
+}
+
+// tenantSnapshot holds the items of one tenant
+type tenantSnapshot struct {
+	Tenant  string              `json:"tenant"`
+	Items   []models.Item       `json:"items"`
+	History []models.AuditEntry `json:"history,omitempty"`
+	Seq     int                 `json:"seq"`
+}
+
+// journalState is the store state recovered from a snapshot and log: the items, audit trails and
+// index sequence of each tenant
+type journalState struct {
+	tenants map[string]*tenantItems
+}
+
+// tenant returns the recovered items of a tenant, adding the tenant if it has none yet
+func (s *journalState) tenant(name string) *tenantItems {
+	if name == "" {
+		name = DefaultTenant
+	}
+	if _, exists := s.tenants[name]; !exists {
+		s.tenants[name] = newTenantItems(name)
+	}
+	return s.tenants[name]
+}
+
+// journal is an append-only, fsync'd log of store mutations with periodic compacted snapshots
+type journal struct {
+	dir           string
+	log           *os.File
+	records       int
+	snapshotEvery int
+	err           error // why the log can no longer be appended to, if it cannot
+}
+
+// openJournal recovers the state persisted in dir and opens the log for appending
+func openJournal(dir string, snapshotEvery int) (*journal, *journalState, error) {
+	if snapshotEvery <= 0 {
+		snapshotEvery = DefaultSnapshotEvery
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, nil, err
+	}
+
+	state, err := readSnapshot(filepath.Join(dir, journalSnapshotFile))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log, err := os.OpenFile(filepath.Join(dir, journalLogFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	records, legacy, err := replayLog(log, state)
+	if err != nil {
+		log.Close()
+		return nil, nil, err
+	}
+
+	// Logs written before the sequence was recorded only carry it implicitly in the indexes, items
+	// logged before versioning existed start at version 1, and those logged before tenants existed
+	// are DefaultTenant's
+	for _, t := range state.tenants {
+		for guid, item := range t.items {
+			t.seq = max(t.seq, item.Index)
+			if item.Version == 0 {
+				item.Version = 1
+			}
+			item.Tenant = t.name
+			t.items[guid] = item
+		}
+	}
+
+	j := &journal{
+		dir:           dir,
+		log:           log,
+		records:       records,
+		snapshotEvery: snapshotEvery,
+	}
+
+	// A log in the legacy framing is compacted away, so that every record appended to it has a
+	// checksummed header
+	if legacy {
+		if err := j.snapshot(*state); err != nil {
+			log.Close()
+			return nil, nil, err
+		}
+	}
+	return j, state, nil
+}
+
+// append durably writes a record to the end of the log
+func (j *journal) append(rec journalRecord) error {
+	if j.err != nil {
+		return j.err
+	}
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	offset, err := j.log.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	_, err = j.log.Write(frameRecord(payload))
+	if err == nil {
+		err = j.log.Sync()
+	}
+	if err != nil {
+		// Drop any partial write so later records are not appended after a torn one
+		if terr := j.log.Truncate(offset); terr != nil {
+			j.err = fmt.Errorf("%w: %v", ErrJournalUnusable, terr)
+		} else if _, serr := j.log.Seek(offset, io.SeekStart); serr != nil {
+			j.err = fmt.Errorf("%w: %v", ErrJournalUnusable, serr)
+		}
+		return err
+	}
+
+	j.records++
+	return nil
+}
+
+func (j *journal) shouldSnapshot() bool {
+	return j.records >= j.snapshotEvery
+}
+
+// snapshot atomically replaces the snapshot with items and truncates the log.
+// A crash between the two steps is harmless because replaying the log over the new snapshot is idempotent.
+func (j *journal) snapshot(state journalState) error {
+	if j.err != nil {
+		return j.err
+	}
+	var snap snapshot
+	for _, t := range state.tenants {
+		ts := tenantSnapshot{Tenant: t.name, Items: make([]models.Item, 0, len(t.items)), Seq: t.seq}
+		for _, item := range t.items {
+			ts.Items = append(ts.Items, item)
+		}
+		for _, entries := range t.history {
+			ts.History = append(ts.History, entries...)
+		}
+		snap.Tenants = append(snap.Tenants, ts)
+	}
+	payload, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(j.dir, journalSnapshotFile)
+	tmp := path + ".tmp"
+	if err := writeFileSync(tmp, append(bytes.Clone(journalMagic), frameRecord(payload)...)); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	if err := syncDir(j.dir); err != nil {
+		return err
+	}
+
+	if err := startLog(j.log); err != nil {
+		j.err = fmt.Errorf("%w: %v", ErrJournalUnusable, err)
+		return err
+	}
+	j.records = 0
+	return nil
+}
+
+// startLog empties the log and writes the file header, leaving the file positioned for appending
+func startLog(log *os.File) error {
+	if err := log.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := log.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := log.Write(journalMagic); err != nil {
+		return err
+	}
+	return log.Sync()
+}
+
+func (j *journal) close() error {
+	return j.log.Close()
+}
+
+func readSnapshot(path string) (*journalState, error) {
+	state := &journalState{tenants: make(map[string]*tenantItems)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	unframe := unframeLegacyRecord
+	if bytes.HasPrefix(data, journalMagic) {
+		data, unframe = data[len(journalMagic):], unframeRecord
+	}
+	payload, n, err := unframe(data)
+	if err != nil || n != len(data) {
+		return nil, fmt.Errorf("%w: invalid snapshot %s", ErrJournalCorrupt, path)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(payload, &snap); err != nil {
+		return nil, fmt.Errorf("%w: invalid snapshot %s: %v", ErrJournalCorrupt, path, err)
+	}
+	if len(snap.Items) > 0 || len(snap.History) > 0 || snap.Seq > 0 {
+		snap.Tenants = append(snap.Tenants, tenantSnapshot{Tenant: DefaultTenant, Items: snap.Items, History: snap.History, Seq: snap.Seq})
+	}
+	for _, ts := range snap.Tenants {
+		t := state.tenant(ts.Tenant)
+		for _, item := range ts.Items {
+			t.items[item.GUID] = item
+		}
+		for _, entry := range ts.History {
+			t.history[entry.GUID] = append(t.history[entry.GUID], entry)
+		}
+		t.seq = ts.Seq
+	}
+	return state, nil
+}
+
+// replayLog applies every valid record in the log to state and leaves the file positioned for appending,
+// reporting whether the log is in the legacy framing. A torn final record (from a crash mid-write) is
+// truncated away; a bad record header, or a bad record followed by more data, is reported as corruption.
+func replayLog(log *os.File, state *journalState) (int, bool, error) {
+	data, err := io.ReadAll(log)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// An empty log, or one torn while its file header was written, holds no records
+	if len(data) < len(journalMagic) && bytes.HasPrefix(journalMagic, data) {
+		return 0, false, startLog(log)
+	}
+
+	offset, unframe, legacy := 0, unframeLegacyRecord, true
+	if bytes.HasPrefix(data, journalMagic) {
+		offset, unframe, legacy = len(journalMagic), unframeRecord, false
+	}
+
+	records := 0
+	for offset < len(data) {
+		payload, n, err := unframe(data[offset:])
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			// The record runs past the end of the log, so it is the last one and was torn
+			break
+		} else if errors.Is(err, errBadHeader) {
+			return 0, false, fmt.Errorf("%w: bad record header at offset %d", ErrJournalCorrupt, offset)
+		} else if err != nil {
+			if offset+n < len(data) {
+				return 0, false, fmt.Errorf("%w: bad record at offset %d", ErrJournalCorrupt, offset)
+			}
+			break
+		}
+
+		var rec journalRecord
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			return 0, false, fmt.Errorf("%w: undecodable record at offset %d: %v", ErrJournalCorrupt, offset, err)
+		}
+		state.apply(rec)
+
+		offset += n
+		records++
+	}
+
+	if offset < len(data) {
+		if err := log.Truncate(int64(offset)); err != nil {
+			return 0, false, err
+		}
+		if err := log.Sync(); err != nil {
+			return 0, false, err
+		}
+	}
+	if _, err := log.Seek(int64(offset), io.SeekStart); err != nil {
+		return 0, false, err
+	}
+	return records, legacy, nil
+}
+
+func (s *journalState) apply(rec journalRecord) {
+	t := s.tenant(rec.Tenant)
+	switch rec.Op {
+	case opPut:
+		if rec.Item != nil {
+			t.items[rec.GUID] = *rec.Item
+		}
+	case opPutAll:
+		for _, item := range rec.Items {
+			t.items[item.GUID] = *item
+		}
+	case opDelete:
+		delete(t.items, rec.GUID)
+	case opPurge:
+		for _, guid := range rec.GUIDs {
+			delete(t.items, guid)
+		}
+	}
+	t.seq = max(t.seq, rec.Seq)
+
+	// The log may be replayed over a snapshot that already holds its entries
+	for _, entry := range rec.Audit {
+		if entry.Seq > len(t.history[entry.GUID]) {
+			t.history[entry.GUID] = append(t.history[entry.GUID], entry)
+		}
+	}
+}
+
+func frameRecord(payload []byte) []byte {
+	buf := make([]byte, recordHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.Checksum(payload, crcTable))
+	binary.LittleEndian.PutUint32(buf[8:12], crc32.Checksum(buf[0:8], crcTable))
+	copy(buf[recordHeaderSize:], payload)
+	return buf
+}
+
+// errBadHeader means a record header fails its checksum, so its length cannot be trusted
+var errBadHeader = fmt.Errorf("%w: bad record header", ErrJournalCorrupt)
+
+// unframeRecord decodes the record at the start of data and returns its payload and framed size.
+// io.ErrUnexpectedEOF means the record is incomplete; errBadHeader means its header is damaged;
+// ErrJournalCorrupt means the payload checksum does not match.
+func unframeRecord(data []byte) ([]byte, int, error) {
+	if len(data) < recordHeaderSize {
+		return nil, len(data), io.ErrUnexpectedEOF
+	}
+	if crc32.Checksum(data[0:8], crcTable) != binary.LittleEndian.Uint32(data[8:12]) {
+		return nil, 0, errBadHeader
+	}
+	return unframePayload(data, recordHeaderSize)
+}
+
+// unframeLegacyRecord decodes a record in the legacy framing, whose length is not checksummed
+func unframeLegacyRecord(data []byte) ([]byte, int, error) {
+	if len(data) < legacyHeaderSize {
+		return nil, len(data), io.ErrUnexpectedEOF
+	}
+	return unframePayload(data, legacyHeaderSize)
+}
+
+func unframePayload(data []byte, headerSize int) ([]byte, int, error) {
+	length := int(binary.LittleEndian.Uint32(data[0:4]))
+	sum := binary.LittleEndian.Uint32(data[4:8])
+	if length > len(data)-headerSize {
+		return nil, len(data), io.ErrUnexpectedEOF
+	}
+
+	payload := data[headerSize : headerSize+length]
+	if crc32.Checksum(payload, crcTable) != sum {
+		return nil, headerSize + length, ErrJournalCorrupt
+	}
+	return bytes.Clone(payload), headerSize + length, nil
+}
+
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
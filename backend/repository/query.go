@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor parameter")
+
+// ItemQuery describes which items to list and which page of them to return.
+// Every filter that is set must match (AND semantics); zero values are ignored.
+type ItemQuery struct {
+	Search          string             // case-insensitive substring matched against GUID, type and status
+	Types           []enums.ItemType   // item type is any of these
+	Statuses        []enums.ItemStatus // item status is any of these
+	Currencies      []string           // amount currency is any of these
+	AmountMin       *models.Money      // inclusive, compared by face value across currencies
+	AmountMax       *models.Money      // inclusive, compared by face value across currencies
+	CreatedFrom     *time.Time         // inclusive
+	CreatedTo       *time.Time         // inclusive
+	DebtorName      string             // case-insensitive substring of "first last"
+	BeneficiaryName string             // case-insensitive substring of "first last"
+	SortCode        string             // exact match on either party's account
+	AccountNumber   string             // exact match on either party's account
+	OriginalGUID    string             // item is a reversal of this item
+	IncludeDeleted  bool               // soft-deleted items match too; otherwise only live items match
+	Sort            []SortKey          // ordering, always tie-broken by (Index, GUID); empty orders by (Index, GUID)
+	Limit           int                // page size; 0 returns every matching item
+	Cursor          *Cursor            // position to page from; nil starts at the first item
+}
+
+// ItemPage is one page of items in the query's sort order
+type ItemPage struct {
+	Items      []models.Item
+	Total      int
+	Totals     []CurrencyTotal // every matching item, summed per currency in code order
+	NextCursor *Cursor
+	PrevCursor *Cursor
+}
+
+// CurrencyTotal is the number and sum of the matching items in one currency
+type CurrencyTotal struct {
+	Count  int
+	Amount models.Money
+}
+
+// currencyTotals accumulates matching items per currency
+type currencyTotals map[string]CurrencyTotal
+
+func (ct currencyTotals) add(item models.Item) {
+	currency := item.Amount.CurrencyCode()
+	t := ct[currency]
+	t.Count++
+	t.Amount = models.NewMoney(t.Amount.Minor+item.Amount.Minor, currency)
+	ct[currency] = t
+}
+
+// list returns the totals ordered by currency code, and the number of items they cover
+func (ct currencyTotals) list() ([]CurrencyTotal, int) {
+	totals := make([]CurrencyTotal, 0, len(ct))
+	count := 0
+	for _, t := range ct {
+		totals = append(totals, t)
+		count += t.Count
+	}
+	slices.SortFunc(totals, func(a, b CurrencyTotal) int {
+		return strings.Compare(a.Amount.Currency, b.Amount.Currency)
+	})
+	return totals, count
+}
+
+// Cursor is a stable position between two items in a given sort order.
+// Before is false for cursors that page forward (items after the position) and true for cursors that page backward.
+// Sort and Values record the sort the cursor was issued for and the boundary item's value for each of its keys.
+type Cursor struct {
+	Index  int      `json:"i"`
+	GUID   string   `json:"g"`
+	Before bool     `json:"b,omitempty"`
+	Sort   string   `json:"s,omitempty"`
+	Values []string `json:"v,omitempty"`
+}
+
+// EncodeCursor returns the opaque string form of a cursor for use in responses
+func EncodeCursor(c *Cursor) *string {
+	if c == nil {
+		return nil
+	}
+	raw, _ := json.Marshal(c)
+	encoded := base64.RawURLEncoding.EncodeToString(raw)
+	return &encoded
+}
+
+// DecodeCursor parses a cursor produced by EncodeCursor; an empty string yields nil
+func DecodeCursor(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.GUID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// itemKey is the stable sort key items are paginated by
+type itemKey struct {
+	index int
+	guid  string
+}
+
+func keyOf(item models.Item) itemKey {
+	return itemKey{index: item.Index, guid: item.GUID}
+}
+
+func (k itemKey) less(other itemKey) bool {
+	if k.index != other.index {
+		return k.index < other.index
+	}
+	return k.guid < other.guid
+}
+
+// matches reports whether an item satisfies the query's filters
+func (q ItemQuery) matches(item models.Item) bool {
+	if item.IsDeleted() && !q.IncludeDeleted {
+		return false
+	}
+	if q.Search != "" {
+		queryLower := strings.ToLower(q.Search)
+		guidLower := strings.ToLower(item.GUID)
+		typeLower := strings.ToLower(string(item.Type))
+		statusLower := strings.ToLower(string(item.Status))
+
+		matches := strings.Contains(guidLower, queryLower) ||
+			strings.Contains(typeLower, queryLower) ||
+			strings.Contains(statusLower, queryLower)
+
+		if !matches {
+			return false
+		}
+	}
+	if len(q.Types) > 0 && !slices.Contains(q.Types, item.Type) {
+		return false
+	}
+	if len(q.Statuses) > 0 && !slices.Contains(q.Statuses, item.Status) {
+		return false
+	}
+	if len(q.Currencies) > 0 && !slices.Contains(q.Currencies, item.Amount.CurrencyCode()) {
+		return false
+	}
+	if q.AmountMin != nil && item.Amount.Scaled() < q.AmountMin.Scaled() {
+		return false
+	}
+	if q.AmountMax != nil && item.Amount.Scaled() > q.AmountMax.Scaled() {
+		return false
+	}
+	if q.CreatedFrom != nil && item.Created.Before(*q.CreatedFrom) {
+		return false
+	}
+	if q.CreatedTo != nil && item.Created.After(*q.CreatedTo) {
+		return false
+	}
+
+	debtor, beneficiary := item.Attributes.Debtor, item.Attributes.Beneficiary
+	if q.DebtorName != "" && !nameContains(debtor, q.DebtorName) {
+		return false
+	}
+	if q.BeneficiaryName != "" && !nameContains(beneficiary, q.BeneficiaryName) {
+		return false
+	}
+	if q.SortCode != "" && debtor.Account.SortCode != q.SortCode && beneficiary.Account.SortCode != q.SortCode {
+		return false
+	}
+	if q.AccountNumber != "" && debtor.Account.AccountNumber != q.AccountNumber && beneficiary.Account.AccountNumber != q.AccountNumber {
+		return false
+	}
+	if q.OriginalGUID != "" && item.OriginalGUID != q.OriginalGUID {
+		return false
+	}
+	return true
+}
+
+func nameContains(party models.Party, name string) bool {
+	fullName := strings.ToLower(party.FirstName + " " + party.LastName)
+	return strings.Contains(fullName, strings.ToLower(name))
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"cmp"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SortKey orders items by one field
+type SortKey struct {
+	Field enums.SortField
+	Desc  bool
+}
+
+// sortPosition is where an item (or cursor) falls in a query's sort order:
+// one value per sort key followed by the (Index, GUID) tie-breaker
+type sortPosition struct {
+	values []any
+	key    itemKey
+}
+
+// sortSpec is the canonical form of the query's sort, e.g. "-amount,created"
+func (q ItemQuery) sortSpec() string {
+	parts := make([]string, 0, len(q.Sort))
+	for _, k := range q.Sort {
+		if k.Desc {
+			parts = append(parts, "-"+string(k.Field))
+		} else {
+			parts = append(parts, string(k.Field))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func (q ItemQuery) position(item models.Item) sortPosition {
+	values := make([]any, len(q.Sort))
+	for i, k := range q.Sort {
+		values[i] = sortValue(item, k.Field)
+	}
+	return sortPosition{values: values, key: keyOf(item)}
+}
+
+// cursorPosition decodes the query's cursor, rejecting cursors issued for a different sort
+func (q ItemQuery) cursorPosition() (sortPosition, error) {
+	c := q.Cursor
+	if c.Sort != q.sortSpec() || len(c.Values) != len(q.Sort) {
+		return sortPosition{}, ErrInvalidCursor
+	}
+
+	values := make([]any, len(q.Sort))
+	for i, k := range q.Sort {
+		v, err := decodeSortValue(k.Field, c.Values[i])
+		if err != nil {
+			return sortPosition{}, ErrInvalidCursor
+		}
+		values[i] = v
+	}
+	return sortPosition{values: values, key: itemKey{index: c.Index, guid: c.GUID}}, nil
+}
+
+// cursorFor returns a cursor positioned at item in the query's sort order
+func (q ItemQuery) cursorFor(item models.Item, before bool) *Cursor {
+	c := &Cursor{Index: item.Index, GUID: item.GUID, Before: before, Sort: q.sortSpec()}
+	for _, v := range q.position(item).values {
+		c.Values = append(c.Values, encodeSortValue(v))
+	}
+	return c
+}
+
+// compare orders two positions by the query's sort keys, then by (Index, GUID)
+func (q ItemQuery) compare(a, b sortPosition) int {
+	for i, k := range q.Sort {
+		if c := compareSortValues(a.values[i], b.values[i]); c != 0 {
+			if k.Desc {
+				return -c
+			}
+			return c
+		}
+	}
+	if a.key.less(b.key) {
+		return -1
+	}
+	if b.key.less(a.key) {
+		return 1
+	}
+	return 0
+}
+
+// sortValue returns the value an item is ordered by for a sort field
+func sortValue(item models.Item, field enums.SortField) any {
+	switch field {
+	case enums.SortAmount:
+		return item.Amount.Scaled()
+	case enums.SortCreated:
+		return item.Created.UTC()
+	case enums.SortType:
+		return string(item.Type)
+	case enums.SortStatus:
+		return string(item.Status)
+	case enums.SortDebtorName:
+		return partySortName(item.Attributes.Debtor)
+	case enums.SortBeneficiaryName:
+		return partySortName(item.Attributes.Beneficiary)
+	default:
+		return item.Index
+	}
+}
+
+// partySortName orders parties by last name, then first name, ignoring the case of ASCII letters
+func partySortName(party models.Party) string {
+	return foldASCII(party.LastName + " " + party.FirstName)
+}
+
+// foldASCII lower-cases ASCII letters only, as SQLite's lower() does, so that every store orders
+// names alike and their cursors can be used with one another
+func foldASCII(s string) string {
+	return strings.Map(func(r rune) rune {
+		if 'A' <= r && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, s)
+}
+
+func compareSortValues(a, b any) int {
+	switch av := a.(type) {
+	case int64:
+		return cmp.Compare(av, b.(int64))
+	case int:
+		return cmp.Compare(av, b.(int))
+	case time.Time:
+		return av.Compare(b.(time.Time))
+	case string:
+		return strings.Compare(av, b.(string))
+	}
+	return 0
+}
+
+func encodeSortValue(v any) string {
+	switch tv := v.(type) {
+	case int64:
+		return strconv.FormatInt(tv, 10)
+	case int:
+		return strconv.Itoa(tv)
+	case time.Time:
+		return tv.Format(time.RFC3339Nano)
+	case string:
+		return tv
+	}
+	return ""
+}
+
+func decodeSortValue(field enums.SortField, s string) (any, error) {
+	switch field {
+	case enums.SortAmount:
+		return strconv.ParseInt(s, 10, 64)
+	case enums.SortCreated:
+		return time.Parse(time.RFC3339Nano, s)
+	case enums.SortType, enums.SortStatus, enums.SortDebtorName, enums.SortBeneficiaryName:
+		return s, nil
+	default:
+		return strconv.Atoi(s)
+	}
+}
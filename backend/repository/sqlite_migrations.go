@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// sqliteMigration upgrades the schema by one version inside a transaction
+type sqliteMigration func(tx *sql.Tx) error
+
+// sqliteMigrations are applied in order; PRAGMA user_version records how many have run.
+// Append new migrations to the end and never edit one that has shipped.
+var sqliteMigrations = []sqliteMigration{
+	migrateInitialSchema,
+	migrateCreatedToUTC,
+	migrateIndexSequence,
+	migrateItemVersion,
+	migrateAmountToMinorUnits,
+	migrateAmountScaled,
+	migrateStatusTransitions,
+	migrateReversalOriginal,
+	migrateAccountBank,
+	migrateAccountType,
+	migrateAuditEntries,
+	migrateSoftDelete,
+	migrateApprovals,
+	migrateTenants,
+	migrateChangedBy,
+}
+
+func migrateSQLite(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+
+	for v := version; v < len(sqliteMigrations); v++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if err := sqliteMigrations[v](tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("sqlite migration %d: %w", v+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, v+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func execAll(tx *sql.Tx, stmts ...string) error {
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateInitialSchema(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS items (
+			guid    TEXT PRIMARY KEY,
+			idx     INTEGER NOT NULL,
+			amount  REAL NOT NULL,
+			type    TEXT NOT NULL,
+			status  TEXT NOT NULL,
+			created TEXT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS items_order ON items (idx, guid)`,
+		`CREATE TABLE IF NOT EXISTS parties (
+			item_guid  TEXT NOT NULL REFERENCES items (guid) ON DELETE CASCADE,
+			role       TEXT NOT NULL CHECK (role IN ('debtor', 'beneficiary')),
+			first_name TEXT NOT NULL,
+			last_name  TEXT NOT NULL,
+			PRIMARY KEY (item_guid, role)
+		)`,
+		`CREATE TABLE IF NOT EXISTS accounts (
+			item_guid      TEXT NOT NULL,
+			role           TEXT NOT NULL,
+			sort_code      TEXT NOT NULL,
+			account_number TEXT NOT NULL,
+			PRIMARY KEY (item_guid, role),
+			FOREIGN KEY (item_guid, role) REFERENCES parties (item_guid, role) ON DELETE CASCADE
+		)`,
+	)
+}
+
+// migrateCreatedToUTC rewrites created timestamps in the fixed-width UTC layout so they compare correctly as text
+func migrateCreatedToUTC(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT guid, created FROM items`)
+	if err != nil {
+		return err
+	}
+
+	updates := make(map[string]string)
+	for rows.Next() {
+		var guid, created string
+		if err := rows.Scan(&guid, &created); err != nil {
+			rows.Close()
+			return err
+		}
+		t, err := time.Parse(time.RFC3339Nano, created)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		updates[guid] = formatTime(t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for guid, created := range updates {
+		if _, err := tx.Exec(`UPDATE items SET created = ? WHERE guid = ?`, created, guid); err != nil {
+			return err
+		}
+	}
+	return execAll(tx, `CREATE INDEX IF NOT EXISTS items_created ON items (created)`)
+}
+
+// migrateIndexSequence adds the counter Create allocates item indexes from, continuing after the highest existing index
+func migrateIndexSequence(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS sequences (
+			name  TEXT PRIMARY KEY,
+			value INTEGER NOT NULL
+		)`,
+		`INSERT INTO sequences (name, value) SELECT 'items', COALESCE(MAX(idx), 0) FROM items`,
+	)
+}
+
+// migrateItemVersion adds the optimistic concurrency version; existing items start at version 1
+func migrateItemVersion(tx *sql.Tx) error {
+	return execAll(tx, `ALTER TABLE items ADD COLUMN version INTEGER NOT NULL DEFAULT 1`)
+}
+
+// migrateAmountToMinorUnits replaces the floating-point amount with integer minor units and a currency.
+// Every amount stored before currencies existed was in pounds.
+func migrateAmountToMinorUnits(tx *sql.Tx) error {
+	return execAll(tx,
+		`ALTER TABLE items ADD COLUMN amount_minor INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE items ADD COLUMN currency TEXT NOT NULL DEFAULT 'GBP'`,
+		`UPDATE items SET amount_minor = CAST(ROUND(amount * 100) AS INTEGER)`,
+		`ALTER TABLE items DROP COLUMN amount`,
+	)
+}
+
+// migrateAmountScaled adds the amount in ten-thousandths of a major unit (models.Money.Scaled), which
+// filters and sorts amounts in different currencies by face value. Existing amounts are all in pounds.
+func migrateAmountScaled(tx *sql.Tx) error {
+	return execAll(tx,
+		`ALTER TABLE items ADD COLUMN amount_scaled INTEGER NOT NULL DEFAULT 0`,
+		`UPDATE items SET amount_scaled = amount_minor * 100`,
+		`CREATE INDEX IF NOT EXISTS items_currency ON items (currency)`,
+	)
+}
+
+// migrateStatusTransitions adds each item's status history, in the order the transitions were made
+func migrateStatusTransitions(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS transitions (
+			item_guid   TEXT NOT NULL REFERENCES items (guid) ON DELETE CASCADE,
+			seq         INTEGER NOT NULL,
+			from_status TEXT NOT NULL,
+			to_status   TEXT NOT NULL,
+			actor       TEXT NOT NULL,
+			reason      TEXT NOT NULL,
+			at          TEXT NOT NULL,
+			PRIMARY KEY (item_guid, seq)
+		)`,
+	)
+}
+
+// migrateReversalOriginal links reversals to the item they reverse; existing items link to nothing
+func migrateReversalOriginal(tx *sql.Tx) error {
+	return execAll(tx,
+		`ALTER TABLE items ADD COLUMN original_guid TEXT NOT NULL DEFAULT ''`,
+		`CREATE INDEX IF NOT EXISTS items_original ON items (original_guid)`,
+	)
+}
+
+// migrateAccountBank adds the bank details looked up from the sort code directory. They are NULL for
+// existing accounts, which were saved before the directory existed.
+func migrateAccountBank(tx *sql.Tx) error {
+	return execAll(tx,
+		`ALTER TABLE accounts ADD COLUMN bank_name TEXT`,
+		`ALTER TABLE accounts ADD COLUMN branch_name TEXT`,
+		`ALTER TABLE accounts ADD COLUMN schemes TEXT`,
+	)
+}
+
+// migrateAccountType adds IBAN accounts alongside UK ones; existing accounts are all UK accounts
+func migrateAccountType(tx *sql.Tx) error {
+	return execAll(tx,
+		`ALTER TABLE accounts ADD COLUMN type TEXT NOT NULL DEFAULT 'UK'`,
+		`ALTER TABLE accounts ADD COLUMN iban TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE accounts ADD COLUMN bic TEXT NOT NULL DEFAULT ''`,
+	)
+}
+
+// migrateAuditEntries adds the audit trail of each item, which is kept after the item is deleted and
+// can only be appended to. Existing items have no history before this migration.
+func migrateAuditEntries(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS audit_entries (
+			item_guid  TEXT NOT NULL,
+			seq        INTEGER NOT NULL,
+			action     TEXT NOT NULL,
+			actor      TEXT NOT NULL,
+			request_id TEXT NOT NULL,
+			at         TEXT NOT NULL,
+			version    INTEGER NOT NULL,
+			changes    TEXT NOT NULL,
+			PRIMARY KEY (item_guid, seq)
+		)`,
+		`CREATE TRIGGER IF NOT EXISTS audit_entries_no_update BEFORE UPDATE ON audit_entries
+		BEGIN SELECT RAISE(ABORT, 'audit entries cannot be changed'); END`,
+		`CREATE TRIGGER IF NOT EXISTS audit_entries_no_delete BEFORE DELETE ON audit_entries
+		BEGIN SELECT RAISE(ABORT, 'audit entries cannot be deleted'); END`,
+	)
+}
+
+// migrateSoftDelete marks items deleted instead of removing them; no existing item is deleted
+func migrateSoftDelete(tx *sql.Tx) error {
+	return execAll(tx,
+		`ALTER TABLE items ADD COLUMN deleted_at TEXT`,
+		`CREATE INDEX IF NOT EXISTS items_deleted ON items (deleted_at)`,
+	)
+}
+
+// migrateApprovals adds who created each item and the approvals it needs and has been given. Items
+// created before approvals existed were created by nobody recorded and needed none.
+func migrateApprovals(tx *sql.Tx) error {
+	return execAll(tx,
+		`ALTER TABLE items ADD COLUMN created_by TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE items ADD COLUMN required_approvals INTEGER NOT NULL DEFAULT 0`,
+		`CREATE TABLE IF NOT EXISTS approvals (
+			item_guid TEXT NOT NULL REFERENCES items (guid) ON DELETE CASCADE,
+			seq       INTEGER NOT NULL,
+			actor     TEXT NOT NULL,
+			decision  TEXT NOT NULL,
+			reason    TEXT NOT NULL,
+			at        TEXT NOT NULL,
+			PRIMARY KEY (item_guid, seq)
+		)`,
+	)
+}
+
+// migrateTenants scopes items and their audit trails to a tenant, with an index sequence per tenant.
+// Everything stored before tenants existed belongs to DefaultTenant, which continues the old sequence.
+func migrateTenants(tx *sql.Tx) error {
+	return execAll(tx,
+		`ALTER TABLE items ADD COLUMN tenant TEXT NOT NULL DEFAULT 'default'`,
+		`DROP INDEX IF EXISTS items_order`,
+		`CREATE INDEX IF NOT EXISTS items_tenant_order ON items (tenant, idx, guid)`,
+		`ALTER TABLE audit_entries ADD COLUMN tenant TEXT NOT NULL DEFAULT 'default'`,
+		`CREATE INDEX IF NOT EXISTS audit_entries_tenant ON audit_entries (tenant, item_guid)`,
+		`UPDATE sequences SET name = 'items:default' WHERE name = 'items'`,
+	)
+}
+
+// migrateChangedBy adds who last changed each item's amount, currency or parties and held it for approval
+// again; no existing item records one
+func migrateChangedBy(tx *sql.Tx) error {
+	return execAll(tx, `ALTER TABLE items ADD COLUMN changed_by TEXT NOT NULL DEFAULT ''`)
+}
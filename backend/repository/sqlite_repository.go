@@ -0,0 +1,1018 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"slices"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const (
+	roleDebtor      = "debtor"
+	roleBeneficiary = "beneficiary"
+)
+
+// sqliteTimeLayout is fixed width so that UTC timestamps sort and compare correctly as text
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// fromItems joins an item to both of its parties so filters can reference them
+const fromItems = `
+	FROM items i
+	LEFT JOIN parties d ON d.item_guid = i.guid AND d.role = 'debtor'
+	LEFT JOIN accounts da ON da.item_guid = i.guid AND da.role = 'debtor'
+	LEFT JOIN parties b ON b.item_guid = i.guid AND b.role = 'beneficiary'
+	LEFT JOIN accounts ba ON ba.item_guid = i.guid AND ba.role = 'beneficiary'`
+
+// netAmount is the amount of an item other than a reversal less its reversals that have not been
+// declined or returned (see enums.ItemStatus.IsVoid) or deleted, or NULL for a reversal
+const netAmount = `
+	CASE WHEN i.type = 'REVERSAL' THEN NULL ELSE i.amount_minor - COALESCE((
+		SELECT SUM(r.amount_minor) FROM items r
+		WHERE r.original_guid = i.guid AND r.tenant = i.tenant AND r.status NOT IN ('DECLINED', 'RETURNED') AND r.deleted_at IS NULL
+	), 0) END`
+
+// notDeleted is the condition that an item in fromItems has not been soft deleted
+const notDeleted = `i.deleted_at IS NULL`
+
+// selectItems flattens an item and both of its parties into a single row
+const selectItems = `
+	SELECT i.guid, i.idx, i.version, i.amount_minor, i.currency, i.type, i.status, i.created, i.original_guid, i.deleted_at,
+		i.created_by, i.changed_by, i.required_approvals, i.tenant,` + netAmount + `,
+		COALESCE(d.first_name, ''), COALESCE(d.last_name, ''),
+		COALESCE(da.type, ''), COALESCE(da.sort_code, ''), COALESCE(da.account_number, ''), COALESCE(da.iban, ''), COALESCE(da.bic, ''),
+		da.bank_name, da.branch_name, da.schemes,
+		COALESCE(b.first_name, ''), COALESCE(b.last_name, ''),
+		COALESCE(ba.type, ''), COALESCE(ba.sort_code, ''), COALESCE(ba.account_number, ''), COALESCE(ba.iban, ''), COALESCE(ba.bic, ''),
+		ba.bank_name, ba.branch_name, ba.schemes` + fromItems
+
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// querier runs queries on the database or inside a transaction
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// NewSQLiteStore opens (or creates) the SQLite database at path and migrates it to the current schema
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer, so serialise access through one connection.
+	// This also keeps ":memory:" databases alive for the lifetime of the store.
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// Close releases the underlying database handle
+func (ss *SQLiteStore) Close() error {
+	return ss.db.Close()
+}
+
+// GetAll returns all items of the tenant that have not been deleted
+func (ss *SQLiteStore) GetAll(ctx context.Context) ([]models.Item, error) {
+	return queryItems(ss.db, selectItems+` WHERE i.tenant = ? AND `+notDeleted+` ORDER BY i.idx, i.guid`, TenantFrom(ctx))
+}
+
+// GetAllFiltered returns one page of the tenant's filtered items using keyset pagination on the
+// sort columns
+func (ss *SQLiteStore) GetAllFiltered(ctx context.Context, query ItemQuery) (ItemPage, error) {
+	var page ItemPage
+
+	var at sortPosition
+	if query.Cursor != nil {
+		var err error
+		if at, err = query.cursorPosition(); err != nil {
+			return page, err
+		}
+	}
+
+	where, args := sqliteFilter(TenantFrom(ctx), query)
+	if err := ss.summarize(&page, where, args); err != nil {
+		return page, err
+	}
+
+	columns := sqliteSortColumns(query)
+	forward := query.Cursor == nil || !query.Cursor.Before
+	pageWhere, pageArgs := slices.Clone(where), slices.Clone(args)
+	if query.Cursor != nil {
+		cond, condArgs := keysetCondition(columns, at, forward)
+		pageWhere = append(pageWhere, cond)
+		pageArgs = append(pageArgs, condArgs...)
+	}
+
+	stmt := selectItems + whereClause(pageWhere) + orderByClause(columns, !forward)
+	if query.Limit > 0 {
+		stmt += ` LIMIT ?`
+		pageArgs = append(pageArgs, query.Limit+1)
+	}
+
+	items, err := queryItems(ss.db, stmt, pageArgs...)
+	if err != nil {
+		return page, err
+	}
+	more := query.Limit > 0 && len(items) > query.Limit
+	if more {
+		items = items[:query.Limit]
+	}
+	if !forward {
+		slices.Reverse(items)
+	}
+	page.Items = items
+
+	if query.Limit > 0 && len(items) > 0 {
+		first, last := items[0], items[len(items)-1]
+
+		hasBefore, hasAfter := more, more
+		if forward {
+			cond, condArgs := keysetCondition(columns, query.position(first), false)
+			hasBefore, err = ss.exists(append(slices.Clone(where), cond), append(slices.Clone(args), condArgs...))
+		} else {
+			cond, condArgs := keysetCondition(columns, query.position(last), true)
+			hasAfter, err = ss.exists(append(slices.Clone(where), cond), append(slices.Clone(args), condArgs...))
+		}
+		if err != nil {
+			return page, err
+		}
+
+		if hasBefore {
+			page.PrevCursor = query.cursorFor(first, true)
+		}
+		if hasAfter {
+			page.NextCursor = query.cursorFor(last, false)
+		}
+	}
+
+	return page, nil
+}
+
+// GetByGUID returns one of the tenant's items by GUID, unless it has been deleted
+func (ss *SQLiteStore) GetByGUID(ctx context.Context, guid string) (*models.Item, error) {
+	item, err := getItem(ss.db, TenantFrom(ctx), guid)
+	if err == nil && item.IsDeleted() {
+		return nil, ErrNotFound
+	}
+	return item, err
+}
+
+// GetDeletedByGUID returns one of the tenant's soft-deleted items by GUID, or ErrNotFound if no
+// deleted item of the tenant has it
+func (ss *SQLiteStore) GetDeletedByGUID(ctx context.Context, guid string) (*models.Item, error) {
+	item, err := getItem(ss.db, TenantFrom(ctx), guid)
+	if err == nil && !item.IsDeleted() {
+		return nil, ErrNotFound
+	}
+	return item, err
+}
+
+// getItem reads one of a tenant's items by GUID, whether or not it has been deleted
+func getItem(q querier, tenant, guid string) (*models.Item, error) {
+	items, err := queryItems(q, selectItems+` WHERE i.tenant = ? AND i.guid = ?`, tenant, guid)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrNotFound
+	}
+	return &items[0], nil
+}
+
+// Count returns the number of the tenant's items that have not been deleted
+func (ss *SQLiteStore) Count(ctx context.Context) (int, error) {
+	var count int
+	err := ss.db.QueryRow(`SELECT COUNT(*) FROM items i WHERE i.tenant = ? AND `+notDeleted, TenantFrom(ctx)).Scan(&count)
+	return count, err
+}
+
+// History returns the audit trail of one of the tenant's items, oldest change first, even if the
+// item has been deleted
+func (ss *SQLiteStore) History(ctx context.Context, guid string) ([]models.AuditEntry, error) {
+	rows, err := ss.db.Query(
+		`SELECT seq, action, actor, request_id, at, version, changes FROM audit_entries WHERE tenant = ? AND item_guid = ? ORDER BY seq`,
+		TenantFrom(ctx), guid,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.AuditEntry, 0)
+	for rows.Next() {
+		entry := models.AuditEntry{GUID: guid}
+		var action, at, changes string
+		if err := rows.Scan(&entry.Seq, &action, &entry.Actor, &entry.RequestID, &at, &entry.Version, &changes); err != nil {
+			return nil, err
+		}
+		entry.Action = enums.AuditAction(action)
+		if entry.At, err = time.Parse(time.RFC3339Nano, at); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(changes), &entry.Changes); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// Create adds a new item to the tenant at version 1, assigning it the next Index in the tenant's
+// sequence. Indexes are never reused, even after the item holding one is deleted.
+func (ss *SQLiteStore) Create(ctx context.Context, item *models.Item) error {
+	if item == nil {
+		return errors.New("item cannot be nil")
+	}
+	if item.GUID == "" {
+		return errors.New("item GUID cannot be empty")
+	}
+
+	return ss.withTx(func(tx *sql.Tx) error {
+		return createItem(ctx, tx, item)
+	})
+}
+
+// CreateAll adds several items as Create does, in one transaction so that if any cannot be created,
+// none are
+func (ss *SQLiteStore) CreateAll(ctx context.Context, items []*models.Item) error {
+	for _, item := range items {
+		if item == nil {
+			return errors.New("item cannot be nil")
+		}
+		if item.GUID == "" {
+			return errors.New("item GUID cannot be empty")
+		}
+	}
+
+	created := make([]models.Item, len(items))
+	err := ss.withTx(func(tx *sql.Tx) error {
+		for i, item := range items {
+			created[i] = *item
+			if err := createItem(ctx, tx, &created[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range items {
+		*items[i] = created[i]
+	}
+	return nil
+}
+
+// createItem inserts an item into the tenant at version 1 with the next Index in the tenant's sequence
+func createItem(ctx context.Context, tx *sql.Tx, item *models.Item) error {
+	tenant := TenantFrom(ctx)
+	var heldElsewhere bool
+	err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM items WHERE guid = ? AND tenant <> ?)`, item.GUID, tenant).Scan(&heldElsewhere)
+	if err != nil {
+		return err
+	}
+	if heldElsewhere {
+		return ErrGUIDInUse
+	}
+
+	before, err := getItem(tx, tenant, item.GUID)
+	if errors.Is(err, ErrNotFound) {
+		before = nil
+	} else if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(
+		`INSERT INTO sequences (name, value) VALUES (?, 1) ON CONFLICT (name) DO UPDATE SET value = value + 1 RETURNING value`,
+		sequenceName(tenant),
+	).Scan(&item.Index)
+	if err != nil {
+		return err
+	}
+
+	item.Tenant = tenant
+	item.Version = 1
+	item.DeletedAt = nil
+
+	_, err = tx.Exec(
+		`INSERT INTO items (guid, tenant, idx, version, amount_minor, currency, amount_scaled, type, status, created, original_guid, created_by, changed_by, required_approvals)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (guid) DO UPDATE SET idx = excluded.idx, version = excluded.version,
+			amount_minor = excluded.amount_minor, currency = excluded.currency, amount_scaled = excluded.amount_scaled,
+			type = excluded.type, status = excluded.status, created = excluded.created, original_guid = excluded.original_guid,
+			created_by = excluded.created_by, changed_by = excluded.changed_by, required_approvals = excluded.required_approvals, deleted_at = NULL`,
+		item.GUID, item.Tenant, item.Index, item.Version, item.Amount.Minor, item.Amount.CurrencyCode(), item.Amount.Scaled(), string(item.Type), string(item.Status), formatTime(item.Created), item.OriginalGUID,
+		item.CreatedBy, item.ChangedBy, item.RequiredApprovals,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Mirror the in-memory store, where creating an existing GUID replaces it
+	if _, err := tx.Exec(`DELETE FROM parties WHERE item_guid = ?`, item.GUID); err != nil {
+		return err
+	}
+	if err := insertAttributes(tx, item.GUID, item.Attributes); err != nil {
+		return err
+	}
+	if err := insertTransitions(tx, item.GUID, item.Transitions); err != nil {
+		return err
+	}
+	if err := insertApprovals(tx, item.GUID, item.Approvals); err != nil {
+		return err
+	}
+	if err := scanNetAmount(tx, item); err != nil {
+		return err
+	}
+	return insertAuditEntry(ctx, tx, enums.AuditCreate, before, item)
+}
+
+// Update replaces one of the tenant's items by a given GUID if it is still at item.Version, then
+// bumps item.Version. It returns ErrVersionConflict if the item has been changed since that version
+// was read.
+func (ss *SQLiteStore) Update(ctx context.Context, item *models.Item) error {
+	if item == nil {
+		return errors.New("item cannot be nil")
+	}
+	if item.GUID == "" {
+		return errors.New("item GUID cannot be empty")
+	}
+
+	tenant := TenantFrom(ctx)
+	err := ss.withTx(func(tx *sql.Tx) error {
+		before, err := getItem(tx, tenant, item.GUID)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.Exec(
+			`UPDATE items SET idx = ?, version = version + 1, amount_minor = ?, currency = ?, amount_scaled = ?,
+				type = ?, status = ?, created = ?, original_guid = ?, created_by = ?, changed_by = ?, required_approvals = ?
+			WHERE guid = ? AND tenant = ? AND version = ? AND deleted_at IS NULL`,
+			item.Index, item.Amount.Minor, item.Amount.CurrencyCode(), item.Amount.Scaled(), string(item.Type), string(item.Status), formatTime(item.Created), item.OriginalGUID,
+			item.CreatedBy, item.ChangedBy, item.RequiredApprovals, item.GUID, tenant, item.Version,
+		)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return missingOrConflict(tx, tenant, item.GUID)
+		}
+
+		if _, err := tx.Exec(`DELETE FROM parties WHERE item_guid = ?`, item.GUID); err != nil {
+			return err
+		}
+		if err := insertAttributes(tx, item.GUID, item.Attributes); err != nil {
+			return err
+		}
+		if err := insertTransitions(tx, item.GUID, item.Transitions); err != nil {
+			return err
+		}
+		if err := insertApprovals(tx, item.GUID, item.Approvals); err != nil {
+			return err
+		}
+		if err := scanNetAmount(tx, item); err != nil {
+			return err
+		}
+
+		updated := *item
+		updated.Tenant = tenant
+		updated.Version++
+		return insertAuditEntry(ctx, tx, enums.AuditUpdate, before, &updated)
+	})
+	if err != nil {
+		return err
+	}
+
+	item.Tenant = tenant
+	item.Version++
+	return nil
+}
+
+// Delete soft deletes one of the tenant's items by GUID, marking it deleted at the time of the
+// change and bumping its version. A non-zero version makes the delete conditional on the item still
+// being at that version, returning ErrVersionConflict otherwise.
+func (ss *SQLiteStore) Delete(ctx context.Context, guid string, version int) error {
+	return ss.withTx(func(tx *sql.Tx) error {
+		before, err := getItem(tx, TenantFrom(ctx), guid)
+		if err != nil {
+			return err
+		}
+		if before.IsDeleted() {
+			return ErrNotFound
+		}
+		if version != 0 && before.Version != version {
+			return ErrVersionConflict
+		}
+
+		change := ChangeFrom(ctx)
+		deletedAt := change.At.UTC()
+		deleted := *before
+		deleted.Version++
+		deleted.DeletedAt = &deletedAt
+		if _, err := tx.Exec(`UPDATE items SET version = ?, deleted_at = ? WHERE guid = ?`, deleted.Version, formatTime(deletedAt), guid); err != nil {
+			return err
+		}
+		return insertAuditEntry(WithChange(ctx, change), tx, enums.AuditDelete, before, &deleted)
+	})
+}
+
+// Restore undoes the soft delete of one of the tenant's items by GUID and bumps its version,
+// returning the item as restored. It returns ErrNotDeleted if the item has not been deleted, and a
+// non-zero version makes the restore conditional on the item still being at that version.
+func (ss *SQLiteStore) Restore(ctx context.Context, guid string, version int) (*models.Item, error) {
+	tenant := TenantFrom(ctx)
+	var restored *models.Item
+	err := ss.withTx(func(tx *sql.Tx) error {
+		before, err := getItem(tx, tenant, guid)
+		if err != nil {
+			return err
+		}
+		if !before.IsDeleted() {
+			return ErrNotDeleted
+		}
+		if version != 0 && before.Version != version {
+			return ErrVersionConflict
+		}
+
+		if _, err := tx.Exec(`UPDATE items SET version = version + 1, deleted_at = NULL WHERE guid = ?`, guid); err != nil {
+			return err
+		}
+		if restored, err = getItem(tx, tenant, guid); err != nil {
+			return err
+		}
+		return insertAuditEntry(ctx, tx, enums.AuditRestore, before, restored)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// Purge permanently removes every item of the tenant soft deleted before deletedBefore, in one
+// transaction, and returns their GUIDs in index order. Their audit trails are kept.
+func (ss *SQLiteStore) Purge(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	guids := make([]string, 0)
+	err := ss.withTx(func(tx *sql.Tx) error {
+		purged, err := queryItems(tx, selectItems+` WHERE i.tenant = ? AND i.deleted_at < ? ORDER BY i.idx, i.guid`, TenantFrom(ctx), formatTime(deletedBefore))
+		if err != nil {
+			return err
+		}
+		for _, item := range purged {
+			if _, err := tx.Exec(`DELETE FROM items WHERE guid = ?`, item.GUID); err != nil {
+				return err
+			}
+			if err := insertAuditEntry(ctx, tx, enums.AuditPurge, &item, nil); err != nil {
+				return err
+			}
+			guids = append(guids, item.GUID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return guids, nil
+}
+
+// missingOrConflict explains why a versioned write to one of a tenant's live items matched no rows
+func missingOrConflict(tx *sql.Tx, tenant, guid string) error {
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM items WHERE guid = ? AND tenant = ? AND deleted_at IS NULL)`, guid, tenant).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrVersionConflict
+	}
+	return ErrNotFound
+}
+
+// summarize sets the page's per-currency totals and overall total for the filtered items
+func (ss *SQLiteStore) summarize(page *ItemPage, where []string, args []any) error {
+	rows, err := ss.db.Query(
+		`SELECT i.currency, COUNT(*), SUM(i.amount_minor)`+fromItems+whereClause(where)+` GROUP BY i.currency ORDER BY i.currency`,
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	page.Totals = make([]CurrencyTotal, 0)
+	for rows.Next() {
+		var t CurrencyTotal
+		if err := rows.Scan(&t.Amount.Currency, &t.Count, &t.Amount.Minor); err != nil {
+			return err
+		}
+		page.Totals = append(page.Totals, t)
+		page.Total += t.Count
+	}
+	return rows.Err()
+}
+
+func (ss *SQLiteStore) exists(where []string, args []any) (bool, error) {
+	var found bool
+	err := ss.db.QueryRow(`SELECT EXISTS (SELECT 1`+fromItems+whereClause(where)+`)`, args...).Scan(&found)
+	return found, err
+}
+
+// sqliteFilter translates the query's filters into WHERE conditions over fromItems, which only
+// match the tenant's items
+func sqliteFilter(tenant string, query ItemQuery) ([]string, []any) {
+	where := []string{`i.tenant = ?`}
+	args := []any{tenant}
+	if !query.IncludeDeleted {
+		where = append(where, notDeleted)
+	}
+
+	if query.Search != "" {
+		where = append(where, `(instr(lower(i.guid), ?) > 0 OR instr(lower(i.type), ?) > 0 OR instr(lower(i.status), ?) > 0)`)
+		searchLower := strings.ToLower(query.Search)
+		args = append(args, searchLower, searchLower, searchLower)
+	}
+	if len(query.Types) > 0 {
+		where = append(where, `i.type IN (`+placeholders(len(query.Types))+`)`)
+		for _, t := range query.Types {
+			args = append(args, string(t))
+		}
+	}
+	if len(query.Statuses) > 0 {
+		where = append(where, `i.status IN (`+placeholders(len(query.Statuses))+`)`)
+		for _, st := range query.Statuses {
+			args = append(args, string(st))
+		}
+	}
+	if len(query.Currencies) > 0 {
+		where = append(where, `i.currency IN (`+placeholders(len(query.Currencies))+`)`)
+		for _, c := range query.Currencies {
+			args = append(args, c)
+		}
+	}
+	if query.AmountMin != nil {
+		where = append(where, `i.amount_scaled >= ?`)
+		args = append(args, query.AmountMin.Scaled())
+	}
+	if query.AmountMax != nil {
+		where = append(where, `i.amount_scaled <= ?`)
+		args = append(args, query.AmountMax.Scaled())
+	}
+	if query.CreatedFrom != nil {
+		where = append(where, `i.created >= ?`)
+		args = append(args, formatTime(*query.CreatedFrom))
+	}
+	if query.CreatedTo != nil {
+		where = append(where, `i.created <= ?`)
+		args = append(args, formatTime(*query.CreatedTo))
+	}
+	if query.DebtorName != "" {
+		where = append(where, `instr(lower(d.first_name || ' ' || d.last_name), ?) > 0`)
+		args = append(args, strings.ToLower(query.DebtorName))
+	}
+	if query.BeneficiaryName != "" {
+		where = append(where, `instr(lower(b.first_name || ' ' || b.last_name), ?) > 0`)
+		args = append(args, strings.ToLower(query.BeneficiaryName))
+	}
+	if query.SortCode != "" {
+		where = append(where, `(da.sort_code = ? OR ba.sort_code = ?)`)
+		args = append(args, query.SortCode, query.SortCode)
+	}
+	if query.AccountNumber != "" {
+		where = append(where, `(da.account_number = ? OR ba.account_number = ?)`)
+		args = append(args, query.AccountNumber, query.AccountNumber)
+	}
+	if query.OriginalGUID != "" {
+		where = append(where, `i.original_guid = ?`)
+		args = append(args, query.OriginalGUID)
+	}
+
+	return where, args
+}
+
+// sqliteSortColumn is one ORDER BY term of a query's sort
+type sqliteSortColumn struct {
+	expr string
+	desc bool
+}
+
+// sqliteSortColumns lists the query's sort keys followed by the (idx, guid) tie-breaker
+func sqliteSortColumns(query ItemQuery) []sqliteSortColumn {
+	columns := make([]sqliteSortColumn, 0, len(query.Sort)+2)
+	for _, k := range query.Sort {
+		columns = append(columns, sqliteSortColumn{expr: sqliteSortExpr(k.Field), desc: k.Desc})
+	}
+	return append(columns, sqliteSortColumn{expr: `i.idx`}, sqliteSortColumn{expr: `i.guid`})
+}
+
+// sqliteSortExpr mirrors sortValue for a sort field
+func sqliteSortExpr(field enums.SortField) string {
+	switch field {
+	case enums.SortAmount:
+		return `i.amount_scaled`
+	case enums.SortCreated:
+		return `i.created`
+	case enums.SortType:
+		return `i.type`
+	case enums.SortStatus:
+		return `i.status`
+	case enums.SortDebtorName:
+		return `lower(COALESCE(d.last_name, '') || ' ' || COALESCE(d.first_name, ''))`
+	case enums.SortBeneficiaryName:
+		return `lower(COALESCE(b.last_name, '') || ' ' || COALESCE(b.first_name, ''))`
+	default:
+		return `i.idx`
+	}
+}
+
+// keysetCondition matches rows strictly after (or before) a position in the sort order, expanding
+// (a, b, c) > (x, y, z) into a disjunction so that each column can have its own direction
+func keysetCondition(columns []sqliteSortColumn, at sortPosition, after bool) (string, []any) {
+	values := make([]any, 0, len(columns))
+	for _, v := range at.values {
+		if t, ok := v.(time.Time); ok {
+			v = formatTime(t)
+		}
+		values = append(values, v)
+	}
+	values = append(values, at.key.index, at.key.guid)
+
+	var terms []string
+	var args []any
+	for i, col := range columns {
+		op := `>`
+		if col.desc == after {
+			op = `<`
+		}
+
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, columns[j].expr+` = ?`)
+			args = append(args, values[j])
+		}
+		parts = append(parts, col.expr+` `+op+` ?`)
+		args = append(args, values[i])
+		terms = append(terms, `(`+strings.Join(parts, ` AND `)+`)`)
+	}
+	return `(` + strings.Join(terms, ` OR `) + `)`, args
+}
+
+// orderByClause orders by the sort columns, or against them when paging backward
+func orderByClause(columns []sqliteSortColumn, reverse bool) string {
+	terms := make([]string, 0, len(columns))
+	for _, col := range columns {
+		if col.desc != reverse {
+			terms = append(terms, col.expr+` DESC`)
+		} else {
+			terms = append(terms, col.expr)
+		}
+	}
+	return ` ORDER BY ` + strings.Join(terms, `, `)
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func whereClause(where []string) string {
+	if len(where) == 0 {
+		return ""
+	}
+	return ` WHERE ` + strings.Join(where, ` AND `)
+}
+
+func (ss *SQLiteStore) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func queryItems(q querier, query string, args ...any) ([]models.Item, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.Item, 0)
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := loadTransitions(q, items); err != nil {
+		return nil, err
+	}
+	return items, loadApprovals(q, items)
+}
+
+// loadTransitions fills in the status history of each item
+func loadTransitions(q querier, items []models.Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	positions := make(map[string]int, len(items))
+	guids := make([]string, len(items))
+	for i, item := range items {
+		positions[item.GUID] = i
+		guids[i] = item.GUID
+	}
+
+	// Pass the GUIDs as one JSON array so that large pages stay within SQLite's parameter limit
+	guidsJSON, err := json.Marshal(guids)
+	if err != nil {
+		return err
+	}
+	rows, err := q.Query(
+		`SELECT item_guid, from_status, to_status, actor, reason, at FROM transitions
+		WHERE item_guid IN (SELECT value FROM json_each(?)) ORDER BY item_guid, seq`,
+		string(guidsJSON),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var guid, from, to, at string
+		var t models.StatusTransition
+		if err := rows.Scan(&guid, &from, &to, &t.Actor, &t.Reason, &at); err != nil {
+			return err
+		}
+		t.From, t.To = enums.ItemStatus(from), enums.ItemStatus(to)
+		if t.At, err = time.Parse(time.RFC3339Nano, at); err != nil {
+			return err
+		}
+
+		item := &items[positions[guid]]
+		item.Transitions = append(item.Transitions, t)
+	}
+	return rows.Err()
+}
+
+// loadApprovals fills in the approval decisions on each item
+func loadApprovals(q querier, items []models.Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	positions := make(map[string]int, len(items))
+	guids := make([]string, len(items))
+	for i, item := range items {
+		positions[item.GUID] = i
+		guids[i] = item.GUID
+	}
+
+	guidsJSON, err := json.Marshal(guids)
+	if err != nil {
+		return err
+	}
+	rows, err := q.Query(
+		`SELECT item_guid, actor, decision, reason, at FROM approvals
+		WHERE item_guid IN (SELECT value FROM json_each(?)) ORDER BY item_guid, seq`,
+		string(guidsJSON),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var guid, decision, at string
+		var a models.Approval
+		if err := rows.Scan(&guid, &a.Actor, &decision, &a.Reason, &at); err != nil {
+			return err
+		}
+		a.Decision = enums.ApprovalDecision(decision)
+		if a.At, err = time.Parse(time.RFC3339Nano, at); err != nil {
+			return err
+		}
+
+		item := &items[positions[guid]]
+		item.Approvals = append(item.Approvals, a)
+	}
+	return rows.Err()
+}
+
+func scanItem(rows *sql.Rows) (models.Item, error) {
+	var item models.Item
+	var itemType, status, created string
+	var deletedAt sql.NullString
+	var net sql.NullInt64
+	var debtorBank, beneficiaryBank nullBank
+	debtor := &item.Attributes.Debtor
+	beneficiary := &item.Attributes.Beneficiary
+
+	err := rows.Scan(
+		&item.GUID, &item.Index, &item.Version, &item.Amount.Minor, &item.Amount.Currency, &itemType, &status, &created, &item.OriginalGUID, &deletedAt,
+		&item.CreatedBy, &item.ChangedBy, &item.RequiredApprovals, &item.Tenant, &net,
+		&debtor.FirstName, &debtor.LastName,
+		&debtor.Account.AccountType, &debtor.Account.SortCode, &debtor.Account.AccountNumber, &debtor.Account.IBAN, &debtor.Account.BIC,
+		&debtorBank.name, &debtorBank.branch, &debtorBank.schemes,
+		&beneficiary.FirstName, &beneficiary.LastName,
+		&beneficiary.Account.AccountType, &beneficiary.Account.SortCode, &beneficiary.Account.AccountNumber, &beneficiary.Account.IBAN, &beneficiary.Account.BIC,
+		&beneficiaryBank.name, &beneficiaryBank.branch, &beneficiaryBank.schemes,
+	)
+	if err != nil {
+		return item, err
+	}
+	debtor.Account.Bank = debtorBank.bank()
+	beneficiary.Account.Bank = beneficiaryBank.bank()
+
+	item.Type = enums.ItemType(itemType)
+	item.Status = enums.ItemStatus(status)
+	if net.Valid {
+		item.NetAmount = &models.Money{Minor: net.Int64, Currency: item.Amount.Currency}
+	}
+	if deletedAt.Valid {
+		at, err := time.Parse(time.RFC3339Nano, deletedAt.String)
+		if err != nil {
+			return item, err
+		}
+		item.DeletedAt = &at
+	}
+	item.Created, err = time.Parse(time.RFC3339Nano, created)
+	return item, err
+}
+
+// scanNetAmount refreshes the NetAmount of an item just written in tx
+func scanNetAmount(tx *sql.Tx, item *models.Item) error {
+	var net sql.NullInt64
+	if err := tx.QueryRow(`SELECT`+netAmount+` FROM items i WHERE i.guid = ?`, item.GUID).Scan(&net); err != nil {
+		return err
+	}
+
+	item.NetAmount = nil
+	if net.Valid {
+		item.NetAmount = &models.Money{Minor: net.Int64, Currency: item.Amount.CurrencyCode()}
+	}
+	return nil
+}
+
+func insertAttributes(tx *sql.Tx, guid string, attributes models.Attributes) error {
+	parties := []struct {
+		role  string
+		party models.Party
+	}{
+		{roleDebtor, attributes.Debtor},
+		{roleBeneficiary, attributes.Beneficiary},
+	}
+
+	for _, p := range parties {
+		_, err := tx.Exec(
+			`INSERT INTO parties (item_guid, role, first_name, last_name) VALUES (?, ?, ?, ?)`,
+			guid, p.role, p.party.FirstName, p.party.LastName,
+		)
+		if err != nil {
+			return err
+		}
+		var bankName, branchName, schemes sql.NullString
+		if bank := p.party.Account.Bank; bank != nil {
+			bankName = sql.NullString{String: bank.Name, Valid: true}
+			branchName = sql.NullString{String: bank.Branch, Valid: true}
+			schemes = sql.NullString{String: joinSchemes(bank.Schemes), Valid: true}
+		}
+		account := p.party.Account
+		accountType := account.AccountType
+		if accountType == "" {
+			accountType = enums.UKAccount
+		}
+		_, err = tx.Exec(
+			`INSERT INTO accounts (item_guid, role, type, sort_code, account_number, iban, bic, bank_name, branch_name, schemes)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			guid, p.role, string(accountType), account.SortCode, account.AccountNumber, account.IBAN, account.BIC, bankName, branchName, schemes,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nullBank scans an account's bank details, which are NULL if it has none
+type nullBank struct {
+	name, branch, schemes sql.NullString
+}
+
+func (nb nullBank) bank() *models.Bank {
+	if !nb.name.Valid {
+		return nil
+	}
+
+	bank := &models.Bank{Name: nb.name.String, Branch: nb.branch.String, Schemes: []enums.PaymentScheme{}}
+	if nb.schemes.String != "" {
+		for _, scheme := range strings.Split(nb.schemes.String, ",") {
+			bank.Schemes = append(bank.Schemes, enums.PaymentScheme(scheme))
+		}
+	}
+	return bank
+}
+
+// joinSchemes stores payment schemes as a comma-separated list
+func joinSchemes(schemes []enums.PaymentScheme) string {
+	names := make([]string, len(schemes))
+	for i, scheme := range schemes {
+		names[i] = string(scheme)
+	}
+	return strings.Join(names, ",")
+}
+
+// insertTransitions replaces an item's stored status history
+func insertTransitions(tx *sql.Tx, guid string, transitions []models.StatusTransition) error {
+	if _, err := tx.Exec(`DELETE FROM transitions WHERE item_guid = ?`, guid); err != nil {
+		return err
+	}
+
+	for seq, t := range transitions {
+		_, err := tx.Exec(
+			`INSERT INTO transitions (item_guid, seq, from_status, to_status, actor, reason, at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			guid, seq+1, string(t.From), string(t.To), t.Actor, t.Reason, formatTime(t.At),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertApprovals replaces an item's stored approval decisions
+func insertApprovals(tx *sql.Tx, guid string, approvals []models.Approval) error {
+	if _, err := tx.Exec(`DELETE FROM approvals WHERE item_guid = ?`, guid); err != nil {
+		return err
+	}
+
+	for seq, a := range approvals {
+		_, err := tx.Exec(
+			`INSERT INTO approvals (item_guid, seq, actor, decision, reason, at) VALUES (?, ?, ?, ?, ?, ?)`,
+			guid, seq+1, a.Actor, string(a.Decision), a.Reason, formatTime(a.At),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertAuditEntry appends the change carried by ctx from before to after to the item's audit trail
+func insertAuditEntry(ctx context.Context, tx *sql.Tx, action enums.AuditAction, before, after *models.Item) error {
+	subject := after
+	if subject == nil {
+		subject = before
+	}
+	var seq int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(seq), 0) + 1 FROM audit_entries WHERE item_guid = ?`, subject.GUID).Scan(&seq); err != nil {
+		return err
+	}
+
+	entry, err := newAuditEntry(ctx, action, seq, before, after)
+	if err != nil {
+		return err
+	}
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		`INSERT INTO audit_entries (item_guid, tenant, seq, action, actor, request_id, at, version, changes) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.GUID, TenantFrom(ctx), entry.Seq, string(entry.Action), entry.Actor, entry.RequestID, formatTime(entry.At), entry.Version, string(changes),
+	)
+	return err
+}
+
+// sequenceName names the sequence a tenant's item indexes are allocated from
+func sequenceName(tenant string) string {
+	return "items:" + tenant
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"errors"
+)
+
+// DefaultTenant owns the items of requests that name no tenant, and every item stored before
+// items were scoped to tenants
+const DefaultTenant = "default"
+
+// ErrGUIDInUse is returned when creating an item whose GUID another tenant's item already has.
+// GUIDs are unique across tenants, so that no write to one tenant can replace another's item.
+var ErrGUIDInUse = errors.New("item GUID is in use by another tenant")
+
+type tenantKey struct{}
+
+// WithTenant returns a context scoping every storage method it is passed to to tenant's items
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFrom returns the tenant carried by ctx, or DefaultTenant if it carries none
+func TenantFrom(ctx context.Context) string {
+	if tenant, _ := ctx.Value(tenantKey{}).(string); tenant != "" {
+		return tenant
+	}
+	return DefaultTenant
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"go-test/backend/auth"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// TestHS256KeyID and TestRS256KeyID name the test keys in TestJWKS
+	TestHS256KeyID = "test-hs256"
+	TestRS256KeyID = "test-rs256"
+)
+
+// testSecret is the HS256 test key
+var testSecret = []byte("items-test-secret-for-hs256-tokens")
+
+// testRSAKey is the RS256 test key, generated once per test binary
+var testRSAKey = sync.OnceValue(func() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+})
+
+// TestJWKS returns a key set file holding the test keys tokens are minted with
+func TestJWKS() []byte {
+	encode := base64.RawURLEncoding.EncodeToString
+	public := testRSAKey().PublicKey
+	data, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "oct", "kid": TestHS256KeyID, "alg": "HS256", "use": "sig", "k": encode(testSecret)},
+		{"kty": "RSA", "kid": TestRS256KeyID, "alg": "RS256", "use": "sig", "n": encode(public.N.Bytes()), "e": encode(big.NewInt(int64(public.E)).Bytes())},
+	}})
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// TestVerifier returns a verifier accepting the tokens MintToken and SignToken make
+func TestVerifier() *auth.Verifier {
+	keys, err := auth.ParseJWKS(TestJWKS())
+	if err != nil {
+		panic(err)
+	}
+	return auth.NewVerifier(keys, auth.Options{})
+}
+
+// TestClaims returns claims for subject in roles, issued now and expiring in an hour
+func TestClaims(subject string, roles ...string) *auth.Claims {
+	now := time.Now()
+	return &auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+		Roles: roles,
+	}
+}
+
+// MintToken returns an RS256 token for subject in roles, expiring in an hour
+func MintToken(subject string, roles ...string) string {
+	return SignToken(jwt.SigningMethodRS256, TestRS256KeyID, TestClaims(subject, roles...))
+}
+
+// SignToken signs claims with the test key for method, naming kid in the token header when it is set
+func SignToken(method jwt.SigningMethod, kid string, claims jwt.Claims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	var key any = testSecret
+	if _, ok := method.(*jwt.SigningMethodRSA); ok {
+		key = testRSAKey()
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
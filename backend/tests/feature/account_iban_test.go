@@ -0,0 +1,152 @@
+package feature
+
+import (
+	"encoding/json"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInternationalAccounts(t *testing.T) {
+	r, _ := tests.SetupReadRouter()
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	// withBeneficiaryAccount replaces the UK beneficiary account of the valid payload
+	withBeneficiaryAccount := func(account string) string {
+		return strings.Replace(createValidCreatePayload(), `{
+					"sort_code": "87-65-43",
+					"account_number": "87654321"
+				}`, account, 1)
+	}
+
+	t.Run("It validates IBANs by country length and mod-97 checksum", func(t *testing.T) {
+		// Arrange
+		cases := []struct {
+			iban  string
+			valid bool
+		}{
+			{"GB82WEST12345698765432", true},
+			{"GB82 WEST 1234 5698 7654 32", true},
+			{"de89370400440532013000", true},
+			{"FR1420041010050500013M02606", true},
+			{"NL91ABNA0417164300", true},
+			{"BE68539007547034", true},
+			{"NO9386011117947", true},
+			{"MT84MALT011000012345MTLCAST001S", true},
+			{"GB82WEST12345698765431", false}, // checksum
+			{"GB82WEST1234569876543", false},  // one short for GB
+			{"NL91ABNA04171643001", false},    // one long for NL
+			{"XX82WEST12345698765432", false}, // unregistered country
+			{"GBXXWEST12345698765432", false}, // check digits not numeric
+			{"GB82WEST1234569876543!", false},
+			{"", false},
+		}
+
+		for _, tc := range cases {
+			// Act
+			valid := models.ValidIBAN(tc.iban)
+
+			// Assert
+			assert.Equal(t, tc.valid, valid, tc.iban)
+		}
+	})
+
+	t.Run("It creates items with IBAN accounts in electronic format", func(t *testing.T) {
+		// Arrange
+		payload := withBeneficiaryAccount(`{"type": "iban", "iban": "de89 3704 0044 0532 0130 00", "bic": "cobadeffxxx"}`)
+
+		// Act
+		created := send(http.MethodPost, "/items", payload)
+
+		// Assert
+		require.Equal(t, http.StatusCreated, created.Code)
+		var item models.Item
+		require.NoError(t, json.Unmarshal(created.Body.Bytes(), &item))
+
+		get := send(http.MethodGet, "/items/"+item.GUID, "")
+		assert.Equal(t, http.StatusOK, get.Code)
+		assert.Contains(t, get.Body.String(), `"account":{"type":"IBAN","sort_code":"","account_number":"","iban":"DE89370400440532013000","bic":"COBADEFFXXX"}`)
+
+		var stored models.Item
+		require.NoError(t, json.Unmarshal(get.Body.Bytes(), &stored))
+		assert.Equal(t, enums.UKAccount, stored.Attributes.Debtor.Account.AccountType)
+		assert.NotNil(t, stored.Attributes.Debtor.Account.Bank)
+		assert.Equal(t, enums.IBANAccount, stored.Attributes.Beneficiary.Account.AccountType)
+		assert.Nil(t, stored.Attributes.Beneficiary.Account.Bank)
+	})
+
+	t.Run("It treats accounts without a type as UK accounts", func(t *testing.T) {
+		// Act
+		w := send(http.MethodPost, "/items", createValidCreatePayload())
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"account":{"type":"UK","sort_code":"87-65-43","account_number":"87654321"`)
+	})
+
+	t.Run("It validates accounts according to their type", func(t *testing.T) {
+		// Arrange
+		cases := []struct {
+			name    string
+			account string
+			field   string
+			message string
+		}{
+			{"invalid IBAN", `{"type": "IBAN", "iban": "GB82WEST12345698765431"}`, "iban", "Must be a valid IBAN"},
+			{"invalid BIC", `{"type": "IBAN", "iban": "GB82WEST12345698765432", "bic": "WEST2L"}`, "bic", "Must be a valid 8 or 11 character BIC"},
+			{"IBAN account without an IBAN", `{"type": "IBAN", "bic": "WESTGB2L"}`, "iban", "This field is required"},
+			{"IBAN account with a sort code", `{"type": "IBAN", "iban": "GB82WEST12345698765432", "sort_code": "87-65-43"}`, "sortcode", "This field is not allowed for this type of account"},
+			{"UK account with an IBAN", `{"sort_code": "87-65-43", "account_number": "87654321", "iban": "GB82WEST12345698765432"}`, "iban", "This field is not allowed for this type of account"},
+			{"UK account with a BIC", `{"type": "UK", "sort_code": "87-65-43", "account_number": "87654321", "bic": "WESTGB2L"}`, "bic", "This field is not allowed for this type of account"},
+			{"UK account without a sort code", `{"account_number": "87654321"}`, "sortcode", "This field is required"},
+			{"unknown account type", `{"type": "SWIFT", "iban": "GB82WEST12345698765432"}`, "accounttype", "Invalid account type. Must be UK or IBAN"},
+		}
+
+		for _, tc := range cases {
+			// Act
+			w := send(http.MethodPost, "/items", withBeneficiaryAccount(tc.account))
+
+			// Assert
+			assert.Equal(t, http.StatusBadRequest, w.Code, tc.name)
+			var body map[string]map[string]string
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), tc.name)
+			assert.Equal(t, tc.message, body["errors"][tc.field], tc.name)
+		}
+	})
+
+	t.Run("It reverses items between IBAN accounts", func(t *testing.T) {
+		// Arrange
+		original := send(http.MethodPost, "/items", withBeneficiaryAccount(`{"type": "IBAN", "iban": "NL91ABNA0417164300"}`))
+		require.Equal(t, http.StatusCreated, original.Code)
+		var item models.Item
+		require.NoError(t, json.Unmarshal(original.Body.Bytes(), &item))
+
+		reversal := `{
+			"amount": "10.00",
+			"type": "REVERSAL",
+			"original_guid": "` + item.GUID + `",
+			"attributes": {
+				"debtor": {"first_name": "Jane", "last_name": "Smith", "account": {"type": "IBAN", "iban": "nl91 abna 0417 1643 00"}},
+				"beneficiary": {"first_name": "John", "last_name": "Doe", "account": {"sort_code": "12-34-56", "account_number": "12345678"}}
+			}
+		}`
+
+		// Act
+		w := send(http.MethodPost, "/items", reversal)
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+}
@@ -0,0 +1,139 @@
+package feature
+
+import (
+	"go-test/backend/domain/modulus"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountModulusChecking(t *testing.T) {
+	r, _ := tests.SetupReadRouter()
+
+	t.Run("It agrees with VocaLink's published test cases", func(t *testing.T) {
+		// Arrange
+		cases := []struct {
+			sortCode, accountNumber string
+			valid                   bool
+			description             string
+		}{
+			{"089999", "66374958", true, "modulus 10"},
+			{"107999", "88837491", true, "modulus 11"},
+			{"202959", "63748472", true, "modulus 11 and double alternate"},
+			{"871427", "46238510", true, "exceptions 10 and 11, first check passes"},
+			{"872427", "46238510", true, "exceptions 10 and 11, second check passes"},
+			{"871427", "09123496", true, "exception 10, ab = 09 and g = 9"},
+			{"871427", "99123496", true, "exception 10, ab = 99 and g = 9"},
+			{"820000", "73688637", true, "exception 3, first sort code in range"},
+			{"827999", "73988638", true, "exception 3, last sort code in range"},
+			{"827101", "28748352", true, "exception 3, both checks"},
+			{"134020", "63849203", true, "exception 4"},
+			{"118765", "64371389", true, "exception 1"},
+			{"200915", "41011166", true, "exception 6, foreign currency account"},
+			{"938611", "07806039", true, "exception 5"},
+			{"938600", "42368003", true, "exception 5 with substitution"},
+			{"938063", "55065200", true, "exception 5, both remainders 0"},
+			{"772798", "99345694", true, "exception 7"},
+			{"086090", "06774744", true, "exception 8"},
+			{"309070", "02355688", true, "exceptions 2 and 9, first check passes"},
+			{"309070", "12345668", true, "exceptions 2 and 9, second check passes"},
+			{"309070", "12345677", true, "exception 2, a != 0 and g != 9"},
+			{"309070", "99345694", true, "exception 2, a != 0 and g = 9"},
+			{"938063", "15764273", false, "exception 5, second check digit incorrect"},
+			{"938063", "15764264", false, "exception 5, first check digit incorrect"},
+			{"938063", "15763217", false, "exception 5, remainder of 1"},
+			{"118765", "64371388", false, "exception 1 fails"},
+			{"203099", "66831036", false, "fails double alternate"},
+			{"203099", "58716970", false, "fails modulus 11"},
+			{"089999", "66374959", false, "fails modulus 10"},
+			{"107999", "88837493", false, "fails modulus 11"},
+			{"074456", "12345112", true, "exceptions 12 and 13, modulus 11 passes"},
+			{"070116", "34012583", true, "exceptions 12 and 13, both pass"},
+			{"074456", "11104102", true, "exceptions 12 and 13, modulus 10 passes"},
+			{"180002", "00000190", true, "exception 14, shifted check passes"},
+		}
+
+		for _, tc := range cases {
+			// Act
+			valid := modulus.Default().Valid(tc.sortCode, tc.accountNumber)
+
+			// Assert
+			assert.Equal(t, tc.valid, valid, tc.sortCode+" "+tc.accountNumber+": "+tc.description)
+		}
+	})
+
+	t.Run("It accepts sort codes the weight table does not cover", func(t *testing.T) {
+		// Act
+		valid := modulus.Default().Valid("12-34-56", "12345678")
+
+		// Assert
+		assert.True(t, valid)
+	})
+
+	t.Run("It rejects items whose accounts fail the check", func(t *testing.T) {
+		// Arrange
+		payload := strings.Replace(createValidCreatePayload(), `"sort_code": "12-34-56"`, `"sort_code": "08-99-99"`, 1)
+		payload = strings.Replace(payload, `"account_number": "12345678"`, `"account_number": "66374959"`, 1)
+		req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(payload))
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"accountnumber":"Account number is not valid for this sort code"`)
+	})
+
+	t.Run("It accepts items whose accounts pass the check", func(t *testing.T) {
+		// Arrange
+		payload := strings.Replace(createValidCreatePayload(), `"sort_code": "12-34-56"`, `"sort_code": "08-99-99"`, 1)
+		payload = strings.Replace(payload, `"account_number": "12345678"`, `"account_number": "66374958"`, 1)
+		req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(payload))
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("It checks against a weight table loaded from a file", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		weights := filepath.Join(dir, "valacdos.txt")
+		require.NoError(t, os.WriteFile(weights, []byte("123456 123456 MOD10 0 0 0 0 0 0 7 1 3 7 1 3 7 1\n"), 0o644))
+
+		previous := modulus.Default()
+		defer modulus.SetDefault(previous)
+
+		// Act
+		table, err := modulus.Load(weights, "")
+		require.NoError(t, err)
+		modulus.SetDefault(table)
+		req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(createValidCreatePayload()))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Account number is not valid for this sort code")
+		assert.True(t, table.Valid("089999", "66374959"), "sort codes outside the loaded table are not checked")
+	})
+
+	t.Run("It rejects malformed weight tables", func(t *testing.T) {
+		// Act
+		_, err := modulus.Parse(strings.NewReader("089000 089999 MOD12 0 0 0 0 0 0 7 1 3 7 1 3 7 1\n"), strings.NewReader(""))
+
+		// Assert
+		assert.ErrorContains(t, err, "unknown method")
+	})
+}
@@ -0,0 +1,276 @@
+package feature
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"go-test/backend/domain/dto"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"go-test/backend/repository"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// changeOf returns the change an audit entry records to field, if any
+func changeOf(entry models.AuditEntry, field string) (models.FieldChange, bool) {
+	for _, change := range entry.Changes {
+		if change.Field == field {
+			return change, true
+		}
+	}
+	return models.FieldChange{}, false
+}
+
+func TestAuditTrail(t *testing.T) {
+	r, s := tests.SetupReadRouter()
+
+	send := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	history := func(t *testing.T, guid string) dto.ItemHistoryResponse {
+		w := send(http.MethodGet, "/items/"+guid+"/history", "", nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response dto.ItemHistoryResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+	create := func(t *testing.T) models.Item {
+		w := send(http.MethodPost, "/items", createValidCreatePayload(), map[string]string{"X-Actor": "alice", "X-Request-ID": "req-create-1"})
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var item models.Item
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+		return item
+	}
+
+	t.Run("It records who created an item, in answer to which request, with every field it was created with", func(t *testing.T) {
+		// Arrange
+		item := create(t)
+
+		// Act
+		response := history(t, item.GUID)
+
+		// Assert
+		require.Equal(t, 1, response.Total)
+		entry := response.Data[0]
+		assert.Equal(t, item.GUID, entry.GUID)
+		assert.Equal(t, 1, entry.Seq)
+		assert.Equal(t, enums.AuditCreate, entry.Action)
+		assert.Equal(t, "alice", entry.Actor)
+		assert.Equal(t, "req-create-1", entry.RequestID)
+		assert.Equal(t, 1, entry.Version)
+		assert.False(t, entry.At.IsZero())
+
+		sortCode, ok := changeOf(entry, "attributes.debtor.account.sort_code")
+		require.True(t, ok)
+		assert.JSONEq(t, `null`, string(sortCode.Before))
+		assert.JSONEq(t, `"12-34-56"`, string(sortCode.After))
+		status, ok := changeOf(entry, "status")
+		require.True(t, ok)
+		assert.JSONEq(t, `"ACCEPTED"`, string(status.After))
+		_, ok = changeOf(entry, "version")
+		assert.False(t, ok)
+	})
+
+	t.Run("It records only the fields an update changed, down to nested attributes", func(t *testing.T) {
+		// Arrange
+		item := create(t)
+		update := strings.Replace(createValidCreatePayload(), `"Smith"`, `"Jones"`, 1)
+		update = strings.Replace(update, `"amount": 100`, `"amount": 250.5`, 1)
+
+		// Act
+		w := send(http.MethodPut, "/items/"+item.GUID, update, map[string]string{"X-Actor": "bob", "X-Request-ID": "req-update-1"})
+		response := history(t, item.GUID)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, 2, response.Total)
+		entry := response.Data[1]
+		assert.Equal(t, 2, entry.Seq)
+		assert.Equal(t, enums.AuditUpdate, entry.Action)
+		assert.Equal(t, "bob", entry.Actor)
+		assert.Equal(t, "req-update-1", entry.RequestID)
+		assert.Equal(t, 2, entry.Version)
+
+		fields := make([]string, 0, len(entry.Changes))
+		for _, change := range entry.Changes {
+			fields = append(fields, change.Field)
+		}
+		assert.Equal(t, []string{"amount", "attributes.beneficiary.last_name"}, fields)
+		lastName, _ := changeOf(entry, "attributes.beneficiary.last_name")
+		assert.JSONEq(t, `"Smith"`, string(lastName.Before))
+		assert.JSONEq(t, `"Jones"`, string(lastName.After))
+		amount, _ := changeOf(entry, "amount")
+		assert.JSONEq(t, `"100.00"`, string(amount.Before))
+		assert.JSONEq(t, `"250.50"`, string(amount.After))
+	})
+
+	t.Run("It records status transitions as changes to the status and its transitions", func(t *testing.T) {
+		// Arrange
+		item := &models.Item{GUID: "audit-transition-guid", Amount: models.NewMoney(10000, models.DefaultCurrency), Type: enums.ADMISSION, Status: enums.PENDING}
+		require.NoError(t, s.Create(context.Background(), item))
+
+		// Act
+		w := send(http.MethodPost, "/items/"+item.GUID+"/transitions", `{"status": "ACCEPTED", "reason": "Checks passed"}`, map[string]string{"X-Actor": "carol"})
+		response := history(t, item.GUID)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, 2, response.Total)
+		assert.Equal(t, repository.SystemActor, response.Data[0].Actor)
+		entry := response.Data[1]
+		assert.Equal(t, "carol", entry.Actor)
+		status, ok := changeOf(entry, "status")
+		require.True(t, ok)
+		assert.JSONEq(t, `"PENDING"`, string(status.Before))
+		assert.JSONEq(t, `"ACCEPTED"`, string(status.After))
+		to, ok := changeOf(entry, "transitions.0.to")
+		require.True(t, ok)
+		assert.JSONEq(t, `null`, string(to.Before))
+		assert.JSONEq(t, `"ACCEPTED"`, string(to.After))
+	})
+
+	t.Run("It keeps the history of a deleted item and records the deletion", func(t *testing.T) {
+		// Arrange
+		item := create(t)
+
+		// Act
+		w := send(http.MethodDelete, "/items/"+item.GUID, "", map[string]string{"X-Actor": "dave"})
+		response := history(t, item.GUID)
+
+		// Assert
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Equal(t, 2, response.Total)
+		entry := response.Data[1]
+		assert.Equal(t, enums.AuditDelete, entry.Action)
+		assert.Equal(t, "dave", entry.Actor)
+		assert.Equal(t, 2, entry.Version)
+		require.Len(t, entry.Changes, 1)
+		assert.Equal(t, "deleted_at", entry.Changes[0].Field)
+		assert.JSONEq(t, `null`, string(entry.Changes[0].Before))
+		assert.NotEqual(t, `null`, string(entry.Changes[0].After))
+	})
+
+	t.Run("It generates a request ID when none or an implausible one is sent", func(t *testing.T) {
+		// Arrange
+		headers := map[string]string{"X-Request-ID": "not\ta request id"}
+
+		// Act
+		w := send(http.MethodPost, "/items", createValidCreatePayload(), headers)
+
+		// Assert
+		require.Equal(t, http.StatusCreated, w.Code)
+		requestID := w.Header().Get("X-Request-ID")
+		assert.Len(t, requestID, 36)
+
+		var item models.Item
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+		response := history(t, item.GUID)
+		require.Equal(t, 1, response.Total)
+		assert.Equal(t, requestID, response.Data[0].RequestID)
+		assert.Equal(t, "anonymous", response.Data[0].Actor)
+	})
+
+	t.Run("It returns 404 for an item that never existed", func(t *testing.T) {
+		// Act
+		w := send(http.MethodGet, "/items/no-such-guid/history", "", nil)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.JSONEq(t, `{"error": "Item not found"}`, w.Body.String())
+	})
+
+	t.Run("It records nothing when a change is refused", func(t *testing.T) {
+		// Arrange
+		item := create(t)
+
+		// Act
+		w := send(http.MethodPut, "/items/"+item.GUID, createUpdatePayload(), map[string]string{"If-Match": `"7"`})
+		response := history(t, item.GUID)
+
+		// Assert
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Equal(t, 1, response.Total)
+	})
+}
+
+func TestAuditTrailIsDurable(t *testing.T) {
+	ctx := repository.WithChange(context.Background(), repository.Change{Actor: "erin", RequestID: "req-durable-1"})
+	newItem := func(guid string) *models.Item {
+		return &models.Item{GUID: guid, Amount: models.NewMoney(5000, models.DefaultCurrency), Type: enums.SUBMISSION, Status: enums.PENDING}
+	}
+
+	t.Run("It replays the history from the journal and its snapshots", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		s, err := repository.NewDurableStore(dir, 2)
+		require.NoError(t, err)
+		item := newItem("durable-audit-guid")
+		require.NoError(t, s.Create(ctx, item))
+		item.Amount = models.NewMoney(7500, models.DefaultCurrency)
+		require.NoError(t, s.Update(ctx, item))
+		require.NoError(t, s.Delete(ctx, item.GUID, 0))
+		require.NoError(t, s.Close())
+
+		// Act
+		reopened, err := repository.NewDurableStore(dir, 2)
+		require.NoError(t, err)
+		defer reopened.Close()
+		entries, err := reopened.History(context.Background(), item.GUID)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		for i, action := range []enums.AuditAction{enums.AuditCreate, enums.AuditUpdate, enums.AuditDelete} {
+			assert.Equal(t, i+1, entries[i].Seq)
+			assert.Equal(t, action, entries[i].Action)
+			assert.Equal(t, "erin", entries[i].Actor)
+			assert.Equal(t, "req-durable-1", entries[i].RequestID)
+		}
+	})
+
+	t.Run("It refuses to change or delete SQLite audit entries", func(t *testing.T) {
+		// Arrange
+		path := filepath.Join(t.TempDir(), "items.db")
+		s, err := repository.NewSQLiteStore(path)
+		require.NoError(t, err)
+		require.NoError(t, s.Create(ctx, newItem("immutable-audit-guid")))
+		require.NoError(t, s.Close())
+
+		db, err := sql.Open("sqlite", path)
+		require.NoError(t, err)
+		defer db.Close()
+
+		// Act
+		_, errUpdate := db.Exec(`UPDATE audit_entries SET actor = 'mallory'`)
+		_, errDelete := db.Exec(`DELETE FROM audit_entries`)
+
+		// Assert
+		assert.ErrorContains(t, errUpdate, "audit entries cannot be changed")
+		assert.ErrorContains(t, errDelete, "audit entries cannot be deleted")
+
+		reopened, err := repository.NewSQLiteStore(path)
+		require.NoError(t, err)
+		defer reopened.Close()
+		entries, err := reopened.History(context.Background(), "immutable-audit-guid")
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "erin", entries[0].Actor)
+	})
+}
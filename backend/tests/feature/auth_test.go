@@ -0,0 +1,218 @@
+package feature
+
+import (
+	"context"
+	"encoding/json"
+	"go-test/backend/auth"
+	"go-test/backend/domain/dto"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthentication(t *testing.T) {
+	r, s := tests.SetupAuthRouter()
+
+	send := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	bearer := func(token string) map[string]string {
+		return map[string]string{"Authorization": "Bearer " + token}
+	}
+
+	t.Run("It refuses requests without a bearer token", func(t *testing.T) {
+		for name, headers := range map[string]map[string]string{
+			"no header":    nil,
+			"basic scheme": {"Authorization": "Basic YWxpY2U6c2VjcmV0"},
+			"empty token":  {"Authorization": "Bearer "},
+		} {
+			t.Run(name, func(t *testing.T) {
+				// Act
+				w := send(http.MethodGet, "/items", "", headers)
+
+				// Assert
+				assert.Equal(t, http.StatusUnauthorized, w.Code)
+				assert.JSONEq(t, `{"error": "Missing bearer token"}`, w.Body.String())
+				assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+			})
+		}
+	})
+
+	t.Run("It accepts RS256 and HS256 tokens signed by a key in the key set", func(t *testing.T) {
+		for name, token := range map[string]string{
+			"RS256": tests.MintToken("alice", "viewer"),
+			"HS256": tests.SignToken(jwt.SigningMethodHS256, tests.TestHS256KeyID, tests.TestClaims("alice", "viewer")),
+		} {
+			t.Run(name, func(t *testing.T) {
+				// Act
+				w := send(http.MethodGet, "/items", "", bearer(token))
+
+				// Assert
+				assert.Equal(t, http.StatusOK, w.Code)
+			})
+		}
+	})
+
+	t.Run("It audits changes as made by the token's subject, whatever X-Actor says", func(t *testing.T) {
+		// Arrange
+		headers := bearer(tests.MintToken("alice", "operator"))
+		headers["X-Actor"] = "mallory"
+
+		// Act
+		w := send(http.MethodPost, "/items", createPendingPayload("100"), headers)
+
+		// Assert
+		require.Equal(t, http.StatusCreated, w.Code)
+		var item models.Item
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+
+		w = send(http.MethodGet, "/items/"+item.GUID+"/history", "", bearer(tests.MintToken("bob", "viewer")))
+		require.Equal(t, http.StatusOK, w.Code)
+		var history dto.ItemHistoryResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+		require.Equal(t, 1, history.Total)
+		assert.Equal(t, "alice", history.Data[0].Actor)
+	})
+
+	t.Run("It records the token's subject as who made a transition, whatever the body says", func(t *testing.T) {
+		// Arrange
+		item := &models.Item{GUID: "auth-transition-guid", Amount: models.NewMoney(10000, models.DefaultCurrency), Type: enums.ADMISSION, Status: enums.PENDING}
+		require.NoError(t, s.Create(context.Background(), item))
+
+		// Act
+		w := send(http.MethodPost, "/items/"+item.GUID+"/transitions", `{"status": "ACCEPTED", "actor": "the-ceo", "reason": "Checks passed"}`, bearer(tests.MintToken("carol", "approver")))
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var body models.Item
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Len(t, body.Transitions, 1)
+		assert.Equal(t, "carol", body.Transitions[0].Actor)
+		assert.Equal(t, "Checks passed", body.Transitions[0].Reason)
+	})
+
+	t.Run("It refuses an expired token", func(t *testing.T) {
+		// Arrange
+		claims := tests.TestClaims("alice")
+		claims.IssuedAt = jwt.NewNumericDate(time.Now().Add(-2 * time.Hour))
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+
+		// Act
+		w := send(http.MethodGet, "/items", "", bearer(tests.SignToken(jwt.SigningMethodRS256, tests.TestRS256KeyID, claims)))
+
+		// Assert
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.JSONEq(t, `{"error": "Token has expired"}`, w.Body.String())
+		assert.Equal(t, `Bearer error="invalid_token"`, w.Header().Get("WWW-Authenticate"))
+	})
+
+	t.Run("It refuses tokens it cannot verify or that leave out required claims", func(t *testing.T) {
+		withoutExpiry := tests.TestClaims("alice")
+		withoutExpiry.ExpiresAt = nil
+		unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, tests.TestClaims("alice")).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		require.NoError(t, err)
+		valid := tests.MintToken("alice")
+
+		for name, token := range map[string]string{
+			"malformed":             "not.a.token",
+			"tampered signature":    valid[:len(valid)-4] + "AAAA",
+			"unknown key":           tests.SignToken(jwt.SigningMethodHS256, "another-key", tests.TestClaims("alice")),
+			"key for another alg":   tests.SignToken(jwt.SigningMethodHS256, tests.TestRS256KeyID, tests.TestClaims("alice")),
+			"unsupported algorithm": tests.SignToken(jwt.SigningMethodHS512, tests.TestHS256KeyID, tests.TestClaims("alice")),
+			"unsigned":              unsigned,
+			"without an expiry":     tests.SignToken(jwt.SigningMethodRS256, tests.TestRS256KeyID, withoutExpiry),
+			"without a subject":     tests.SignToken(jwt.SigningMethodRS256, tests.TestRS256KeyID, tests.TestClaims("")),
+		} {
+			t.Run(name, func(t *testing.T) {
+				// Act
+				w := send(http.MethodGet, "/items", "", bearer(token))
+
+				// Assert
+				assert.Equal(t, http.StatusUnauthorized, w.Code)
+				assert.JSONEq(t, `{"error": "Invalid token"}`, w.Body.String())
+			})
+		}
+	})
+
+	t.Run("It answers health checks without a token", func(t *testing.T) {
+		// Act
+		w := send(http.MethodGet, "/healthz", "", nil)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"status": "ok"}`, w.Body.String())
+	})
+}
+
+func TestJWKS(t *testing.T) {
+	t.Run("It verifies tokens with the keys in a JWKS file", func(t *testing.T) {
+		// Arrange
+		path := filepath.Join(t.TempDir(), "jwks.json")
+		require.NoError(t, os.WriteFile(path, tests.TestJWKS(), 0o600))
+
+		// Act
+		keys, err := auth.LoadJWKS(path)
+		require.NoError(t, err)
+		claims, err := auth.NewVerifier(keys, auth.Options{}).Verify(tests.MintToken("alice"))
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "alice", claims.Subject)
+	})
+
+	t.Run("It checks the issuer and audience when told to", func(t *testing.T) {
+		// Arrange
+		keys, err := auth.ParseJWKS(tests.TestJWKS())
+		require.NoError(t, err)
+		verifier := auth.NewVerifier(keys, auth.Options{Issuer: "https://issuer.example", Audience: "items"})
+		claims := tests.TestClaims("alice")
+		claims.Issuer = "https://issuer.example"
+		claims.Audience = jwt.ClaimStrings{"items"}
+
+		// Act
+		_, errMatching := verifier.Verify(tests.SignToken(jwt.SigningMethodRS256, tests.TestRS256KeyID, claims))
+		_, errOther := verifier.Verify(tests.MintToken("alice"))
+
+		// Assert
+		assert.NoError(t, errMatching)
+		assert.ErrorIs(t, errOther, auth.ErrInvalidToken)
+	})
+
+	t.Run("It refuses key sets it cannot use", func(t *testing.T) {
+		for name, jwks := range map[string]string{
+			"not JSON":             `keys`,
+			"no keys":              `{"keys": []}`,
+			"unsupported type":     `{"keys": [{"kty": "EC", "crv": "P-256", "x": "AA", "y": "AA"}]}`,
+			"mismatched alg":       `{"keys": [{"kty": "oct", "alg": "RS256", "k": "c2VjcmV0"}]}`,
+			"encryption key":       `{"keys": [{"kty": "oct", "use": "enc", "k": "c2VjcmV0"}]}`,
+			"secret not base64url": `{"keys": [{"kty": "oct", "k": "not base64!"}]}`,
+			"RSA without modulus":  `{"keys": [{"kty": "RSA", "e": "AQAB"}]}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				// Act
+				_, err := auth.ParseJWKS([]byte(jwks))
+
+				// Assert
+				assert.ErrorIs(t, err, auth.ErrInvalidJWKS)
+			})
+		}
+	})
+}
@@ -0,0 +1,283 @@
+package feature
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"go-test/backend/domain/bacs"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// updateGolden rewrites the golden files from the current output: go test ./backend/tests/feature -run Bacs -update
+var updateGolden = flag.Bool("update", false, "rewrite golden files")
+
+// bacsParty builds a party with a UK account at a branch that takes Bacs payments
+func bacsParty(first, last, sortCode, accountNumber string) models.Party {
+	return models.Party{FirstName: first, LastName: last, Account: models.Account{
+		AccountType:   enums.UKAccount,
+		SortCode:      sortCode,
+		AccountNumber: accountNumber,
+		Bank:          &models.Bank{Name: "Example Bank plc", Schemes: []enums.PaymentScheme{enums.BACS, enums.FPS}},
+	}}
+}
+
+// bacsItem builds an ACCEPTED GBP item paying amount pence from debtor to beneficiary
+func bacsItem(guid string, itemType enums.ItemType, amount int64, debtor, beneficiary models.Party) models.Item {
+	return models.Item{
+		GUID:       guid,
+		Amount:     models.NewMoney(amount, "GBP"),
+		Type:       itemType,
+		Status:     enums.ACCEPTED,
+		Attributes: models.Attributes{Debtor: debtor, Beneficiary: beneficiary},
+	}
+}
+
+func TestBacsStandard18(t *testing.T) {
+	submission := bacs.Submission{
+		ServiceUserNumber: "123456",
+		ServiceUserName:   "Example Payroll Ltd",
+		SerialNumber:      "f00042",
+		Created:           time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC),
+		ProcessingDate:    time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC),
+	}
+	payroll := bacsParty("Example", "Payroll Ltd", "12-34-56", "12345678")
+	expenses := bacsParty("Example", "Expenses", "12-34-56", "87654321")
+	alice := bacsParty("Alice", "O'Brien-Smith", "87-65-43", "11112222")
+	bob := bacsParty("Bob", "Jones", "08-99-99", "33334444")
+	carol := bacsParty("Carol", "Lee", "20-30-99", "55556666")
+
+	t.Run("It writes golden Standard 18 files", func(t *testing.T) {
+		// Arrange
+		cases := []struct {
+			golden string
+			items  []models.Item
+		}{
+			{"single_credit.txt", []models.Item{
+				bacsItem("0b5e3f0e-1111-4c2a-9d6e-000000000001", enums.SUBMISSION, 125000, payroll, alice),
+			}},
+			{"credits_by_originating_account.txt", []models.Item{
+				bacsItem("0b5e3f0e-1111-4c2a-9d6e-000000000002", enums.SUBMISSION, 210050, payroll, alice),
+				bacsItem("0b5e3f0e-1111-4c2a-9d6e-000000000003", enums.SUBMISSION, 4599, expenses, bob),
+				bacsItem("0b5e3f0e-1111-4c2a-9d6e-000000000004", enums.SUBMISSION, 189900, payroll, carol),
+			}},
+			{"debits_credits_and_reversals.txt", []models.Item{
+				bacsItem("0b5e3f0e-1111-4c2a-9d6e-000000000005", enums.ADMISSION, 3000, alice, payroll),
+				bacsItem("0b5e3f0e-1111-4c2a-9d6e-000000000006", enums.ADMISSION, 1500, bob, payroll),
+				bacsItem("0b5e3f0e-1111-4c2a-9d6e-000000000007", enums.SUBMISSION, 99999, payroll, carol),
+				bacsItem("0b5e3f0e-1111-4c2a-9d6e-000000000008", enums.REVERSAL, 500, payroll, bob),
+			}},
+		}
+
+		for _, tc := range cases {
+			// Act
+			var file bytes.Buffer
+			require.NoError(t, bacs.Write(&file, submission, tc.items), tc.golden)
+
+			// Assert
+			path := filepath.Join("testdata", "bacs", tc.golden)
+			if *updateGolden {
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+				require.NoError(t, os.WriteFile(path, file.Bytes(), 0o644))
+			}
+			golden, err := os.ReadFile(path)
+			require.NoError(t, err, tc.golden)
+			assert.Equal(t, string(golden), file.String(), tc.golden)
+		}
+	})
+
+	t.Run("It writes 80 character labels around 100 character records", func(t *testing.T) {
+		// Arrange
+		items := []models.Item{
+			bacsItem("0b5e3f0e-1111-4c2a-9d6e-000000000009", enums.SUBMISSION, 100, payroll, alice),
+			bacsItem("0b5e3f0e-1111-4c2a-9d6e-000000000010", enums.ADMISSION, 200, bob, expenses),
+		}
+
+		// Act
+		var file bytes.Buffer
+		require.NoError(t, bacs.Write(&file, submission, items))
+
+		// Assert
+		lines := strings.Split(strings.TrimSuffix(file.String(), "\n"), "\n")
+		require.Len(t, lines, 4+4+3)
+		for i, line := range lines {
+			length := 100
+			if i < 4 || i >= len(lines)-3 {
+				length = 80
+			}
+			assert.Len(t, line, length, line)
+		}
+		labels := []string{"VOL1", "HDR1", "HDR2", "UHL1", "EOF1", "EOF2", "UTL1"}
+		for i, label := range labels[:4] {
+			assert.True(t, strings.HasPrefix(lines[i], label), label)
+		}
+		for i, label := range labels[4:] {
+			assert.True(t, strings.HasPrefix(lines[len(lines)-3+i], label), label)
+		}
+		// Two debit records (one detail, one contra) of 3.00 and two credit records of 3.00 in all
+		assert.Equal(t, "UTL1"+"0000000000300"+"0000000000300"+"0000002"+"0000002", lines[len(lines)-1][:44])
+	})
+
+	t.Run("It maps item types to transaction codes", func(t *testing.T) {
+		// Arrange
+		cases := map[enums.ItemType]string{
+			enums.SUBMISSION: "99",
+			enums.ADMISSION:  "17",
+			enums.REVERSAL:   "99",
+		}
+
+		for itemType, want := range cases {
+			// Act
+			code, ok := bacs.TransactionCode(itemType)
+
+			// Assert
+			assert.True(t, ok, itemType)
+			assert.Equal(t, want, code, itemType)
+		}
+	})
+
+	t.Run("It refuses items Bacs cannot pay", func(t *testing.T) {
+		// Arrange
+		valid := bacsItem("refused", enums.SUBMISSION, 100, payroll, alice)
+		pending := valid
+		pending.Status = enums.PENDING
+		euros := valid
+		euros.Amount = models.NewMoney(100, "EUR")
+		tooLarge := valid
+		tooLarge.Amount = models.NewMoney(bacs.MaxAmount+1, "GBP")
+		iban := valid
+		iban.Attributes.Beneficiary.Account = models.Account{AccountType: enums.IBANAccount, IBAN: "DE89370400440532013000"}
+		fasterOnly := valid
+		fasterOnly.Attributes.Beneficiary.Account.Bank = &models.Bank{Name: "Example Bank plc", Schemes: []enums.PaymentScheme{enums.FPS}}
+		unlisted := valid
+		unlisted.Attributes.Beneficiary.Account.Bank = nil
+
+		cases := []struct {
+			name string
+			item models.Item
+			err  error
+		}{
+			{"valid", valid, nil},
+			{"branch not in the directory", unlisted, nil},
+			{"pending", pending, bacs.ErrNotAccepted},
+			{"euros", euros, bacs.ErrCurrency},
+			{"over the limit", tooLarge, bacs.ErrAmountLimit},
+			{"IBAN account", iban, bacs.ErrAccountType},
+			{"branch without Bacs", fasterOnly, bacs.ErrNotReachable},
+		}
+
+		for _, tc := range cases {
+			// Act
+			err := bacs.Check(tc.item)
+
+			// Assert
+			assert.ErrorIs(t, err, tc.err, tc.name)
+			if tc.err != nil {
+				assert.ErrorIs(t, bacs.Write(&bytes.Buffer{}, submission, []models.Item{tc.item}), tc.err, tc.name)
+			}
+		}
+	})
+}
+
+func TestBacsFileEndpoint(t *testing.T) {
+	r, s := tests.SetupReadRouter()
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/items:bacs", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format(time.DateOnly)
+	request := func(guids ...string) string {
+		body, _ := json.Marshal(map[string]any{
+			"guids":               guids,
+			"service_user_number": "123456",
+			"service_user_name":   "Example Payroll Ltd",
+			"serial_number":       "f00043",
+			"processing_date":     tomorrow,
+		})
+		return string(body)
+	}
+
+	payroll := bacsParty("Example", "Payroll Ltd", "12-34-56", "12345678")
+	alice := bacsParty("Alice", "Smith", "87-65-43", "11112222")
+	accepted := bacsItem("bacs-accepted", enums.SUBMISSION, 125000, payroll, alice)
+	require.NoError(t, s.Create(context.Background(), &accepted))
+	pending := bacsItem("bacs-pending", enums.SUBMISSION, 100, payroll, alice)
+	pending.Status = enums.PENDING
+	require.NoError(t, s.Create(context.Background(), &pending))
+
+	t.Run("It returns a Standard 18 file paying the selected items", func(t *testing.T) {
+		// Act
+		w := send(request(accepted.GUID))
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/plain; charset=us-ascii", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="F00043.txt"`, w.Header().Get("Content-Disposition"))
+		lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+		require.Len(t, lines, 9)
+		assert.Equal(t, "VOL1F00043", lines[0][:10])
+		assert.Equal(t, "876543"+"11112222"+"0"+"99"+"123456"+"12345678"+"    "+"00000125000", lines[4][:46])
+		assert.Equal(t, "123456"+"12345678"+"0"+"17"+"123456"+"12345678"+"    "+"00000125000", lines[5][:46])
+
+		stored, err := s.GetByGUID(context.Background(), accepted.GUID)
+		require.NoError(t, err)
+		assert.Equal(t, enums.ACCEPTED, stored.Status)
+	})
+
+	t.Run("It reports every item that cannot be paid and writes no file", func(t *testing.T) {
+		// Act
+		w := send(request(accepted.GUID, pending.GUID, "missing", accepted.GUID))
+
+		// Assert
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var body map[string]map[string]string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, map[string]string{
+			pending.GUID:  "Only ACCEPTED items can be paid by Bacs",
+			"missing":     "Item not found",
+			accepted.GUID: "Item is selected more than once",
+		}, body["errors"])
+	})
+
+	t.Run("It validates the file details", func(t *testing.T) {
+		// Arrange
+		cases := []struct {
+			name    string
+			body    string
+			field   string
+			message string
+		}{
+			{"short service user number", strings.Replace(request(accepted.GUID), `"123456"`, `"12345"`, 1), "serviceusernumber", "Must be a six digit service user number"},
+			{"serial number with symbols", strings.Replace(request(accepted.GUID), `"f00043"`, `"f0-043"`, 1), "serialnumber", "Must be six letters or digits"},
+			{"processing date not a date", strings.Replace(request(accepted.GUID), tomorrow, "next tuesday", 1), "processingdate", "Must be a date (YYYY-MM-DD)"},
+			{"processing date in the past", strings.Replace(request(accepted.GUID), tomorrow, "2020-01-01", 1), "processingdate", "Must not be in the past"},
+			{"no items", `{"service_user_number": "123456", "service_user_name": "Example", "serial_number": "F00043", "processing_date": "` + tomorrow + `"}`, "guids", "This field is required"},
+		}
+
+		for _, tc := range cases {
+			// Act
+			w := send(tc.body)
+
+			// Assert
+			assert.Equal(t, http.StatusBadRequest, w.Code, tc.name)
+			var body map[string]map[string]string
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), tc.name)
+			assert.Equal(t, tc.message, body["errors"][tc.field], tc.name)
+		}
+	})
+}
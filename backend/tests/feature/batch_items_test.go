@@ -0,0 +1,202 @@
+package feature
+
+import (
+	"context"
+	"encoding/json"
+	"go-test/backend/domain/dto"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchCreateItems(t *testing.T) {
+	r, s := tests.SetupReadRouter()
+
+	send := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) dto.ItemBatchResponse {
+		var body dto.ItemBatchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+	batch := func(rows ...string) string {
+		return "[" + strings.Join(rows, ",") + "]"
+	}
+	count := func(t *testing.T) int {
+		n, err := s.Count(context.Background())
+		require.NoError(t, err)
+		return n
+	}
+	invalid := strings.Replace(createValidCreatePayload(), `"amount": 100`, `"amount": -5`, 1)
+
+	t.Run("It creates every item of a valid batch", func(t *testing.T) {
+		// Arrange
+		before := count(t)
+
+		// Act
+		w := send("/items:batch", batch(createValidCreatePayload(), createValidCreatePayload()))
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+		body := decode(t, w)
+		assert.Equal(t, 2, body.Created)
+		assert.Equal(t, 0, body.Failed)
+		require.Len(t, body.Results, 2)
+		for i, result := range body.Results {
+			assert.Equal(t, i, result.Index)
+			assert.Equal(t, http.StatusCreated, result.Status)
+			require.NotNil(t, result.Item)
+			assert.Equal(t, 1, result.Item.Version)
+		}
+		assert.Less(t, body.Results[0].Item.Index, body.Results[1].Item.Index)
+		assert.Equal(t, before+2, count(t))
+	})
+
+	t.Run("It reports invalid items in place and creates the rest", func(t *testing.T) {
+		// Arrange
+		before := count(t)
+
+		// Act
+		w := send("/items:batch", batch(createValidCreatePayload(), invalid, `{"amount": "1.00"}`))
+
+		// Assert
+		assert.Equal(t, http.StatusMultiStatus, w.Code)
+		body := decode(t, w)
+		assert.Equal(t, 1, body.Created)
+		assert.Equal(t, 2, body.Failed)
+		assert.Equal(t, http.StatusCreated, body.Results[0].Status)
+		assert.Equal(t, http.StatusBadRequest, body.Results[1].Status)
+		assert.Nil(t, body.Results[1].Item)
+		assert.Equal(t, "Value must be greater than 0", body.Results[1].Errors["amount"])
+		assert.Equal(t, "This field is required", body.Results[2].Errors["type"])
+		assert.Equal(t, before+1, count(t))
+	})
+
+	t.Run("It creates nothing in atomic mode unless every item is valid", func(t *testing.T) {
+		// Arrange
+		before := count(t)
+
+		// Act
+		w := send("/items:batch?atomic=true", batch(createValidCreatePayload(), invalid))
+
+		// Assert
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		body := decode(t, w)
+		assert.Equal(t, 0, body.Created)
+		assert.Equal(t, 2, body.Failed)
+		assert.Equal(t, http.StatusFailedDependency, body.Results[0].Status)
+		assert.Nil(t, body.Results[0].Item)
+		assert.Equal(t, http.StatusBadRequest, body.Results[1].Status)
+		assert.Equal(t, before, count(t))
+	})
+
+	t.Run("It creates every item in atomic mode", func(t *testing.T) {
+		// Arrange
+		before := count(t)
+
+		// Act
+		w := send("/items:batch?atomic=true", batch(createValidCreatePayload(), createValidCreatePayload(), createValidCreatePayload()))
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+		body := decode(t, w)
+		assert.Equal(t, 3, body.Created)
+		for _, result := range body.Results {
+			require.NotNil(t, result.Item)
+			stored, err := s.GetByGUID(context.Background(), result.Item.GUID)
+			require.NoError(t, err)
+			assert.Equal(t, result.Item.Index, stored.Index)
+		}
+		assert.Equal(t, before+3, count(t))
+	})
+
+	t.Run("It checks reversals against earlier items of the batch", func(t *testing.T) {
+		// Arrange
+		original := &models.Item{
+			GUID:   "batch-original",
+			Amount: models.NewMoney(10000, models.DefaultCurrency),
+			Type:   enums.ADMISSION,
+			Status: enums.ACCEPTED,
+			Attributes: models.Attributes{
+				Debtor:      models.Party{FirstName: "John", LastName: "Doe", Account: models.Account{SortCode: "12-34-56", AccountNumber: "12345678"}},
+				Beneficiary: models.Party{FirstName: "Jane", LastName: "Smith", Account: models.Account{SortCode: "87-65-43", AccountNumber: "87654321"}},
+			},
+		}
+		require.NoError(t, s.Create(context.Background(), original))
+		reversal := func(amount string) string {
+			return `{
+				"amount": "` + amount + `",
+				"type": "REVERSAL",
+				"original_guid": "batch-original",
+				"attributes": {
+					"debtor": {"first_name": "Jane", "last_name": "Smith", "account": {"sort_code": "87-65-43", "account_number": "87654321"}},
+					"beneficiary": {"first_name": "John", "last_name": "Doe", "account": {"sort_code": "12-34-56", "account_number": "12345678"}}
+				}
+			}`
+		}
+
+		// Act
+		w := send("/items:batch", batch(reversal("60.00"), reversal("50.00"), reversal("40.00")))
+
+		// Assert
+		assert.Equal(t, http.StatusMultiStatus, w.Code)
+		body := decode(t, w)
+		assert.Equal(t, http.StatusCreated, body.Results[0].Status)
+		assert.Equal(t, http.StatusUnprocessableEntity, body.Results[1].Status)
+		assert.Contains(t, body.Results[1].Error, "unreversed 40.00 GBP")
+		assert.Equal(t, http.StatusCreated, body.Results[2].Status)
+
+		stored, err := s.GetByGUID(context.Background(), original.GUID)
+		require.NoError(t, err)
+		assert.Equal(t, "0.00", stored.NetAmount.String())
+	})
+
+	t.Run("It rejects requests that are not a batch of items", func(t *testing.T) {
+		// Arrange
+		tooMany := make([]string, 1001)
+		for i := range tooMany {
+			tooMany[i] = `{}`
+		}
+
+		cases := []struct {
+			name    string
+			path    string
+			body    string
+			message string
+		}{
+			{"object body", "/items:batch", createValidCreatePayload(), "Request body must be a JSON array of items"},
+			{"empty batch", "/items:batch", `[]`, "Batch must contain at least one item"},
+			{"too many items", "/items:batch", batch(tooMany...), "Batch cannot contain more than " + strconv.Itoa(1000) + " items"},
+			{"invalid atomic", "/items:batch?atomic=maybe", batch(createValidCreatePayload()), "invalid atomic parameter"},
+		}
+
+		for _, tc := range cases {
+			// Act
+			w := send(tc.path, tc.body)
+
+			// Assert
+			assert.Equal(t, http.StatusBadRequest, w.Code, tc.name)
+			assert.Contains(t, w.Body.String(), tc.message, tc.name)
+		}
+	})
+
+	t.Run("It returns 404 for unknown collection methods", func(t *testing.T) {
+		// Act
+		w := send("/items:unknown", batch(createValidCreatePayload()))
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -1,6 +1,7 @@
 package feature
 
 import (
+	"context"
 	"go-test/backend/domain/enums"
 	"go-test/backend/domain/models"
 	"go-test/backend/tests"
@@ -11,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestItCanCreateAnItem(t *testing.T) {
@@ -28,7 +30,7 @@ func TestItCanCreateAnItem(t *testing.T) {
 
 		// Assert
 		assert.Equal(t, http.StatusCreated, w.Code)
-		assert.Contains(t, w.Body.String(), `"amount":100`)
+		assert.Contains(t, w.Body.String(), `"amount":"100.00"`)
 		assert.Contains(t, w.Body.String(), `"type":"ADMISSION"`)
 		assert.Contains(t, w.Body.String(), `"status":"ACCEPTED"`)
 		assert.Contains(t, w.Body.String(), `"first_name":"John"`)
@@ -146,13 +148,13 @@ func TestItCanCreateAnItem(t *testing.T) {
 		// Arrange
 		item := &models.Item{
 			GUID:   "", // Empty GUID
-			Amount: 100,
+			Amount: models.NewMoney(10000, models.DefaultCurrency),
 			Type:   enums.ADMISSION,
 			Status: enums.ACCEPTED,
 		}
 
 		// Act
-		err := s.Create(item)
+		err := s.Create(context.Background(), item)
 
 		// Assert
 		assert.Error(t, err)
@@ -172,7 +174,7 @@ func TestItCanCreateAnItem(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 		assert.Contains(t, w.Body.String(), "amount")
 		assert.Contains(t, w.Body.String(), "type")
-		assert.Contains(t, w.Body.String(), "status")
+		assert.NotContains(t, w.Body.String(), "status", "status is optional and defaults to PENDING")
 		assert.Contains(t, w.Body.String(), "firstname")
 	})
 
@@ -211,7 +213,7 @@ func TestItCanCreateAnItem(t *testing.T) {
 		// Assert
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 		assert.Contains(t, w.Body.String(), "amount")
-		assert.Contains(t, w.Body.String(), "required")
+		assert.Contains(t, w.Body.String(), "Value must be greater than 0")
 	})
 
 	t.Run("It returns 400 error when amount is negative", func(t *testing.T) {
@@ -444,6 +446,64 @@ func TestItCanCreateAnItem(t *testing.T) {
 	})
 }
 
+func TestItemIndexAllocation(t *testing.T) {
+	t.Run("It allocates unique, gap-free indexes to concurrent creates", func(t *testing.T) {
+		// Arrange
+		r, s := tests.SetupReadRouter()
+		numGoroutines := 50
+
+		wg := sync.WaitGroup{}
+		wg.Add(numGoroutines)
+
+		// Act
+		for i := 0; i < numGoroutines; i++ {
+			go func() {
+				defer wg.Done()
+				req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(createValidCreatePayload()))
+				req.Header.Set("Content-Type", "application/json")
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				assert.Equal(t, http.StatusCreated, w.Code)
+			}()
+		}
+		wg.Wait()
+
+		// Assert
+		items, err := s.GetAll(context.Background())
+		require.NoError(t, err)
+		require.Len(t, items, numGoroutines)
+
+		seen := make(map[int]bool)
+		for _, item := range items {
+			assert.False(t, seen[item.Index], "index %d allocated twice", item.Index)
+			seen[item.Index] = true
+		}
+		for i := 1; i <= numGoroutines; i++ {
+			assert.True(t, seen[i], "index %d was never allocated", i)
+		}
+	})
+
+	t.Run("It does not reuse the index of a deleted item", func(t *testing.T) {
+		// Arrange
+		_, s := tests.SetupReadRouter()
+		first := &models.Item{GUID: "index-guid-1"}
+		second := &models.Item{GUID: "index-guid-2"}
+		require.NoError(t, s.Create(context.Background(), first))
+		require.NoError(t, s.Create(context.Background(), second))
+		require.NoError(t, s.Delete(context.Background(), second.GUID, 0))
+
+		// Act
+		third := &models.Item{GUID: "index-guid-3"}
+		err := s.Create(context.Background(), third)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 1, first.Index)
+		assert.Equal(t, 2, second.Index)
+		assert.Equal(t, 3, third.Index)
+	})
+}
+
 func createValidCreatePayload() string {
 	return `{
 		"amount": 100,
@@ -0,0 +1,149 @@
+package feature
+
+import (
+	"encoding/json"
+	"go-test/backend/domain/dto"
+	"go-test/backend/domain/models"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemCurrencies(t *testing.T) {
+	r, _ := tests.SetupReadRouter()
+
+	create := func(amount, currency string) *httptest.ResponseRecorder {
+		fields := `"amount": ` + amount
+		if currency != "" {
+			fields += `, "currency": "` + currency + `"`
+		}
+		payload := strings.Replace(createValidCreatePayload(), `"amount": 100`, fields, 1)
+		req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	list := func(t *testing.T, path string) dto.ItemListResponse {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var page dto.ItemListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page
+	}
+
+	t.Run("It validates amounts against the currency's minor unit", func(t *testing.T) {
+		cases := []struct {
+			amount, currency string
+			status           int
+			body             string
+		}{
+			{`"100.50"`, "", http.StatusCreated, `"amount":"100.50","type":"ADMISSION"`},
+			{`"1500"`, "jpy", http.StatusCreated, `"amount":"1500"`},
+			{`"2.125"`, "BHD", http.StatusCreated, `"amount":"2.125"`},
+			{`"15.5"`, "JPY", http.StatusBadRequest, `"amount":"Must be a decimal amount with no more decimal places than its currency allows"`},
+			{`"922337203685477.59"`, "GBP", http.StatusBadRequest, `"amount":"Must be a decimal amount with no more decimal places than its currency allows"`},
+			{`"922337203685478"`, "JPY", http.StatusBadRequest, `"amount":"Must be a decimal amount with no more decimal places than its currency allows"`},
+			{`"10"`, "XYZ", http.StatusBadRequest, `"currency":"Must be an ISO 4217 currency code, e.g. GBP"`},
+		}
+		for _, tc := range cases {
+			// Act
+			w := create(tc.amount, tc.currency)
+
+			// Assert
+			assert.Equal(t, tc.status, w.Code, tc.amount+" "+tc.currency)
+			assert.Contains(t, w.Body.String(), tc.body)
+		}
+	})
+
+	t.Run("It emits the currency of every item", func(t *testing.T) {
+		// Act
+		w := create(`"20.00"`, "eur")
+
+		// Assert
+		require.Equal(t, http.StatusCreated, w.Code)
+		var item models.Item
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+		assert.Contains(t, w.Body.String(), `"currency":"EUR"`)
+		assert.Equal(t, models.NewMoney(2000, "EUR"), item.Amount)
+	})
+
+	t.Run("It re-reads the amount when an update changes the currency", func(t *testing.T) {
+		// Arrange
+		w := create(`"100.50"`, "GBP")
+		require.Equal(t, http.StatusCreated, w.Code)
+		var item models.Item
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+
+		update := func(body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPut, "/items/"+item.GUID, strings.NewReader(body))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			return w
+		}
+
+		// Act
+		lossy := update(`{"currency": "JPY"}`)
+		converted := update(`{"currency": "JPY", "amount": "12000"}`)
+		tooPrecise := update(`{"amount": "1.5"}`)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, lossy.Code)
+		assert.Contains(t, lossy.Body.String(), `"amount":"Must be a decimal amount with no more decimal places than its currency allows"`)
+		assert.Equal(t, http.StatusOK, converted.Code)
+		assert.Contains(t, converted.Body.String(), `"amount":"12000"`)
+		assert.Contains(t, converted.Body.String(), `"currency":"JPY"`)
+		assert.Equal(t, http.StatusBadRequest, tooPrecise.Code)
+	})
+
+	t.Run("It filters by currency and totals each currency across pages", func(t *testing.T) {
+		// Act
+		all := list(t, "/items?limit=1")
+		yen := list(t, "/items?currency=JPY&limit=0")
+
+		// Assert
+		assert.Len(t, all.Data, 1)
+		assert.Equal(t, []dto.CurrencyTotal{
+			{Currency: "BHD", Count: 1, Amount: models.NewMoney(2125, "BHD")},
+			{Currency: "EUR", Count: 1, Amount: models.NewMoney(2000, "EUR")},
+			{Currency: "GBP", Count: 1, Amount: models.NewMoney(10050, "GBP")},
+			{Currency: "JPY", Count: 2, Amount: models.NewMoney(13500, "JPY")},
+		}, all.Totals)
+		assert.Equal(t, 5, all.Total)
+		assert.Len(t, yen.Data, 2)
+		assert.Equal(t, []dto.CurrencyTotal{{Currency: "JPY", Count: 2, Amount: models.NewMoney(13500, "JPY")}}, yen.Totals)
+	})
+
+	t.Run("It compares amount bounds by face value across currencies", func(t *testing.T) {
+		// Act
+		page := list(t, "/items?limit=0&amount_min=20&amount_max=1500&sort=amount")
+
+		// Assert
+		var got []string
+		for _, item := range page.Data {
+			got = append(got, item.Amount.String()+" "+item.Amount.Currency)
+		}
+		assert.Equal(t, []string{"20.00 EUR", "100.50 GBP", "1500 JPY"}, got)
+	})
+
+	t.Run("It returns 400 error for an unknown currency filter", func(t *testing.T) {
+		// Arrange
+		req := httptest.NewRequest(http.MethodGet, "/items?currency=GBP,ABC", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"currency":"Must be an ISO 4217 currency code, e.g. GBP"`)
+	})
+}
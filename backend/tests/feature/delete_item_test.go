@@ -1,6 +1,7 @@
 package feature
 
 import (
+	"context"
 	"go-test/backend/domain/enums"
 	"go-test/backend/domain/models"
 	"go-test/backend/tests"
@@ -16,11 +17,11 @@ func TestItCanDeleteAnItem(t *testing.T) {
 
 	item := &models.Item{
 		GUID:   "test-guid-123",
-		Amount: 100,
+		Amount: models.NewMoney(10000, models.DefaultCurrency),
 		Type:   enums.ADMISSION,
 		Status: enums.ACCEPTED,
 	}
-	s.Create(item)
+	s.Create(context.Background(), item)
 
 	t.Run("It can delete an item", func(t *testing.T) {
 		// Arrange
@@ -64,11 +65,11 @@ func TestItCanDeleteAnItem(t *testing.T) {
 		// Create a new item for this test
 		testItem := &models.Item{
 			GUID:   "test-guid-delete-twice",
-			Amount: 100,
+			Amount: models.NewMoney(10000, models.DefaultCurrency),
 			Type:   enums.ADMISSION,
 			Status: enums.ACCEPTED,
 		}
-		s.Create(testItem)
+		s.Create(context.Background(), testItem)
 
 		// First delete should succeed
 		req := httptest.NewRequest(http.MethodDelete, "/items/"+testItem.GUID, nil)
@@ -0,0 +1,293 @@
+package feature
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"go-test/backend/repository"
+	"go-test/backend/tests"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDurableStoreRecoversItems(t *testing.T) {
+	createItem := func(t *testing.T, s *repository.ItemsStore) models.Item {
+		r := tests.SetupRouterWithStore(s)
+		req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(createValidCreatePayload()))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var item models.Item
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+		return item
+	}
+
+	t.Run("It replays the log after a restart", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		s, err := repository.NewDurableStore(dir, 0)
+		require.NoError(t, err)
+		kept := createItem(t, s)
+		deleted := createItem(t, s)
+		require.NoError(t, s.Delete(context.Background(), deleted.GUID, 0))
+		require.NoError(t, s.Close())
+
+		// Act
+		reopened, err := repository.NewDurableStore(dir, 0)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		// Assert
+		item, err := reopened.GetByGUID(context.Background(), kept.GUID)
+		require.NoError(t, err)
+		assert.Equal(t, kept.Attributes, item.Attributes)
+		_, err = reopened.GetByGUID(context.Background(), deleted.GUID)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("It recovers from a snapshot and compacts the log", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		s, err := repository.NewDurableStore(dir, 2)
+		require.NoError(t, err)
+		first := createItem(t, s)
+		second := createItem(t, s)
+		third := createItem(t, s)
+		require.NoError(t, s.Close())
+
+		// Act
+		reopened, err := repository.NewDurableStore(dir, 2)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		// Assert
+		assert.FileExists(t, filepath.Join(dir, "items.snapshot"))
+		count, err := reopened.Count(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+		for _, guid := range []string{first.GUID, second.GUID, third.GUID} {
+			_, err := reopened.GetByGUID(context.Background(), guid)
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("It continues the index sequence after a restart", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		s, err := repository.NewDurableStore(dir, 2)
+		require.NoError(t, err)
+		createItem(t, s)
+		createItem(t, s)
+		last := createItem(t, s)
+		require.NoError(t, s.Delete(context.Background(), last.GUID, 0))
+		require.NoError(t, s.Close())
+
+		// Act
+		reopened, err := repository.NewDurableStore(dir, 2)
+		require.NoError(t, err)
+		defer reopened.Close()
+		next := createItem(t, reopened)
+
+		// Assert
+		assert.Equal(t, 3, last.Index)
+		assert.Equal(t, 4, next.Index)
+	})
+
+	t.Run("It keeps amounts in their currency across a restart", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		s, err := repository.NewDurableStore(dir, 2)
+		require.NoError(t, err)
+		yen := &models.Item{GUID: "durable-yen", Amount: models.NewMoney(1500, "JPY")}
+		dinar := &models.Item{GUID: "durable-dinar", Amount: models.NewMoney(2125, "BHD")}
+		require.NoError(t, s.Create(context.Background(), yen))
+		require.NoError(t, s.Create(context.Background(), dinar))
+		require.NoError(t, s.Close())
+
+		// Act
+		reopened, err := repository.NewDurableStore(dir, 2)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		// Assert
+		for _, want := range []*models.Item{yen, dinar} {
+			got, err := reopened.GetByGUID(context.Background(), want.GUID)
+			require.NoError(t, err)
+			assert.Equal(t, want.Amount, got.Amount)
+		}
+	})
+
+	t.Run("It nets reversals off their original after a restart", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		s, err := repository.NewDurableStore(dir, 2)
+		require.NoError(t, err)
+		original := &models.Item{GUID: "durable-original", Amount: models.NewMoney(10000, models.DefaultCurrency), Type: enums.ADMISSION}
+		reversal := &models.Item{GUID: "durable-reversal", Amount: models.NewMoney(2500, models.DefaultCurrency), Type: enums.REVERSAL, OriginalGUID: original.GUID}
+		require.NoError(t, s.Create(context.Background(), original))
+		require.NoError(t, s.Create(context.Background(), reversal))
+		require.NoError(t, s.Close())
+
+		// Act
+		reopened, err := repository.NewDurableStore(dir, 2)
+		require.NoError(t, err)
+		defer reopened.Close()
+		got, err := reopened.GetByGUID(context.Background(), original.GUID)
+
+		// Assert
+		require.NoError(t, err)
+		require.NotNil(t, got.NetAmount)
+		assert.Equal(t, "75.00", got.NetAmount.String())
+	})
+
+	t.Run("It replays batches after a restart", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		s, err := repository.NewDurableStore(dir, 0)
+		require.NoError(t, err)
+		first := createItem(t, s)
+		batch := []*models.Item{{GUID: "durable-batch-1"}, {GUID: "durable-batch-2"}}
+		require.NoError(t, s.CreateAll(context.Background(), batch))
+		require.NoError(t, s.Close())
+
+		// Act
+		reopened, err := repository.NewDurableStore(dir, 0)
+		require.NoError(t, err)
+		defer reopened.Close()
+		next := createItem(t, reopened)
+
+		// Assert
+		for i, want := range batch {
+			got, err := reopened.GetByGUID(context.Background(), want.GUID)
+			require.NoError(t, err)
+			assert.Equal(t, first.Index+1+i, got.Index)
+		}
+		assert.Equal(t, first.Index+3, next.Index)
+	})
+
+	t.Run("It discards a torn write at the end of the log", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		s, err := repository.NewDurableStore(dir, 0)
+		require.NoError(t, err)
+		kept := createItem(t, s)
+		require.NoError(t, s.Close())
+
+		logPath := filepath.Join(dir, "items.log")
+		f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0o644)
+		require.NoError(t, err)
+		_, err = f.Write([]byte{0xff, 0x00, 0x00, 0x00, 0x01, 0x02})
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		// Act
+		reopened, err := repository.NewDurableStore(dir, 0)
+		require.NoError(t, err)
+
+		// Assert
+		_, err = reopened.GetByGUID(context.Background(), kept.GUID)
+		assert.NoError(t, err)
+
+		// New writes must land after the last good record, not after the torn bytes
+		added := createItem(t, reopened)
+		require.NoError(t, reopened.Close())
+		again, err := repository.NewDurableStore(dir, 0)
+		require.NoError(t, err)
+		defer again.Close()
+		_, err = again.GetByGUID(context.Background(), added.GUID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("It reports corruption in the middle of the log", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		s, err := repository.NewDurableStore(dir, 0)
+		require.NoError(t, err)
+		createItem(t, s)
+		createItem(t, s)
+		require.NoError(t, s.Close())
+
+		logPath := filepath.Join(dir, "items.log")
+		data, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		data[20] ^= 0xff // flip a byte inside the first record's payload
+		require.NoError(t, os.WriteFile(logPath, data, 0o644))
+
+		// Act
+		_, err = repository.NewDurableStore(dir, 0)
+
+		// Assert
+		assert.ErrorIs(t, err, repository.ErrJournalCorrupt)
+	})
+
+	t.Run("It reports a damaged record length in the middle of the log", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		s, err := repository.NewDurableStore(dir, 0)
+		require.NoError(t, err)
+		createItem(t, s)
+		createItem(t, s)
+		createItem(t, s)
+		require.NoError(t, s.Close())
+
+		logPath := filepath.Join(dir, "items.log")
+		data, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		// The log starts with an 8-byte file header, and each record with a 12-byte header whose
+		// first 4 bytes are the payload length
+		second := 8 + 12 + int(binary.LittleEndian.Uint32(data[8:12]))
+		data[second+3] ^= 0x01 // make the second record claim to run past the end of the log
+		require.NoError(t, os.WriteFile(logPath, data, 0o644))
+
+		// Act
+		_, err = repository.NewDurableStore(dir, 0)
+
+		// Assert
+		assert.ErrorIs(t, err, repository.ErrJournalCorrupt)
+		after, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		assert.Equal(t, data, after)
+	})
+
+	t.Run("It reads a log written before record headers were checksummed", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		var log []byte
+		for _, guid := range []string{"legacy-1", "legacy-2"} {
+			payload := []byte(`{"op":"put","guid":"` + guid + `","item":{"guid":"` + guid + `","amount":"1.00","currency":"GBP"},"seq":` + guid[len(guid)-1:] + `}`)
+			header := make([]byte, 8)
+			binary.LittleEndian.PutUint32(header[0:4], uint32(len(payload)))
+			binary.LittleEndian.PutUint32(header[4:8], crc32.Checksum(payload, crc32.MakeTable(crc32.Castagnoli)))
+			log = append(append(log, header...), payload...)
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "items.log"), log, 0o644))
+
+		// Act
+		s, err := repository.NewDurableStore(dir, 0)
+		require.NoError(t, err)
+		added := createItem(t, s)
+		require.NoError(t, s.Close())
+		reopened, err := repository.NewDurableStore(dir, 0)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		// Assert
+		assert.Equal(t, 3, added.Index)
+		for _, guid := range []string{"legacy-1", "legacy-2", added.GUID} {
+			_, err := reopened.GetByGUID(context.Background(), guid)
+			assert.NoError(t, err, guid)
+		}
+	})
+}
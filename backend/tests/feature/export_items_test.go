@@ -0,0 +1,159 @@
+package feature
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportItems(t *testing.T) {
+	r, s := tests.SetupReadRouter()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	newItem := func(n int, itemType enums.ItemType) *models.Item {
+		return &models.Item{
+			GUID:    fmt.Sprintf("export-%04d", n),
+			Amount:  models.NewMoney(int64(100+n), models.DefaultCurrency),
+			Type:    itemType,
+			Status:  enums.PENDING,
+			Created: time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC),
+			Attributes: models.Attributes{
+				Debtor:      models.Party{FirstName: "John", LastName: "Doe", Account: models.Account{SortCode: "12-34-56", AccountNumber: "12345678", Bank: &models.Bank{Name: "Example Bank plc", Branch: "London City"}}},
+				Beneficiary: models.Party{FirstName: "Hans", LastName: "Muller", Account: models.Account{AccountType: enums.IBANAccount, IBAN: "DE89370400440532013000", BIC: "COBADEFFXXX"}},
+			},
+		}
+	}
+
+	// More items than one page of the export, one in ten of them submissions
+	items := make([]*models.Item, 1234)
+	for i := range items {
+		itemType := enums.ADMISSION
+		if i%10 == 0 {
+			itemType = enums.SUBMISSION
+		}
+		items[i] = newItem(i, itemType)
+	}
+	require.NoError(t, s.CreateAll(context.Background(), items))
+
+	t.Run("It streams every item as NDJSON in index order", func(t *testing.T) {
+		// Act
+		w := get("/items/export?format=ndjson")
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="items.ndjson"`, w.Header().Get("Content-Disposition"))
+
+		scanner := bufio.NewScanner(w.Body)
+		var exported []models.Item
+		for scanner.Scan() {
+			var item models.Item
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &item))
+			exported = append(exported, item)
+		}
+		require.Len(t, exported, len(items))
+		for i, item := range exported {
+			assert.Equal(t, items[i].GUID, item.GUID)
+		}
+		assert.Equal(t, "13.33", exported[len(exported)-1].Amount.String())
+	})
+
+	t.Run("It exports the items matching the list filters and sort as a JSON array", func(t *testing.T) {
+		// Act
+		w := get("/items/export?format=json&type=submission&sort=-amount")
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		var exported []models.Item
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &exported))
+		require.Len(t, exported, 124)
+		assert.Equal(t, "export-1230", exported[0].GUID)
+		assert.Equal(t, "export-0000", exported[len(exported)-1].GUID)
+		for _, item := range exported {
+			assert.Equal(t, enums.SUBMISSION, item.Type)
+		}
+	})
+
+	t.Run("It exports JSON by default and an empty array when nothing matches", func(t *testing.T) {
+		// Act
+		w := get("/items/export?status=SETTLED")
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `[]`, w.Body.String())
+	})
+
+	t.Run("It flattens the parties of each item into CSV columns", func(t *testing.T) {
+		// Act
+		w := get("/items/export?format=CSV&debtor_name=john&amount_max=1.05")
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		records, err := csv.NewReader(w.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 7)
+
+		row := make(map[string]string)
+		for i, column := range records[0] {
+			row[column] = records[6][i]
+		}
+		assert.Equal(t, "export-0005", row["guid"])
+		assert.Equal(t, "2025-01-15T10:30:00Z", row["created"])
+		assert.Equal(t, "ADMISSION", row["type"])
+		assert.Equal(t, "1.05", row["amount"])
+		assert.Equal(t, "GBP", row["currency"])
+		assert.Equal(t, "1.05", row["net_amount"])
+		assert.Equal(t, "John", row["debtor_first_name"])
+		assert.Equal(t, "UK", row["debtor_account_type"])
+		assert.Equal(t, "12-34-56", row["debtor_sort_code"])
+		assert.Equal(t, "12345678", row["debtor_account_number"])
+		assert.Equal(t, "Example Bank plc", row["debtor_bank_name"])
+		assert.Equal(t, "London City", row["debtor_branch_name"])
+		assert.Equal(t, "IBAN", row["beneficiary_account_type"])
+		assert.Equal(t, "", row["beneficiary_sort_code"])
+		assert.Equal(t, "DE89370400440532013000", row["beneficiary_iban"])
+		assert.Equal(t, "COBADEFFXXX", row["beneficiary_bic"])
+	})
+
+	t.Run("It writes only the CSV header when nothing matches", func(t *testing.T) {
+		// Act
+		w := get("/items/export?format=csv&currency=JPY")
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, strings.HasPrefix(w.Body.String(), "guid,index,version,created,"))
+		assert.Equal(t, 1, strings.Count(w.Body.String(), "\n"))
+	})
+
+	t.Run("It rejects invalid formats and filters", func(t *testing.T) {
+		// Act
+		format := get("/items/export?format=xml")
+		filter := get("/items/export?format=csv&type=BOGUS")
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, format.Code)
+		assert.Contains(t, format.Body.String(), "invalid format parameter")
+		assert.Equal(t, http.StatusBadRequest, filter.Code)
+		assert.Contains(t, filter.Body.String(), `"type"`)
+	})
+}
@@ -0,0 +1,146 @@
+package feature
+
+import (
+	"context"
+	"encoding/json"
+	"go-test/backend/domain/dto"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemsStructuredFilters(t *testing.T) {
+	r, s := tests.SetupReadRouter()
+
+	party := func(first, last, sortCode, accountNumber string) models.Party {
+		return models.Party{
+			FirstName: first,
+			LastName:  last,
+			Account:   models.Account{SortCode: sortCode, AccountNumber: accountNumber},
+		}
+	}
+
+	fixtures := []models.Item{
+		{
+			GUID: "filter-1", Amount: models.NewMoney(5000, models.DefaultCurrency), Type: enums.ADMISSION, Status: enums.ACCEPTED,
+			Created: time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC),
+			Attributes: models.Attributes{
+				Debtor:      party("John", "Doe", "12-34-56", "12345678"),
+				Beneficiary: party("Jane", "Smith", "87-65-43", "87654321"),
+			},
+		},
+		{
+			GUID: "filter-2", Amount: models.NewMoney(15000, models.DefaultCurrency), Type: enums.SUBMISSION, Status: enums.DECLINED,
+			Created: time.Date(2025, 2, 15, 12, 30, 0, 0, time.UTC),
+			Attributes: models.Attributes{
+				Debtor:      party("Alice", "Jones", "11-22-33", "11112222"),
+				Beneficiary: party("Bob", "Brown", "44-55-66", "33334444"),
+			},
+		},
+		{
+			GUID: "filter-3", Amount: models.NewMoney(30000, models.DefaultCurrency), Type: enums.REVERSAL, Status: enums.ACCEPTED,
+			Created: time.Date(2025, 3, 20, 18, 45, 0, 0, time.UTC),
+			Attributes: models.Attributes{
+				Debtor:      party("John", "Doe", "12-34-56", "12345678"),
+				Beneficiary: party("Carol", "White", "77-88-99", "55556666"),
+			},
+		},
+	}
+	for i := range fixtures {
+		s.Create(context.Background(), &fixtures[i])
+	}
+
+	list := func(t *testing.T, url string) []string {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var page dto.ItemListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		guids := make([]string, 0, len(page.Data))
+		for _, item := range page.Data {
+			guids = append(guids, item.GUID)
+		}
+		assert.Equal(t, len(guids), page.Total)
+		return guids
+	}
+
+	t.Run("It can filter by multiple types", func(t *testing.T) {
+		assert.Equal(t, []string{"filter-1", "filter-3"}, list(t, "/items?type=admission,REVERSAL"))
+		assert.Equal(t, []string{"filter-1", "filter-3"}, list(t, "/items?type=ADMISSION&type=REVERSAL"))
+	})
+
+	t.Run("It can filter by status", func(t *testing.T) {
+		assert.Equal(t, []string{"filter-2"}, list(t, "/items?status=declined"))
+	})
+
+	t.Run("It can filter by an amount range", func(t *testing.T) {
+		assert.Equal(t, []string{"filter-2", "filter-3"}, list(t, "/items?amount_min=150"))
+		assert.Equal(t, []string{"filter-1", "filter-2"}, list(t, "/items?amount_max=150"))
+		assert.Equal(t, []string{"filter-2"}, list(t, "/items?amount_min=100&amount_max=200"))
+	})
+
+	t.Run("It can filter by a created date range", func(t *testing.T) {
+		assert.Equal(t, []string{"filter-2"}, list(t, "/items?created_from=2025-02-01&created_to=2025-02-15"))
+		assert.Equal(t, []string{"filter-2", "filter-3"}, list(t, "/items?created_from=2025-02-15T12:30:00Z"))
+		assert.Equal(t, []string{"filter-1"}, list(t, "/items?created_to=2025-02-15T12:29:59Z"))
+	})
+
+	t.Run("It can filter by debtor and beneficiary names", func(t *testing.T) {
+		assert.Equal(t, []string{"filter-1", "filter-3"}, list(t, "/items?debtor_name=john%20doe"))
+		assert.Equal(t, []string{"filter-3"}, list(t, "/items?beneficiary_name=WHITE"))
+	})
+
+	t.Run("It can filter by sort code and account number of either party", func(t *testing.T) {
+		assert.Equal(t, []string{"filter-2"}, list(t, "/items?sort_code=44-55-66"))
+		assert.Equal(t, []string{"filter-1", "filter-3"}, list(t, "/items?account_number=12345678"))
+	})
+
+	t.Run("It combines filters with AND semantics", func(t *testing.T) {
+		assert.Equal(t, []string{"filter-3"}, list(t, "/items?debtor_name=doe&amount_min=100"))
+		assert.Equal(t, []string{}, list(t, "/items?debtor_name=doe&status=DECLINED"))
+	})
+
+	t.Run("It returns 400 errors for invalid filters", func(t *testing.T) {
+		// Arrange
+		req := httptest.NewRequest(http.MethodGet, "/items?type=ADMISSION,BOGUS&status=unknown&amount_min=abc&created_from=yesterday&sort_code=123456&account_number=12", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var body map[string]map[string]string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "Invalid item type. Must be ADMISSION, SUBMISSION, or REVERSAL", body["errors"]["type"])
+		assert.Equal(t, "Invalid item status. Must be PENDING_APPROVAL, PENDING, ACCEPTED, DECLINED, SETTLED or RETURNED", body["errors"]["status"])
+		assert.Equal(t, "This field must be a number", body["errors"]["amountmin"])
+		assert.Equal(t, "Must be a date (YYYY-MM-DD) or an RFC 3339 timestamp", body["errors"]["createdfrom"])
+		assert.Equal(t, "Sort code must be in the format 00-00-00", body["errors"]["sortcode"])
+		assert.Equal(t, "Must be exactly 8 digits", body["errors"]["accountnumber"])
+	})
+
+	t.Run("It returns 400 errors for inverted ranges", func(t *testing.T) {
+		// Arrange
+		req := httptest.NewRequest(http.MethodGet, "/items?amount_min=200&amount_max=100&created_from=2025-03-01&created_to=2025-02-01", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Must be greater than or equal to amount_min")
+		assert.Contains(t, w.Body.String(), "Must be on or after created_from")
+	})
+}
@@ -0,0 +1,194 @@
+package feature
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go-test/backend/domain/dto"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"go-test/backend/repository"
+	"go-test/backend/tests"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportItems(t *testing.T) {
+	r, s := tests.SetupReadRouter()
+
+	send := func(path, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) dto.ItemImportResponse {
+		var body dto.ItemImportResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+	count := func(t *testing.T) int {
+		n, err := s.Count(context.Background())
+		require.NoError(t, err)
+		return n
+	}
+	header := "amount,currency,type,status,original_guid," +
+		"debtor_first_name,debtor_last_name,debtor_sort_code,debtor_account_number," +
+		"beneficiary_first_name,beneficiary_last_name,beneficiary_account_type,beneficiary_sort_code,beneficiary_account_number,beneficiary_iban\n"
+	validRow := "100.50,,ADMISSION,ACCEPTED,,John,Doe,12-34-56,12345678,Jane,Smith,,87-65-43,87654321,\n"
+	ibanRow := "25.00,EUR,submission,,,John,Doe,12-34-56,12345678,Hans,Muller,iban,,,DE89 3704 0044 0532 0130 00\n"
+	invalidRow := "-5,,BOGUS,,,John,Doe,12-34-56,12345678,Jane,Smith,,87-65-43,87654321,\n"
+
+	t.Run("It validates a file without creating items on a dry run", func(t *testing.T) {
+		// Arrange
+		before := count(t)
+
+		// Act
+		w := send("/items/import?dry_run=true", "text/csv", header+validRow+ibanRow)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := decode(t, w)
+		assert.True(t, body.DryRun)
+		assert.Equal(t, 2, body.Rows)
+		assert.Equal(t, 2, body.Valid)
+		assert.Equal(t, 0, body.Failed)
+		assert.Equal(t, 0, body.Created)
+		assert.Empty(t, body.Errors)
+		assert.Equal(t, before, count(t))
+	})
+
+	t.Run("It creates an item for every row of a valid file", func(t *testing.T) {
+		// Arrange
+		before := count(t)
+
+		// Act
+		w := send("/items/import", "text/csv", header+validRow+ibanRow)
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+		body := decode(t, w)
+		assert.False(t, body.DryRun)
+		assert.Equal(t, 2, body.Created)
+		assert.Equal(t, before+2, count(t))
+
+		page, err := s.GetAllFiltered(context.Background(), repository.ItemQuery{Currencies: []string{"EUR"}})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		item := page.Items[0]
+		assert.Equal(t, "25.00", item.Amount.String())
+		assert.Equal(t, enums.SUBMISSION, item.Type)
+		assert.Equal(t, enums.PENDING, item.Status)
+		assert.Equal(t, "Hans", item.Attributes.Beneficiary.FirstName)
+		assert.Equal(t, enums.IBANAccount, item.Attributes.Beneficiary.Account.AccountType)
+		assert.Equal(t, "DE89370400440532013000", item.Attributes.Beneficiary.Account.IBAN)
+		assert.NotNil(t, item.Attributes.Debtor.Account.Bank)
+	})
+
+	t.Run("It reports invalid rows by line and creates nothing", func(t *testing.T) {
+		// Arrange
+		before := count(t)
+		malformed := "1.00,GBP,ADMISSION\n"
+
+		// Act
+		w := send("/items/import", "text/csv", header+validRow+invalidRow+malformed+validRow)
+
+		// Assert
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		body := decode(t, w)
+		assert.Equal(t, 4, body.Rows)
+		assert.Equal(t, 2, body.Valid)
+		assert.Equal(t, 2, body.Failed)
+		assert.Equal(t, 0, body.Created)
+		require.Len(t, body.Errors, 2)
+		assert.Equal(t, 3, body.Errors[0].Line)
+		assert.Equal(t, "Value must be greater than 0", body.Errors[0].Errors["amount"])
+		assert.Equal(t, "Invalid item type. Must be ADMISSION, SUBMISSION, or REVERSAL", body.Errors[0].Errors["type"])
+		assert.Equal(t, 4, body.Errors[1].Line)
+		assert.Contains(t, body.Errors[1].Error, "wrong number of fields")
+		assert.Equal(t, before, count(t))
+	})
+
+	t.Run("It checks reversals against earlier rows of the file", func(t *testing.T) {
+		// Arrange
+		original := &models.Item{
+			GUID:   "import-original",
+			Amount: models.NewMoney(10000, models.DefaultCurrency),
+			Type:   enums.ADMISSION,
+			Status: enums.ACCEPTED,
+			Attributes: models.Attributes{
+				Debtor:      models.Party{FirstName: "John", LastName: "Doe", Account: models.Account{SortCode: "12-34-56", AccountNumber: "12345678"}},
+				Beneficiary: models.Party{FirstName: "Jane", LastName: "Smith", Account: models.Account{SortCode: "87-65-43", AccountNumber: "87654321"}},
+			},
+		}
+		require.NoError(t, s.Create(context.Background(), original))
+		reversal := func(amount string) string {
+			return amount + ",,REVERSAL,,import-original,Jane,Smith,87-65-43,87654321,John,Doe,,12-34-56,12345678,\n"
+		}
+
+		// Act
+		w := send("/items/import?dry_run=1", "text/csv", header+reversal("60.00")+reversal("50.00")+reversal("40.00"))
+
+		// Assert
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		body := decode(t, w)
+		assert.Equal(t, 2, body.Valid)
+		require.Len(t, body.Errors, 1)
+		assert.Equal(t, 3, body.Errors[0].Line)
+		assert.Contains(t, body.Errors[0].Error, "unreversed 40.00 GBP")
+	})
+
+	t.Run("It accepts the file as a multipart upload", func(t *testing.T) {
+		// Arrange
+		before := count(t)
+		var form bytes.Buffer
+		writer := multipart.NewWriter(&form)
+		require.NoError(t, writer.WriteField("note", "January spreadsheet"))
+		part, err := writer.CreateFormFile("file", "items.csv")
+		require.NoError(t, err)
+		_, err = part.Write([]byte("\ufeff" + header + validRow))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		// Act
+		w := send("/items/import", writer.FormDataContentType(), form.String())
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 1, decode(t, w).Created)
+		assert.Equal(t, before+1, count(t))
+	})
+
+	t.Run("It rejects files that are not item imports", func(t *testing.T) {
+		// Arrange
+		cases := []struct {
+			name    string
+			path    string
+			body    string
+			message string
+		}{
+			{"empty file", "/items/import", "", "the file is empty"},
+			{"header only", "/items/import", header, "the file has no rows"},
+			{"unknown column", "/items/import", "amount,type,payee\n1.00,ADMISSION,Jane\n", `unknown column \"payee\"`},
+			{"duplicate column", "/items/import", "amount,type,Amount\n1.00,ADMISSION,1.00\n", `duplicate column \"amount\"`},
+			{"missing column", "/items/import", "amount,currency\n1.00,GBP\n", `missing column \"type\"`},
+			{"invalid dry_run", "/items/import?dry_run=maybe", header + validRow, "invalid dry_run parameter"},
+		}
+
+		for _, tc := range cases {
+			// Act
+			w := send(tc.path, "text/csv", tc.body)
+
+			// Assert
+			assert.Equal(t, http.StatusBadRequest, w.Code, tc.name)
+			assert.Contains(t, w.Body.String(), tc.message, tc.name)
+		}
+	})
+}
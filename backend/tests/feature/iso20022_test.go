@@ -0,0 +1,439 @@
+package feature
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"go-test/backend/domain/dto"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/iso20022"
+	"go-test/backend/domain/models"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// schemaDir holds the XSDs the ISO 20022 messages are validated against
+var schemaDir = filepath.Join("..", "..", "domain", "iso20022", "xsd")
+
+// assertConforms validates an XML document against one of the bundled schemas with xmllint,
+// skipping the test where xmllint is not installed
+func assertConforms(t *testing.T, schema string, document []byte) {
+	t.Helper()
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint is not installed")
+	}
+
+	path := filepath.Join(t.TempDir(), "document.xml")
+	require.NoError(t, os.WriteFile(path, document, 0o644))
+	out, err := exec.Command(xmllint, "--noout", "--schema", filepath.Join(schemaDir, schema), path).CombinedOutput()
+	assert.NoError(t, err, string(out))
+}
+
+// isoItem builds a PENDING item paying amount minor units of currency from debtor to beneficiary
+func isoItem(guid string, itemType enums.ItemType, amount int64, currency string, debtor, beneficiary models.Party) models.Item {
+	item := bacsItem(guid, itemType, amount, debtor, beneficiary)
+	item.Amount = models.NewMoney(amount, currency)
+	item.Status = enums.PENDING
+	return item
+}
+
+// pain001 is the part of a pain.001 message the tests read back
+type pain001 struct {
+	XMLName     xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:pain.001.001.09 Document"`
+	MessageID   string   `xml:"CstmrCdtTrfInitn>GrpHdr>MsgId"`
+	NumberOfTxs int      `xml:"CstmrCdtTrfInitn>GrpHdr>NbOfTxs"`
+	ControlSum  string   `xml:"CstmrCdtTrfInitn>GrpHdr>CtrlSum"`
+	Payments    []struct {
+		ID             string `xml:"PmtInfId"`
+		NumberOfTxs    int    `xml:"NbOfTxs"`
+		ControlSum     string `xml:"CtrlSum"`
+		ExecutionDate  string `xml:"ReqdExctnDt>Dt"`
+		DebtorAccount  string `xml:"DbtrAcct>Id>Othr>Id"`
+		DebtorIBAN     string `xml:"DbtrAcct>Id>IBAN"`
+		DebtorSortCode string `xml:"DbtrAgt>FinInstnId>ClrSysMmbId>MmbId"`
+		Transfers      []struct {
+			EndToEndID string `xml:"PmtId>EndToEndId"`
+			Amount     struct {
+				Currency string `xml:"Ccy,attr"`
+				Value    string `xml:",chardata"`
+			} `xml:"Amt>InstdAmt"`
+			BIC             string `xml:"CdtrAgt>FinInstnId>BICFI"`
+			OtherAgent      string `xml:"CdtrAgt>FinInstnId>Othr>Id"`
+			Creditor        string `xml:"Cdtr>Nm"`
+			CreditorIBAN    string `xml:"CdtrAcct>Id>IBAN"`
+			CreditorAccount string `xml:"CdtrAcct>Id>Othr>Id"`
+			Remittance      string `xml:"RmtInf>Ustrd"`
+		} `xml:"CdtTrfTxInf"`
+	} `xml:"CstmrCdtTrfInitn>PmtInf"`
+}
+
+func TestPain001(t *testing.T) {
+	initiation := iso20022.Initiation{
+		MessageID:              "PAYROLL-0312",
+		InitiatingParty:        "Example Payroll Ltd",
+		Created:                time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC),
+		RequestedExecutionDate: time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC),
+	}
+	payroll := bacsParty("Example", "Payroll Ltd", "12-34-56", "12345678")
+	expenses := bacsParty("Example", "Expenses", "12-34-56", "87654321")
+	alice := bacsParty("Alice", "O'Brien-Smith", "87-65-43", "11112222")
+	hans := models.Party{FirstName: "Hans", LastName: "Müller", Account: models.Account{AccountType: enums.IBANAccount, IBAN: "DE89370400440532013000", BIC: "COBADEFFXXX"}}
+	marie := models.Party{FirstName: "Marie", LastName: "Dubois", Account: models.Account{AccountType: enums.IBANAccount, IBAN: "FR1420041010050500013M02606"}}
+
+	items := []models.Item{
+		isoItem("7d3f6a52-2c1e-4b8e-9a47-0c5f1e2d3b01", enums.SUBMISSION, 125000, "GBP", payroll, alice),
+		isoItem("7d3f6a52-2c1e-4b8e-9a47-0c5f1e2d3b02", enums.SUBMISSION, 4599, "EUR", expenses, hans),
+		isoItem("7d3f6a52-2c1e-4b8e-9a47-0c5f1e2d3b03", enums.SUBMISSION, 100001, "EUR", payroll, marie),
+		isoItem("7d3f6a52-2c1e-4b8e-9a47-0c5f1e2d3b04", enums.REVERSAL, 500, "GBP", payroll, alice),
+	}
+	items[3].OriginalGUID = "7d3f6a52-2c1e-4b8e-9a47-0c5f1e2d3b00"
+
+	t.Run("It writes a message conforming to the pain.001.001.09 schema", func(t *testing.T) {
+		// Act
+		var message bytes.Buffer
+		require.NoError(t, iso20022.WritePain001(&message, initiation, items))
+
+		// Assert
+		assert.True(t, strings.HasPrefix(message.String(), xml.Header))
+		assertConforms(t, "pain.001.001.09.xsd", message.Bytes())
+	})
+
+	t.Run("It groups transfers by debtor account with control sums by face value", func(t *testing.T) {
+		// Act
+		var message bytes.Buffer
+		require.NoError(t, iso20022.WritePain001(&message, initiation, items))
+
+		// Assert
+		var doc pain001
+		require.NoError(t, xml.Unmarshal(message.Bytes(), &doc))
+		assert.Equal(t, "PAYROLL-0312", doc.MessageID)
+		assert.Equal(t, 4, doc.NumberOfTxs)
+		assert.Equal(t, "2301", doc.ControlSum)
+		require.Len(t, doc.Payments, 2)
+
+		fromPayroll, fromExpenses := doc.Payments[0], doc.Payments[1]
+		assert.Equal(t, "PAYROLL-0312-1", fromPayroll.ID)
+		assert.Equal(t, "2025-03-12", fromPayroll.ExecutionDate)
+		assert.Equal(t, "12345678", fromPayroll.DebtorAccount)
+		assert.Equal(t, "123456", fromPayroll.DebtorSortCode)
+		assert.Equal(t, 3, fromPayroll.NumberOfTxs)
+		assert.Equal(t, "2255.01", fromPayroll.ControlSum)
+		assert.Equal(t, "PAYROLL-0312-2", fromExpenses.ID)
+		assert.Equal(t, "87654321", fromExpenses.DebtorAccount)
+		assert.Equal(t, "45.99", fromExpenses.ControlSum)
+
+		toAlice, toMarie, reversal := fromPayroll.Transfers[0], fromPayroll.Transfers[1], fromPayroll.Transfers[2]
+		assert.Equal(t, "7d3f6a522c1e4b8e9a470c5f1e2d3b01", toAlice.EndToEndID)
+		assert.Equal(t, "GBP", toAlice.Amount.Currency)
+		assert.Equal(t, "1250.00", toAlice.Amount.Value)
+		assert.Equal(t, "Alice O'Brien-Smith", toAlice.Creditor)
+		assert.Equal(t, "11112222", toAlice.CreditorAccount)
+		assert.Equal(t, "EUR", toMarie.Amount.Currency)
+		assert.Equal(t, "1000.01", toMarie.Amount.Value)
+		assert.Equal(t, "FR1420041010050500013M02606", toMarie.CreditorIBAN)
+		assert.Equal(t, "NOTPROVIDED", toMarie.OtherAgent)
+		assert.Equal(t, "Reversal of 7d3f6a52-2c1e-4b8e-9a47-0c5f1e2d3b00", reversal.Remittance)
+
+		toHans := fromExpenses.Transfers[0]
+		assert.Equal(t, "Hans Müller", toHans.Creditor)
+		assert.Equal(t, "DE89370400440532013000", toHans.CreditorIBAN)
+		assert.Equal(t, "COBADEFFXXX", toHans.BIC)
+	})
+
+	t.Run("It adds up control sums that do not fit in an int64", func(t *testing.T) {
+		// Arrange
+		large := []models.Item{
+			isoItem("7d3f6a52-2c1e-4b8e-9a47-0c5f1e2d3b11", enums.SUBMISSION, 90000000000000000, "GBP", payroll, alice),
+			isoItem("7d3f6a52-2c1e-4b8e-9a47-0c5f1e2d3b12", enums.SUBMISSION, 90000000000000000, "GBP", payroll, alice),
+		}
+
+		// Act
+		var message bytes.Buffer
+		require.NoError(t, iso20022.WritePain001(&message, initiation, large))
+
+		// Assert
+		var doc pain001
+		require.NoError(t, xml.Unmarshal(message.Bytes(), &doc))
+		assert.Equal(t, "1800000000000000", doc.ControlSum)
+		require.Len(t, doc.Payments, 1)
+		assert.Equal(t, "1800000000000000", doc.Payments[0].ControlSum)
+	})
+
+	t.Run("It refuses items that are not PENDING credit transfers", func(t *testing.T) {
+		// Arrange
+		accepted := items[0]
+		accepted.Status = enums.ACCEPTED
+		admission := items[0]
+		admission.Type = enums.ADMISSION
+
+		cases := []struct {
+			name string
+			item models.Item
+			err  error
+		}{
+			{"pending submission", items[0], nil},
+			{"pending reversal", items[3], nil},
+			{"accepted", accepted, iso20022.ErrNotPending},
+			{"admission", admission, iso20022.ErrItemType},
+		}
+
+		for _, tc := range cases {
+			// Act
+			err := iso20022.CheckCreditTransfer(tc.item)
+
+			// Assert
+			assert.ErrorIs(t, err, tc.err, tc.name)
+			if tc.err != nil {
+				assert.ErrorIs(t, iso20022.WritePain001(&bytes.Buffer{}, initiation, []models.Item{tc.item}), tc.err, tc.name)
+			}
+		}
+	})
+
+	t.Run("It restores GUIDs from end to end identifiers", func(t *testing.T) {
+		// Act
+		id := iso20022.EndToEndID(items[0].GUID)
+
+		// Assert
+		assert.Equal(t, items[0].GUID, iso20022.GUIDFromEndToEndID(id))
+		assert.Equal(t, items[0].GUID, iso20022.GUIDFromEndToEndID(strings.ToUpper(id)))
+		assert.Equal(t, "not-one-of-ours", iso20022.GUIDFromEndToEndID("not-one-of-ours"))
+	})
+}
+
+func TestPacs002(t *testing.T) {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "iso20022", "pacs002.xml"))
+	require.NoError(t, err)
+
+	t.Run("The fixture conforms to the pacs.002.001.10 schema", func(t *testing.T) {
+		assertConforms(t, "pacs.002.001.10.xsd", fixture)
+	})
+
+	t.Run("It reads the status of each transaction", func(t *testing.T) {
+		// Act
+		report, err := iso20022.ReadPacs002(bytes.NewReader(fixture))
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "STS-20250312-0001", report.MessageID)
+		require.Len(t, report.Transactions, 6)
+
+		accepted, rejected := report.Transactions[0], report.Transactions[1]
+		assert.Equal(t, "7d3f6a522c1e4b8e9a470c5f1e2d3b01", accepted.EndToEndID)
+		assert.Equal(t, "ACSC", accepted.Explanation())
+		assert.Equal(t, "AC04", rejected.Reason)
+		assert.Equal(t, "RJCT AC04: Account closed", rejected.Explanation())
+		assert.Equal(t, "RJCT LATE", report.Transactions[3].Explanation())
+	})
+
+	t.Run("It maps transaction statuses to item statuses", func(t *testing.T) {
+		// Arrange
+		cases := map[string]enums.ItemStatus{
+			"ACSC": enums.ACCEPTED,
+			"ACCC": enums.ACCEPTED,
+			"ACSP": enums.ACCEPTED,
+			"RJCT": enums.DECLINED,
+			"PDNG": "",
+			"ACTC": "",
+		}
+
+		for code, want := range cases {
+			// Act
+			status, err := iso20022.TransactionStatus{Status: code}.ItemStatus()
+
+			// Assert
+			assert.NoError(t, err, code)
+			assert.Equal(t, want, status, code)
+		}
+		_, err := iso20022.TransactionStatus{Status: "XXXX"}.ItemStatus()
+		assert.ErrorIs(t, err, iso20022.ErrUnknownStatus)
+	})
+
+	t.Run("It refuses documents that are not payment status reports", func(t *testing.T) {
+		// Arrange
+		cases := map[string]string{
+			"not XML":         "STS-20250312-0001,ACSC",
+			"wrong namespace": strings.Replace(string(fixture), "pacs.002.001.10", "pacs.002.001.03", 1),
+			"no message id":   strings.Replace(string(fixture), "<MsgId>STS-20250312-0001</MsgId>", "", 1),
+		}
+
+		for name, document := range cases {
+			// Act
+			_, err := iso20022.ReadPacs002(strings.NewReader(document))
+
+			// Assert
+			assert.ErrorIs(t, err, iso20022.ErrInvalidDocument, name)
+		}
+	})
+}
+
+func TestISO20022Endpoints(t *testing.T) {
+	r, s := tests.SetupReadRouter()
+
+	send := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/items:"+method, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format(time.DateOnly)
+	request := func(guids ...string) string {
+		body, _ := json.Marshal(map[string]any{
+			"guids":                    guids,
+			"message_id":               "PAYROLL-0312",
+			"initiating_party":         "Example Payroll Ltd",
+			"requested_execution_date": tomorrow,
+		})
+		return string(body)
+	}
+
+	payroll := bacsParty("Example", "Payroll Ltd", "12-34-56", "12345678")
+	alice := bacsParty("Alice", "Smith", "87-65-43", "11112222")
+	item := func(n string, status enums.ItemStatus) models.Item {
+		created := isoItem("7d3f6a52-2c1e-4b8e-9a47-0c5f1e2d3b0"+n, enums.SUBMISSION, 125000, "GBP", payroll, alice)
+		created.Status = status
+		require.NoError(t, s.Create(context.Background(), &created))
+		return created
+	}
+	toAccept := item("1", enums.PENDING)
+	toDecline := item("2", enums.PENDING)
+	inProgress := item("3", enums.PENDING)
+	settled := item("4", enums.SETTLED)
+	unknownStatus := item("6", enums.PENDING)
+
+	fixture, err := os.ReadFile(filepath.Join("testdata", "iso20022", "pacs002.xml"))
+	require.NoError(t, err)
+
+	t.Run("It returns a pain.001 message initiating the selected items", func(t *testing.T) {
+		// Act
+		w := send("pain001", request(toAccept.GUID, toDecline.GUID))
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="PAYROLL-0312.xml"`, w.Header().Get("Content-Disposition"))
+		var doc pain001
+		require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &doc))
+		assert.Equal(t, 2, doc.NumberOfTxs)
+		assert.Equal(t, tomorrow, doc.Payments[0].ExecutionDate)
+		assertConforms(t, "pain.001.001.09.xsd", w.Body.Bytes())
+	})
+
+	t.Run("It reports every item that cannot be initiated and writes no message", func(t *testing.T) {
+		// Act
+		w := send("pain001", request(toAccept.GUID, settled.GUID, "missing", toAccept.GUID))
+
+		// Assert
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var body map[string]map[string]string
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, map[string]string{
+			settled.GUID:  "Only PENDING items can be initiated as credit transfers",
+			"missing":     "Item not found",
+			toAccept.GUID: "Item is selected more than once",
+		}, body["errors"])
+	})
+
+	t.Run("It validates the message details", func(t *testing.T) {
+		// Arrange
+		cases := []struct {
+			name    string
+			body    string
+			field   string
+			message string
+		}{
+			{"message id too long", strings.Replace(request(toAccept.GUID), "PAYROLL-0312", strings.Repeat("X", 36), 1), "messageid", "Value is too long"},
+			{"execution date in the past", strings.Replace(request(toAccept.GUID), tomorrow, "2020-01-01", 1), "requestedexecutiondate", "Must not be in the past"},
+			{"no initiating party", strings.Replace(request(toAccept.GUID), "Example Payroll Ltd", "", 1), "initiatingparty", "This field is required"},
+		}
+
+		for _, tc := range cases {
+			// Act
+			w := send("pain001", tc.body)
+
+			// Assert
+			assert.Equal(t, http.StatusBadRequest, w.Code, tc.name)
+			var body map[string]map[string]string
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), tc.name)
+			assert.Equal(t, tc.message, body["errors"][tc.field], tc.name)
+		}
+	})
+
+	t.Run("It applies a pacs.002 status report to the matching items", func(t *testing.T) {
+		// Act
+		w := send("pacs002", string(fixture))
+
+		// Assert
+		assert.Equal(t, http.StatusMultiStatus, w.Code)
+		var response dto.ItemStatusReportResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "STS-20250312-0001", response.MessageID)
+		assert.Equal(t, 2, response.Updated)
+		assert.Equal(t, 1, response.Unchanged)
+		assert.Equal(t, 3, response.Failed)
+
+		statuses := make([]int, len(response.Results))
+		for i, result := range response.Results {
+			statuses[i] = result.Status
+		}
+		assert.Equal(t, []int{200, 200, 200, 409, 404, 422}, statuses)
+		assert.Equal(t, "Cannot transition item from SETTLED to DECLINED", response.Results[3].Error)
+		assert.Equal(t, `Unknown transaction status "XXXX"`, response.Results[5].Error)
+
+		accepted, err := s.GetByGUID(context.Background(), toAccept.GUID)
+		require.NoError(t, err)
+		assert.Equal(t, enums.ACCEPTED, accepted.Status)
+		declined, err := s.GetByGUID(context.Background(), toDecline.GUID)
+		require.NoError(t, err)
+		assert.Equal(t, enums.DECLINED, declined.Status)
+		require.Len(t, declined.Transitions, 1)
+		assert.Equal(t, "pacs.002 STS-20250312-0001", declined.Transitions[0].Actor)
+		assert.Equal(t, "RJCT AC04: Account closed", declined.Transitions[0].Reason)
+
+		for _, unchanged := range []models.Item{inProgress, settled, unknownStatus} {
+			stored, err := s.GetByGUID(context.Background(), unchanged.GUID)
+			require.NoError(t, err)
+			assert.Equal(t, unchanged.Status, stored.Status, unchanged.GUID)
+		}
+	})
+
+	t.Run("It leaves items unchanged when a report is delivered again", func(t *testing.T) {
+		// Act
+		w := send("pacs002", string(fixture))
+
+		// Assert
+		var response dto.ItemStatusReportResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 0, response.Updated)
+		assert.Equal(t, 3, response.Unchanged)
+
+		accepted, err := s.GetByGUID(context.Background(), toAccept.GUID)
+		require.NoError(t, err)
+		assert.Len(t, accepted.Transitions, 1)
+	})
+
+	t.Run("It refuses bodies that are not status reports", func(t *testing.T) {
+		// Arrange
+		empty := `<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pacs.002.001.10"><FIToFIPmtStsRpt><GrpHdr><MsgId>STS-EMPTY</MsgId></GrpHdr></FIToFIPmtStsRpt></Document>`
+
+		// Act
+		invalid := send("pacs002", `{"status": "ACSC"}`)
+		noTransactions := send("pacs002", empty)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, invalid.Code)
+		assert.Contains(t, invalid.Body.String(), "pacs.002.001.10 payment status report")
+		assert.Equal(t, http.StatusUnprocessableEntity, noTransactions.Code)
+	})
+}
@@ -0,0 +1,136 @@
+package feature
+
+import (
+	"context"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"go-test/backend/repository"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemOptimisticConcurrency(t *testing.T) {
+	r, s := tests.SetupReadRouter()
+
+	newItem := func(t *testing.T, guid string) *models.Item {
+		item := &models.Item{GUID: guid, Amount: models.NewMoney(10000, models.DefaultCurrency), Type: enums.ADMISSION, Status: enums.PENDING}
+		require.NoError(t, s.Create(context.Background(), item))
+		return item
+	}
+	send := func(method, path, ifMatch, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("It emits the item version as an ETag", func(t *testing.T) {
+		// Arrange
+		item := newItem(t, "etag-guid-1")
+
+		// Act
+		get := send(http.MethodGet, "/items/"+item.GUID, "", "")
+		put := send(http.MethodPut, "/items/"+item.GUID, "", createUpdatePayload())
+		post := send(http.MethodPost, "/items", "", createValidCreatePayload())
+
+		// Assert
+		assert.Equal(t, `"1"`, get.Header().Get("ETag"))
+		assert.Contains(t, get.Body.String(), `"version":1`)
+		assert.Equal(t, `"2"`, put.Header().Get("ETag"))
+		assert.Contains(t, put.Body.String(), `"version":2`)
+		assert.Equal(t, `"1"`, post.Header().Get("ETag"))
+	})
+
+	t.Run("It updates when If-Match matches the current ETag", func(t *testing.T) {
+		// Arrange
+		item := newItem(t, "etag-guid-2")
+
+		// Act
+		w := send(http.MethodPut, "/items/"+item.GUID, `"1"`, createUpdatePayload())
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	})
+
+	t.Run("It returns 412 when a PUT carries a stale ETag", func(t *testing.T) {
+		// Arrange
+		item := newItem(t, "etag-guid-3")
+		first := send(http.MethodPut, "/items/"+item.GUID, `"1"`, createUpdatePayload())
+		require.Equal(t, http.StatusOK, first.Code)
+
+		// Act
+		w := send(http.MethodPut, "/items/"+item.GUID, `"1"`, `{"amount": 999}`)
+
+		// Assert
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Contains(t, w.Body.String(), "Item has been modified")
+		current, err := s.GetByGUID(context.Background(), item.GUID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(20000), current.Amount.Minor)
+		assert.Equal(t, 2, current.Version)
+	})
+
+	t.Run("It returns 412 when a DELETE carries a stale ETag", func(t *testing.T) {
+		// Arrange
+		item := newItem(t, "etag-guid-4")
+		require.Equal(t, http.StatusOK, send(http.MethodPut, "/items/"+item.GUID, "", createUpdatePayload()).Code)
+
+		// Act
+		stale := send(http.MethodDelete, "/items/"+item.GUID, `"1"`, "")
+		fresh := send(http.MethodDelete, "/items/"+item.GUID, `"2"`, "")
+
+		// Assert
+		assert.Equal(t, http.StatusPreconditionFailed, stale.Code)
+		assert.Equal(t, http.StatusNoContent, fresh.Code)
+	})
+
+	t.Run("It accepts a wildcard or a list of ETags and rejects weak ETags", func(t *testing.T) {
+		// Arrange
+		item := newItem(t, "etag-guid-5")
+
+		// Act
+		weak := send(http.MethodPut, "/items/"+item.GUID, `W/"1"`, createUpdatePayload())
+		list := send(http.MethodPut, "/items/"+item.GUID, `"7", "1"`, createUpdatePayload())
+		wildcard := send(http.MethodPut, "/items/"+item.GUID, `*`, createUpdatePayload())
+
+		// Assert
+		assert.Equal(t, http.StatusPreconditionFailed, weak.Code)
+		assert.Equal(t, http.StatusOK, list.Code)
+		assert.Equal(t, http.StatusOK, wildcard.Code)
+		assert.Equal(t, `"3"`, wildcard.Header().Get("ETag"))
+	})
+
+	t.Run("It compare-and-swaps store updates on the version", func(t *testing.T) {
+		// Arrange
+		item := newItem(t, "etag-guid-6")
+		mine, err := s.GetByGUID(context.Background(), item.GUID)
+		require.NoError(t, err)
+		theirs, err := s.GetByGUID(context.Background(), item.GUID)
+		require.NoError(t, err)
+
+		// Act
+		theirs.Amount = models.NewMoney(30000, models.DefaultCurrency)
+		errTheirs := s.Update(context.Background(), theirs)
+		mine.Amount = models.NewMoney(40000, models.DefaultCurrency)
+		errMine := s.Update(context.Background(), mine)
+
+		// Assert
+		require.NoError(t, errTheirs)
+		assert.Equal(t, 2, theirs.Version)
+		assert.ErrorIs(t, errMine, repository.ErrVersionConflict)
+		assert.ErrorIs(t, s.Delete(context.Background(), item.GUID, 1), repository.ErrVersionConflict)
+		current, err := s.GetByGUID(context.Background(), item.GUID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(30000), current.Amount.Minor)
+	})
+}
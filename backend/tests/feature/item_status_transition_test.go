@@ -0,0 +1,175 @@
+package feature
+
+import (
+	"context"
+	"encoding/json"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"go-test/backend/helpers"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemStatusTransitions(t *testing.T) {
+	r, s := tests.SetupReadRouter()
+
+	newItem := func(t *testing.T, guid string, status enums.ItemStatus) *models.Item {
+		item := &models.Item{GUID: guid, Amount: models.NewMoney(10000, models.DefaultCurrency), Type: enums.ADMISSION, Status: status}
+		require.NoError(t, s.Create(context.Background(), item))
+		return item
+	}
+	sendAs := func(actor, method, path, ifMatch, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		if actor != "" {
+			req.Header.Set("X-Actor", actor)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	send := func(method, path, ifMatch, body string) *httptest.ResponseRecorder {
+		return sendAs("", method, path, ifMatch, body)
+	}
+
+	t.Run("It creates items as PENDING unless given another initial status", func(t *testing.T) {
+		// Arrange
+		withoutStatus := strings.Replace(createValidCreatePayload(), `"status": "ACCEPTED",`, "", 1)
+		settled := strings.Replace(createValidCreatePayload(), `"ACCEPTED"`, `"SETTLED"`, 1)
+
+		// Act
+		defaulted := send(http.MethodPost, "/items", "", withoutStatus)
+		rejected := send(http.MethodPost, "/items", "", settled)
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, defaulted.Code)
+		assert.Contains(t, defaulted.Body.String(), `"status":"PENDING"`)
+		assert.Equal(t, http.StatusBadRequest, rejected.Code)
+		assert.Contains(t, rejected.Body.String(), "Items must be created as PENDING, ACCEPTED or DECLINED")
+	})
+
+	t.Run("It walks an item through its lifecycle, recording each transition", func(t *testing.T) {
+		// Arrange
+		item := newItem(t, "lifecycle-guid-1", enums.PENDING)
+
+		// Act
+		accepted := sendAs("alice", http.MethodPost, "/items/"+item.GUID+"/transitions", `"1"`, `{"status": "accepted", "reason": "Checks passed"}`)
+		settled := sendAs("bob", http.MethodPost, "/items/"+item.GUID+"/transitions", `"2"`, `{"status": "SETTLED", "reason": "Settlement cycle 42"}`)
+		get := send(http.MethodGet, "/items/"+item.GUID, "", "")
+
+		// Assert
+		assert.Equal(t, http.StatusOK, accepted.Code)
+		assert.Equal(t, `"2"`, accepted.Header().Get("ETag"))
+		assert.Equal(t, http.StatusOK, settled.Code)
+		assert.Equal(t, `"3"`, settled.Header().Get("ETag"))
+
+		var body models.Item
+		require.NoError(t, json.Unmarshal(get.Body.Bytes(), &body))
+		assert.Equal(t, enums.SETTLED, body.Status)
+		require.Len(t, body.Transitions, 2)
+		assert.Equal(t, enums.PENDING, body.Transitions[0].From)
+		assert.Equal(t, enums.ACCEPTED, body.Transitions[0].To)
+		assert.Equal(t, "alice", body.Transitions[0].Actor)
+		assert.Equal(t, "Checks passed", body.Transitions[0].Reason)
+		assert.False(t, body.Transitions[0].At.IsZero())
+		assert.Equal(t, enums.ACCEPTED, body.Transitions[1].From)
+		assert.Equal(t, enums.SETTLED, body.Transitions[1].To)
+		assert.Equal(t, "bob", body.Transitions[1].Actor)
+	})
+
+	t.Run("It returns 409 for a transition the lifecycle does not allow", func(t *testing.T) {
+		// Arrange
+		cases := []struct {
+			from enums.ItemStatus
+			to   string
+		}{
+			{enums.PENDING, "SETTLED"},
+			{enums.ACCEPTED, "DECLINED"},
+			{enums.ACCEPTED, "ACCEPTED"},
+			{enums.DECLINED, "ACCEPTED"},
+			{enums.RETURNED, "SETTLED"},
+		}
+
+		for i, tc := range cases {
+			item := newItem(t, "illegal-guid-"+string(rune('a'+i)), tc.from)
+
+			// Act
+			w := send(http.MethodPost, "/items/"+item.GUID+"/transitions", "", `{"status": "`+tc.to+`", "reason": "test"}`)
+
+			// Assert
+			assert.Equal(t, http.StatusConflict, w.Code, string(tc.from)+" -> "+tc.to)
+			assert.Contains(t, w.Body.String(), "Cannot transition item from "+string(tc.from)+" to "+tc.to)
+			stored, err := s.GetByGUID(context.Background(), item.GUID)
+			require.NoError(t, err)
+			assert.Equal(t, tc.from, stored.Status)
+			assert.Equal(t, 1, stored.Version)
+		}
+	})
+
+	t.Run("It enforces the lifecycle on update and records the change", func(t *testing.T) {
+		// Arrange
+		item := newItem(t, "lifecycle-guid-2", enums.ACCEPTED)
+
+		// Act
+		illegal := send(http.MethodPut, "/items/"+item.GUID, "", `{"status": "PENDING"}`)
+		unchanged := send(http.MethodPut, "/items/"+item.GUID, "", `{"status": "ACCEPTED", "amount": "5.00"}`)
+		returned := send(http.MethodPut, "/items/"+item.GUID, "", `{"status": "returned", "reason": "Account closed"}`)
+
+		// Assert
+		assert.Equal(t, http.StatusConflict, illegal.Code)
+		assert.Contains(t, illegal.Body.String(), "Cannot transition item from ACCEPTED to PENDING")
+		assert.Equal(t, http.StatusOK, unchanged.Code)
+		assert.NotContains(t, unchanged.Body.String(), `"transitions"`)
+		assert.Equal(t, http.StatusOK, returned.Code)
+
+		var body models.Item
+		require.NoError(t, json.Unmarshal(returned.Body.Bytes(), &body))
+		assert.Equal(t, enums.RETURNED, body.Status)
+		require.Len(t, body.Transitions, 1)
+		assert.Equal(t, enums.ACCEPTED, body.Transitions[0].From)
+		assert.Equal(t, enums.RETURNED, body.Transitions[0].To)
+		assert.Equal(t, helpers.AnonymousActor, body.Transitions[0].Actor)
+		assert.Equal(t, "Account closed", body.Transitions[0].Reason)
+		assert.False(t, body.Transitions[0].At.IsZero())
+	})
+
+	t.Run("It records a status change made by an update without a reason as updated", func(t *testing.T) {
+		// Arrange
+		item := newItem(t, "lifecycle-guid-4", enums.PENDING)
+
+		// Act
+		w := send(http.MethodPut, "/items/"+item.GUID, "", `{"status": "ACCEPTED"}`)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		var body models.Item
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Len(t, body.Transitions, 1)
+		assert.Equal(t, helpers.UpdateReason, body.Transitions[0].Reason)
+	})
+
+	t.Run("It validates transition requests", func(t *testing.T) {
+		// Arrange
+		item := newItem(t, "lifecycle-guid-3", enums.PENDING)
+
+		// Act
+		invalid := send(http.MethodPost, "/items/"+item.GUID+"/transitions", "", `{"status": "bogus"}`)
+		missing := send(http.MethodPost, "/items/nonexistent-guid/transitions", "", `{"status": "ACCEPTED", "reason": "test"}`)
+		stale := send(http.MethodPost, "/items/"+item.GUID+"/transitions", `"7"`, `{"status": "ACCEPTED", "reason": "test"}`)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, invalid.Code)
+		assert.Contains(t, invalid.Body.String(), `"status":"Invalid item status`)
+		assert.Contains(t, invalid.Body.String(), `"reason":"This field is required"`)
+		assert.Equal(t, http.StatusNotFound, missing.Code)
+		assert.Equal(t, http.StatusPreconditionFailed, stale.Code)
+	})
+}
@@ -1,6 +1,7 @@
 package feature
 
 import (
+	"context"
 	"go-test/backend/domain/enums"
 	"go-test/backend/domain/models"
 	"go-test/backend/tests"
@@ -16,11 +17,11 @@ func TestItCanListItemByGuid(t *testing.T) {
 
 	item := &models.Item{
 		GUID:   "test-guid-123",
-		Amount: 100,
+		Amount: models.NewMoney(10000, models.DefaultCurrency),
 		Type:   enums.ADMISSION,
 		Status: enums.ACCEPTED,
 	}
-	s.Create(item)
+	s.Create(context.Background(), item)
 
 	t.Run("It can list an item by GUID", func(t *testing.T) {
 		// Arrange
@@ -1,6 +1,10 @@
 package feature
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-test/backend/domain/dto"
 	"go-test/backend/domain/enums"
 	"go-test/backend/domain/models"
 	"go-test/backend/tests"
@@ -10,6 +14,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestItemsListAndFilter(t *testing.T) {
@@ -17,11 +22,11 @@ func TestItemsListAndFilter(t *testing.T) {
 
 	item := &models.Item{
 		GUID:   "test-guid-123",
-		Amount: 100,
+		Amount: models.NewMoney(10000, models.DefaultCurrency),
 		Type:   enums.ADMISSION,
 		Status: enums.ACCEPTED,
 	}
-	s.Create(item)
+	s.Create(context.Background(), item)
 
 	t.Run("It can list all items", func(t *testing.T) {
 		// Arrange
@@ -105,7 +110,8 @@ func TestItemsListAndFilter(t *testing.T) {
 
 		// Assert
 		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, "[]", w.Body.String())
+		assert.Contains(t, w.Body.String(), `"data":[]`)
+		assert.Contains(t, w.Body.String(), `"total":0`)
 	})
 
 	t.Run("It returns empty results when query matches no items", func(t *testing.T) {
@@ -118,7 +124,8 @@ func TestItemsListAndFilter(t *testing.T) {
 
 		// Assert
 		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Equal(t, "[]", w.Body.String())
+		assert.Contains(t, w.Body.String(), `"data":[]`)
+		assert.Contains(t, w.Body.String(), `"total":0`)
 	})
 
 	t.Run("It returns a server error with invalid query params", func(t *testing.T) {
@@ -186,3 +193,102 @@ func TestItemsListAndFilter(t *testing.T) {
 		assert.Contains(t, w.Body.String(), "ADMISSION")
 	})
 }
+
+func TestItemsCursorPagination(t *testing.T) {
+	r, s := tests.SetupReadRouter()
+
+	for i := 1; i <= 5; i++ {
+		s.Create(context.Background(), &models.Item{
+			GUID:   fmt.Sprintf("page-guid-%d", i),
+			Amount: models.NewMoney(10000, models.DefaultCurrency),
+			Type:   enums.ADMISSION,
+			Status: enums.ACCEPTED,
+		})
+	}
+
+	list := func(t *testing.T, url string) dto.ItemListResponse {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var page dto.ItemListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page
+	}
+	guids := func(page dto.ItemListResponse) []string {
+		out := make([]string, 0, len(page.Data))
+		for _, item := range page.Data {
+			out = append(out, item.GUID)
+		}
+		return out
+	}
+
+	t.Run("It returns the first page with a next cursor and total", func(t *testing.T) {
+		// Act
+		page := list(t, "/items?limit=2")
+
+		// Assert
+		assert.Equal(t, []string{"page-guid-1", "page-guid-2"}, guids(page))
+		assert.Equal(t, 5, page.Total)
+		assert.NotNil(t, page.NextCursor)
+		assert.Nil(t, page.PrevCursor)
+	})
+
+	t.Run("It can walk forwards and backwards through pages", func(t *testing.T) {
+		// Act
+		first := list(t, "/items?limit=2")
+		second := list(t, "/items?limit=2&cursor="+*first.NextCursor)
+		third := list(t, "/items?limit=2&cursor="+*second.NextCursor)
+		back := list(t, "/items?limit=2&cursor="+*third.PrevCursor)
+
+		// Assert
+		assert.Equal(t, []string{"page-guid-3", "page-guid-4"}, guids(second))
+		assert.NotNil(t, second.PrevCursor)
+		assert.NotNil(t, second.NextCursor)
+
+		assert.Equal(t, []string{"page-guid-5"}, guids(third))
+		assert.NotNil(t, third.PrevCursor)
+		assert.Nil(t, third.NextCursor)
+
+		assert.Equal(t, guids(second), guids(back))
+		assert.NotNil(t, back.NextCursor)
+	})
+
+	t.Run("It keeps cursors stable when earlier items are deleted", func(t *testing.T) {
+		// Arrange
+		first := list(t, "/items?limit=2")
+		require.NoError(t, s.Delete(context.Background(), "page-guid-1", 0))
+
+		// Act
+		second := list(t, "/items?limit=2&cursor="+*first.NextCursor)
+
+		// Assert
+		assert.Equal(t, []string{"page-guid-3", "page-guid-4"}, guids(second))
+		assert.Equal(t, 4, second.Total)
+	})
+
+	t.Run("It paginates within the filtered results", func(t *testing.T) {
+		// Act
+		page := list(t, "/items?limit=2&query=guid-4")
+
+		// Assert
+		assert.Equal(t, []string{"page-guid-4"}, guids(page))
+		assert.Equal(t, 1, page.Total)
+		assert.Nil(t, page.NextCursor)
+		assert.Nil(t, page.PrevCursor)
+	})
+
+	t.Run("It returns 400 error when the cursor is invalid", func(t *testing.T) {
+		// Arrange
+		req := httptest.NewRequest(http.MethodGet, "/items?cursor=not-a-cursor", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid cursor parameter")
+	})
+}
@@ -0,0 +1,454 @@
+package feature
+
+import (
+	"context"
+	"encoding/json"
+	"go-test/backend/domain/approval"
+	"go-test/backend/domain/dto"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"go-test/backend/repository"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createPendingPayload is createValidCreatePayload for a PENDING item, which may need approval
+func createPendingPayload(amount string) string {
+	payload := strings.Replace(createValidCreatePayload(), `"status": "ACCEPTED"`, `"status": "PENDING"`, 1)
+	return strings.Replace(payload, `"amount": 100`, `"amount": `+amount, 1)
+}
+
+func TestMakerChecker(t *testing.T) {
+	r, _ := tests.SetupAuthRouter()
+
+	maker := tests.MintToken("maker", "operator", "approver")
+	checker := tests.MintToken("checker", "approver")
+	secondChecker := tests.MintToken("second-checker", "approver")
+	changer := tests.MintToken("changer", "operator", "approver")
+
+	send := func(method, path, body, token string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) models.Item {
+		var item models.Item
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+		return item
+	}
+	create := func(t *testing.T, amount string) models.Item {
+		w := send(http.MethodPost, "/items", createPendingPayload(amount), maker)
+		require.Equal(t, http.StatusCreated, w.Code)
+		return decode(t, w)
+	}
+
+	t.Run("It holds new items for approval, recording who created them", func(t *testing.T) {
+		// Arrange
+		payload := strings.Replace(createPendingPayload("100"), `"status": "PENDING",`, ``, 1)
+
+		// Act
+		w := send(http.MethodPost, "/items", payload, maker)
+
+		// Assert
+		require.Equal(t, http.StatusCreated, w.Code)
+		item := decode(t, w)
+		assert.Equal(t, enums.PENDING_APPROVAL, item.Status)
+		assert.Equal(t, "maker", item.CreatedBy)
+		assert.Equal(t, 1, item.RequiredApprovals)
+		assert.Empty(t, item.Approvals)
+	})
+
+	t.Run("It refuses to create an item needing approval in a status approval would skip", func(t *testing.T) {
+		// Act
+		w := send(http.MethodPost, "/items", createValidCreatePayload(), maker)
+
+		// Assert
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.JSONEq(t, `{"error": "Items needing approval must be created PENDING, not ACCEPTED"}`, w.Body.String())
+	})
+
+	t.Run("It refuses approval by the item's creator", func(t *testing.T) {
+		// Arrange
+		item := create(t, "100")
+
+		// Act
+		w := send(http.MethodPost, "/items/"+item.GUID+"/approve", "", maker)
+
+		// Assert
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.JSONEq(t, `{"error": "The creator of an item cannot approve it"}`, w.Body.String())
+		got := decode(t, send(http.MethodGet, "/items/"+item.GUID, "", checker))
+		assert.Equal(t, enums.PENDING_APPROVAL, got.Status)
+		assert.Empty(t, got.Approvals)
+	})
+
+	t.Run("It releases an item to PENDING once another approver approves it", func(t *testing.T) {
+		// Arrange
+		item := create(t, "100")
+
+		// Act
+		w := send(http.MethodPost, "/items/"+item.GUID+"/approve", `{"reason": "Invoice checked"}`, checker)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		approved := decode(t, w)
+		assert.Equal(t, enums.PENDING, approved.Status)
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+		require.Len(t, approved.Approvals, 1)
+		assert.Equal(t, "checker", approved.Approvals[0].Actor)
+		assert.Equal(t, enums.APPROVED, approved.Approvals[0].Decision)
+		assert.Equal(t, "Invoice checked", approved.Approvals[0].Reason)
+		require.Len(t, approved.Transitions, 1)
+		assert.Equal(t, models.StatusTransition{From: enums.PENDING_APPROVAL, To: enums.PENDING, Actor: "checker", Reason: "Invoice checked", At: approved.Transitions[0].At}, approved.Transitions[0])
+
+		got := decode(t, send(http.MethodGet, "/items/"+item.GUID, "", checker))
+		assert.Equal(t, approved.Approvals, got.Approvals)
+		assert.Equal(t, enums.PENDING, got.Status)
+	})
+
+	t.Run("It needs two different approvers for amounts above the threshold", func(t *testing.T) {
+		// Arrange
+		item := create(t, "10000.01")
+		require.Equal(t, 2, item.RequiredApprovals)
+
+		// Act
+		first := send(http.MethodPost, "/items/"+item.GUID+"/approve", "", checker)
+		again := send(http.MethodPost, "/items/"+item.GUID+"/approve", "", checker)
+		second := send(http.MethodPost, "/items/"+item.GUID+"/approve", "", secondChecker)
+
+		// Assert
+		require.Equal(t, http.StatusOK, first.Code)
+		assert.Equal(t, enums.PENDING_APPROVAL, decode(t, first).Status)
+		assert.Equal(t, http.StatusConflict, again.Code)
+		assert.JSONEq(t, `{"error": "Item has already been approved by checker"}`, again.Body.String())
+		require.Equal(t, http.StatusOK, second.Code)
+		released := decode(t, second)
+		assert.Equal(t, enums.PENDING, released.Status)
+		require.Len(t, released.Approvals, 2)
+		assert.Equal(t, "second-checker", released.Approvals[1].Actor)
+		assert.Equal(t, "Approved", released.Transitions[0].Reason)
+	})
+
+	t.Run("It needs one approver for amounts at the threshold", func(t *testing.T) {
+		// Act
+		item := create(t, "10000.00")
+
+		// Assert
+		assert.Equal(t, 1, item.RequiredApprovals)
+	})
+
+	t.Run("It declines a rejected item, which then cannot be approved", func(t *testing.T) {
+		// Arrange
+		item := create(t, "10000.01")
+		require.Equal(t, http.StatusOK, send(http.MethodPost, "/items/"+item.GUID+"/approve", "", checker).Code)
+
+		// Act
+		w := send(http.MethodPost, "/items/"+item.GUID+"/reject", `{"reason": "Duplicate of an earlier payment"}`, secondChecker)
+		approve := send(http.MethodPost, "/items/"+item.GUID+"/approve", "", secondChecker)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		rejected := decode(t, w)
+		assert.Equal(t, enums.DECLINED, rejected.Status)
+		require.Len(t, rejected.Approvals, 2)
+		assert.Equal(t, enums.REJECTED, rejected.Approvals[1].Decision)
+		assert.Equal(t, "Duplicate of an earlier payment", rejected.Approvals[1].Reason)
+		assert.Equal(t, "second-checker", rejected.Transitions[0].Actor)
+		assert.Equal(t, http.StatusConflict, approve.Code)
+		assert.JSONEq(t, `{"error": "Item is not awaiting approval"}`, approve.Body.String())
+	})
+
+	t.Run("It requires a reason to reject an item", func(t *testing.T) {
+		// Arrange
+		item := create(t, "100")
+
+		// Act
+		w := send(http.MethodPost, "/items/"+item.GUID+"/reject", `{}`, checker)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"errors": {"reason": "This field is required"}}`, w.Body.String())
+	})
+
+	t.Run("It lets the creator withdraw their own item by rejecting it", func(t *testing.T) {
+		// Arrange
+		item := create(t, "100")
+
+		// Act
+		w := send(http.MethodPost, "/items/"+item.GUID+"/reject", `{"reason": "Entered in error"}`, maker)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, enums.DECLINED, decode(t, w).Status)
+	})
+
+	t.Run("It leaves an item awaiting approval alone until it is approved or rejected", func(t *testing.T) {
+		// Arrange
+		item := create(t, "100")
+		reversal := strings.NewReplacer(`"type":   "ADMISSION"`, `"type": "REVERSAL", "original_guid": "`+item.GUID+`"`, `"John"`, `"Jane"`, `"Doe"`, `"Smith"`).Replace(createPendingPayload("50"))
+
+		// Act
+		transition := send(http.MethodPost, "/items/"+item.GUID+"/transitions", `{"status": "ACCEPTED", "reason": "Skip approval"}`, checker)
+		update := send(http.MethodPut, "/items/"+item.GUID, `{"amount": 5}`, maker)
+		reverse := send(http.MethodPost, "/items", reversal, maker)
+
+		// Assert
+		assert.Equal(t, http.StatusConflict, transition.Code)
+		assert.JSONEq(t, `{"error": "Cannot transition item from PENDING_APPROVAL to ACCEPTED"}`, transition.Body.String())
+		assert.Equal(t, http.StatusConflict, update.Code)
+		assert.JSONEq(t, `{"error": "Item is awaiting approval and cannot be changed"}`, update.Body.String())
+		assert.Equal(t, http.StatusUnprocessableEntity, reverse.Code)
+		assert.JSONEq(t, `{"error": "Original item is awaiting approval and cannot be reversed"}`, reverse.Body.String())
+	})
+
+	t.Run("It holds an approved item for approval again when its amount changes", func(t *testing.T) {
+		// Arrange
+		item := create(t, "100")
+		require.Equal(t, http.StatusOK, send(http.MethodPost, "/items/"+item.GUID+"/approve", "", checker).Code)
+
+		// Act
+		retyped := send(http.MethodPut, "/items/"+item.GUID, `{"type": "SUBMISSION"}`, maker)
+		raised := send(http.MethodPut, "/items/"+item.GUID, `{"amount": "5000000.00"}`, maker)
+		first := send(http.MethodPost, "/items/"+item.GUID+"/approve", "", checker)
+		second := send(http.MethodPost, "/items/"+item.GUID+"/approve", "", secondChecker)
+
+		// Assert
+		require.Equal(t, http.StatusOK, retyped.Code)
+		assert.Equal(t, enums.PENDING, decode(t, retyped).Status)
+		require.Equal(t, http.StatusOK, raised.Code, raised.Body.String())
+		held := decode(t, raised)
+		assert.Equal(t, enums.PENDING_APPROVAL, held.Status)
+		assert.Equal(t, 2, held.RequiredApprovals)
+		assert.Empty(t, held.Approvals)
+		last := held.Transitions[len(held.Transitions)-1]
+		assert.Equal(t, models.StatusTransition{From: enums.PENDING, To: enums.PENDING_APPROVAL, Actor: "maker", Reason: "Amount, currency or parties changed", At: last.At}, last)
+		require.Equal(t, http.StatusOK, first.Code)
+		assert.Equal(t, enums.PENDING_APPROVAL, decode(t, first).Status)
+		require.Equal(t, http.StatusOK, second.Code)
+		assert.Equal(t, enums.PENDING, decode(t, second).Status)
+	})
+
+	t.Run("It refuses approval by whoever changed the amount of an approved item", func(t *testing.T) {
+		// Arrange
+		item := create(t, "100")
+		require.Equal(t, http.StatusOK, send(http.MethodPost, "/items/"+item.GUID+"/approve", "", checker).Code)
+		raised := send(http.MethodPut, "/items/"+item.GUID, `{"amount": "150.00"}`, changer)
+		require.Equal(t, http.StatusOK, raised.Code, raised.Body.String())
+
+		// Act
+		own := send(http.MethodPost, "/items/"+item.GUID+"/approve", "", changer)
+		other := send(http.MethodPost, "/items/"+item.GUID+"/approve", "", checker)
+
+		// Assert
+		assert.Equal(t, "changer", decode(t, raised).ChangedBy)
+		assert.Equal(t, http.StatusForbidden, own.Code)
+		assert.JSONEq(t, `{"error": "Whoever changed the amount, currency or parties of an item cannot approve it"}`, own.Body.String())
+		require.Equal(t, http.StatusOK, other.Code)
+		approved := decode(t, other)
+		assert.Equal(t, enums.PENDING, approved.Status)
+		require.Len(t, approved.Approvals, 1)
+		assert.Equal(t, "checker", approved.Approvals[0].Actor)
+	})
+
+	t.Run("It refuses to change what was approved once the item has been processed", func(t *testing.T) {
+		// Arrange
+		item := create(t, "100")
+		require.Equal(t, http.StatusOK, send(http.MethodPost, "/items/"+item.GUID+"/approve", "", checker).Code)
+		require.Equal(t, http.StatusOK, send(http.MethodPost, "/items/"+item.GUID+"/transitions", `{"status": "ACCEPTED", "reason": "Checks passed"}`, checker).Code)
+
+		// Act
+		w := send(http.MethodPut, "/items/"+item.GUID, `{"amount": "5000000.00"}`, maker)
+
+		// Assert
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.JSONEq(t, `{"error": "Cannot change the amount, currency or parties of an item needing approval once it has left PENDING"}`, w.Body.String())
+		got := decode(t, send(http.MethodGet, "/items/"+item.GUID, "", checker))
+		assert.Equal(t, "100.00", got.Amount.String())
+		assert.Equal(t, enums.ACCEPTED, got.Status)
+	})
+
+	t.Run("It honours If-Match when approving", func(t *testing.T) {
+		// Arrange
+		item := create(t, "100")
+
+		// Act
+		w := send(http.MethodPost, "/items/"+item.GUID+"/approve", "", checker, "If-Match", `"7"`)
+
+		// Assert
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	})
+
+	t.Run("It returns 404 for an item that does not exist", func(t *testing.T) {
+		// Act
+		w := send(http.MethodPost, "/items/no-such-guid/approve", "", checker)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("It filters the items awaiting approval", func(t *testing.T) {
+		// Arrange
+		item := create(t, "100")
+
+		// Act
+		w := send(http.MethodGet, "/items?status=pending_approval&limit=0", "", checker)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		var response dto.ItemListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		guids := make([]string, 0, len(response.Data))
+		for _, listed := range response.Data {
+			assert.Equal(t, enums.PENDING_APPROVAL, listed.Status)
+			guids = append(guids, listed.GUID)
+		}
+		assert.Contains(t, guids, item.GUID)
+	})
+
+	t.Run("It holds each batch item needing approval, and refuses those that would skip it", func(t *testing.T) {
+		// Act
+		w := send(http.MethodPost, "/items:batch", "["+createPendingPayload("100")+","+createValidCreatePayload()+"]", maker)
+
+		// Assert
+		require.Equal(t, http.StatusMultiStatus, w.Code)
+		var response dto.ItemBatchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, enums.PENDING_APPROVAL, response.Results[0].Item.Status)
+		assert.Equal(t, http.StatusUnprocessableEntity, response.Results[1].Status)
+		assert.Equal(t, "Items needing approval must be created PENDING, not ACCEPTED", response.Results[1].Error)
+	})
+
+	t.Run("It lets only approvers and admins decide", func(t *testing.T) {
+		// Arrange
+		item := create(t, "100")
+		operator := tests.MintToken("operator", "operator")
+
+		// Act
+		approve := send(http.MethodPost, "/items/"+item.GUID+"/approve", "", operator)
+		reject := send(http.MethodPost, "/items/"+item.GUID+"/reject", `{"reason": "No"}`, operator)
+
+		// Assert
+		assert.Equal(t, http.StatusForbidden, approve.Code)
+		assert.Equal(t, http.StatusForbidden, reject.Code)
+	})
+}
+
+func TestMakerCheckerIsDurable(t *testing.T) {
+	ctx := repository.WithChange(context.Background(), repository.Change{Actor: "checker"})
+	newItem := func(guid string) *models.Item {
+		item := &models.Item{GUID: guid, Amount: models.NewMoney(2000000, models.DefaultCurrency), Type: enums.SUBMISSION, Status: enums.PENDING, CreatedBy: "maker", ChangedBy: "changer"}
+		item.AwaitApproval(2)
+		return item
+	}
+
+	for name, open := range map[string]func(t *testing.T, dir string) repository.ItemsStorage{
+		"sqlite": func(t *testing.T, dir string) repository.ItemsStorage {
+			s, err := repository.NewSQLiteStore(filepath.Join(dir, "items.db"))
+			require.NoError(t, err)
+			return s
+		},
+		"journal": func(t *testing.T, dir string) repository.ItemsStorage {
+			s, err := repository.NewDurableStore(dir, 0)
+			require.NoError(t, err)
+			return s
+		},
+	} {
+		t.Run("It keeps who created and changed an item and its approvals in the "+name+" store", func(t *testing.T) {
+			// Arrange
+			dir := t.TempDir()
+			s := open(t, dir)
+			item := newItem("durable-approval-guid")
+			require.NoError(t, s.Create(ctx, item))
+			require.NoError(t, item.Approve("checker", "Looks right", time.Now()))
+			require.NoError(t, s.Update(ctx, item))
+			require.NoError(t, s.(interface{ Close() error }).Close())
+
+			// Act
+			reopened := open(t, dir)
+			defer reopened.(interface{ Close() error }).Close()
+			got, err := reopened.GetByGUID(context.Background(), item.GUID)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, enums.PENDING_APPROVAL, got.Status)
+			assert.Equal(t, "maker", got.CreatedBy)
+			assert.Equal(t, "changer", got.ChangedBy)
+			assert.Equal(t, 2, got.RequiredApprovals)
+			require.Len(t, got.Approvals, 1)
+			assert.Equal(t, "checker", got.Approvals[0].Actor)
+			assert.Equal(t, enums.APPROVED, got.Approvals[0].Decision)
+			assert.Equal(t, "Looks right", got.Approvals[0].Reason)
+			assert.WithinDuration(t, item.Approvals[0].At, got.Approvals[0].At, time.Millisecond)
+		})
+	}
+}
+
+func TestApprovalRules(t *testing.T) {
+	gbp := func(amount string) models.Money {
+		money, err := models.ParseMoney(amount, "GBP")
+		require.NoError(t, err)
+		return money
+	}
+
+	t.Run("It reads rules in YAML or JSON and applies the strictest that matches", func(t *testing.T) {
+		for name, data := range map[string]string{
+			"YAML": "approvals: 1\nthresholds:\n  - currency: GBP\n    above: 10000\n    approvals: 2\n  - currency: GBP\n    above: 1000000\n    approvals: 3\n",
+			"JSON": `{"approvals": 1, "thresholds": [{"currency": "GBP", "above": "10000", "approvals": 2}, {"currency": "GBP", "above": "1000000", "approvals": 3}]}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				// Act
+				rules, err := approval.Parse([]byte(data))
+
+				// Assert
+				require.NoError(t, err)
+				assert.Equal(t, 1, rules.Required(gbp("10000")))
+				assert.Equal(t, 2, rules.Required(gbp("10000.01")))
+				assert.Equal(t, 3, rules.Required(gbp("2000000")))
+				assert.Equal(t, 1, rules.Required(models.NewMoney(5000000, "JPY")))
+			})
+		}
+	})
+
+	t.Run("It needs no approvals when the rules ask for none", func(t *testing.T) {
+		// Act
+		rules, err := approval.Parse([]byte(`approvals: 0`))
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, 0, rules.Required(gbp("1000000")))
+		assert.Equal(t, 0, approval.None().Required(gbp("1000000")))
+	})
+
+	t.Run("It refuses rules it cannot apply", func(t *testing.T) {
+		for name, data := range map[string]string{
+			"not YAML":           `approvals: [`,
+			"negative approvals": `approvals: -1`,
+			"unknown currency":   `{"thresholds": [{"currency": "XYZ", "above": "10", "approvals": 2}]}`,
+			"bad amount":         `{"thresholds": [{"currency": "GBP", "above": "10.001", "approvals": 2}]}`,
+			"no approvals":       `{"thresholds": [{"currency": "GBP", "above": "10", "approvals": 0}]}`,
+			"unknown field":      `{"approvals": 1, "approvers": 2}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				// Act
+				_, err := approval.Parse([]byte(data))
+
+				// Assert
+				assert.ErrorIs(t, err, approval.ErrInvalidRules)
+			})
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package feature
+
+import (
+	"context"
+	"encoding/json"
+	"go-test/backend/domain/dto"
+	"go-test/backend/domain/models"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemMoneyAmounts(t *testing.T) {
+	r, s := tests.SetupReadRouter()
+
+	create := func(amount string) *httptest.ResponseRecorder {
+		payload := strings.Replace(createValidCreatePayload(), `"amount": 100`, `"amount": `+amount, 1)
+		req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("It stores amounts exactly in minor units and emits decimal strings", func(t *testing.T) {
+		// Act
+		fromString := create(`"0.10"`)
+		fromNumber := create(`0.2`)
+
+		// Assert
+		require.Equal(t, http.StatusCreated, fromString.Code, fromString.Body.String())
+		require.Equal(t, http.StatusCreated, fromNumber.Code, fromNumber.Body.String())
+		assert.Contains(t, fromString.Body.String(), `"amount":"0.10"`)
+		assert.Contains(t, fromNumber.Body.String(), `"amount":"0.20"`)
+
+		var a, b models.Item
+		require.NoError(t, json.Unmarshal(fromString.Body.Bytes(), &a))
+		require.NoError(t, json.Unmarshal(fromNumber.Body.Bytes(), &b))
+		stored, err := s.GetByGUID(context.Background(), a.GUID)
+		require.NoError(t, err)
+		assert.Equal(t, models.NewMoney(10, "GBP"), stored.Amount)
+		assert.Equal(t, "0.30", models.NewMoney(a.Amount.Minor+b.Amount.Minor, "GBP").String())
+	})
+
+	t.Run("It returns 400 error when amount has more than 2 decimal places", func(t *testing.T) {
+		for _, amount := range []string{`"10.001"`, `10.005`} {
+			// Act
+			w := create(amount)
+
+			// Assert
+			assert.Equal(t, http.StatusBadRequest, w.Code, amount)
+			assert.Contains(t, w.Body.String(), `"amount":"Must be a decimal amount with no more decimal places than its currency allows"`, amount)
+		}
+	})
+
+	t.Run("It returns 400 error when amount is not a decimal", func(t *testing.T) {
+		for _, amount := range []string{`"abc"`, `"1e3"`, `true`, `"12,50"`} {
+			// Act
+			w := create(amount)
+
+			// Assert
+			assert.Equal(t, http.StatusBadRequest, w.Code, amount)
+			assert.Contains(t, w.Body.String(), `"amount":"Must be a decimal amount`, amount)
+		}
+	})
+
+	t.Run("It updates and filters on exact amounts", func(t *testing.T) {
+		// Arrange
+		w := create(`"5.00"`)
+		require.Equal(t, http.StatusCreated, w.Code)
+		var item models.Item
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+
+		req := httptest.NewRequest(http.MethodPut, "/items/"+item.GUID, strings.NewReader(`{"amount": "7.35"}`))
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		// Act
+		req = httptest.NewRequest(http.MethodGet, "/items?limit=0&amount_min=7.35&amount_max=7.35", nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page dto.ItemListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		require.Len(t, page.Data, 1)
+		assert.Equal(t, item.GUID, page.Data[0].GUID)
+		assert.Equal(t, "7.35", page.Data[0].Amount.String())
+	})
+
+	t.Run("It returns 400 error when an amount filter has more than 2 decimal places", func(t *testing.T) {
+		// Arrange
+		req := httptest.NewRequest(http.MethodGet, "/items?amount_min=1.005", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"amountmin":"Must be a decimal amount with no more decimal places than its currency allows"`)
+	})
+
+	t.Run("It refuses a stored numeric amount that does not fit in minor units", func(t *testing.T) {
+		for _, amount := range []string{`1e30`, `-92233720368547758.09`} {
+			t.Run(amount, func(t *testing.T) {
+				// Arrange
+				var item models.Item
+
+				// Act
+				err := json.Unmarshal([]byte(`{"guid": "out-of-range-guid", "amount": `+amount+`, "currency": "GBP"}`), &item)
+
+				// Assert
+				assert.ErrorIs(t, err, models.ErrInvalidAmount)
+			})
+		}
+	})
+}
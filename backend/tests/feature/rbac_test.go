@@ -0,0 +1,216 @@
+package feature
+
+import (
+	"context"
+	"go-test/backend/auth"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleBasedAccessControl(t *testing.T) {
+	r, store := tests.SetupAuthRouter()
+
+	send := func(method, path string, roles ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+tests.MintToken("alice", roles...))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	roles := []string{"viewer", "operator", "approver", "admin"}
+	routes := []struct {
+		method, path, permission string
+		// allowed lists the roles the default policy lets call the route
+		allowed []string
+	}{
+		{http.MethodGet, "/items", "items.list", roles},
+		{http.MethodGet, "/items/export", "items.export", roles},
+		{http.MethodGet, "/items/rbac-guid", "items.get", roles},
+		{http.MethodGet, "/items/rbac-guid/reversals", "items.reversals", roles},
+		{http.MethodGet, "/items/rbac-guid/history", "items.history", roles},
+		{http.MethodPost, "/items", "items.create", []string{"operator", "admin"}},
+		{http.MethodPost, "/items:batch", "items.batch", []string{"operator", "admin"}},
+		{http.MethodPost, "/items/import", "items.import", []string{"operator", "admin"}},
+		{http.MethodPut, "/items/rbac-guid", "items.update", []string{"operator", "admin"}},
+		{http.MethodPost, "/items:bacs", "items.bacs", []string{"operator", "admin"}},
+		{http.MethodPost, "/items:pain001", "items.pain001", []string{"operator", "admin"}},
+		{http.MethodPost, "/items/rbac-guid/transitions", "items.transition", []string{"approver", "admin"}},
+		{http.MethodPost, "/items:pacs002", "items.pacs002", []string{"approver", "admin"}},
+		{http.MethodPost, "/items/rbac-guid/approve", "items.approve", []string{"approver", "admin"}},
+		{http.MethodPost, "/items/rbac-guid/reject", "items.reject", []string{"approver", "admin"}},
+		{http.MethodDelete, "/items/rbac-guid", "items.delete", []string{"admin"}},
+		{http.MethodPost, "/items/rbac-guid/restore", "items.restore", []string{"admin"}},
+		{http.MethodPost, "/admin/items/purge", "items.purge", []string{"admin"}},
+	}
+
+	for _, route := range routes {
+		for _, role := range roles {
+			name := route.method + " " + route.path + " as " + role
+			if slices.Contains(route.allowed, role) {
+				t.Run(name+" is allowed", func(t *testing.T) {
+					// Act
+					w := send(route.method, route.path, role)
+
+					// Assert
+					assert.NotEqual(t, http.StatusForbidden, w.Code)
+					assert.NotEqual(t, http.StatusUnauthorized, w.Code)
+				})
+			} else {
+				t.Run(name+" is forbidden", func(t *testing.T) {
+					// Act
+					w := send(route.method, route.path, role)
+
+					// Assert
+					assert.Equal(t, http.StatusForbidden, w.Code)
+					assert.JSONEq(t, `{"error": "Requires the `+route.permission+` permission"}`, w.Body.String())
+				})
+			}
+		}
+	}
+
+	t.Run("It forbids every route to a token without roles, or with roles the policy does not know", func(t *testing.T) {
+		for _, route := range routes {
+			// Act
+			none := send(route.method, route.path)
+			unknown := send(route.method, route.path, "superuser")
+
+			// Assert
+			assert.Equal(t, http.StatusForbidden, none.Code, route.path)
+			assert.Equal(t, http.StatusForbidden, unknown.Code, route.path)
+		}
+	})
+
+	t.Run("It allows what any of a token's roles grants", func(t *testing.T) {
+		// Act
+		w := send(http.MethodPost, "/items/rbac-guid/transitions", "viewer", "approver")
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.JSONEq(t, `{"error": "Item not found"}`, w.Body.String())
+	})
+
+	t.Run("It returns 404 for collection methods that do not exist, whatever the caller's roles", func(t *testing.T) {
+		for _, method := range []string{"frobnicate", "purge"} {
+			for _, role := range roles {
+				// Act
+				w := send(http.MethodPost, "/items:"+method, role)
+
+				// Assert
+				assert.Equal(t, http.StatusNotFound, w.Code, method+" as "+role)
+				assert.JSONEq(t, `{"error": "Method not found"}`, w.Body.String(), method+" as "+role)
+			}
+		}
+	})
+
+	t.Run("It requires the transition permission to change the status with an update", func(t *testing.T) {
+		// Arrange
+		item := &models.Item{GUID: "rbac-update-guid", Amount: models.NewMoney(10000, models.DefaultCurrency), Type: enums.ADMISSION, Status: enums.PENDING}
+		require.NoError(t, store.Create(context.Background(), item))
+		update := func(body string, roles ...string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPut, "/items/"+item.GUID, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+tests.MintToken("alice", roles...))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			return w
+		}
+
+		// Act
+		transition := update(`{"status": "ACCEPTED"}`, "operator")
+		unchanged := update(`{"status": "PENDING", "type": "SUBMISSION"}`, "operator")
+		stored, err := store.GetByGUID(context.Background(), item.GUID)
+		require.NoError(t, err)
+		allowed := update(`{"status": "ACCEPTED"}`, "operator", "approver")
+
+		// Assert
+		assert.Equal(t, http.StatusForbidden, transition.Code)
+		assert.JSONEq(t, `{"error": "Requires the items.transition permission"}`, transition.Body.String())
+		assert.Equal(t, http.StatusOK, unchanged.Code, unchanged.Body.String())
+		assert.Equal(t, enums.PENDING, stored.Status)
+		assert.Equal(t, enums.SUBMISSION, stored.Type)
+		assert.Equal(t, http.StatusOK, allowed.Code, allowed.Body.String())
+		assert.Contains(t, allowed.Body.String(), `"status":"ACCEPTED"`)
+	})
+
+	t.Run("It authenticates before it authorizes", func(t *testing.T) {
+		// Arrange
+		req := httptest.NewRequest(http.MethodDelete, "/items/rbac-guid", nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestAccessPolicy(t *testing.T) {
+	t.Run("It reads a policy file in YAML or JSON", func(t *testing.T) {
+		for name, policy := range map[string]string{
+			"policy.yaml": "roles:\n  auditor:\n    - items.history\n",
+			"policy.json": `{"roles": {"auditor": ["items.history"]}}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				// Arrange
+				path := filepath.Join(t.TempDir(), name)
+				require.NoError(t, os.WriteFile(path, []byte(policy), 0o600))
+
+				// Act
+				loaded, err := auth.LoadPolicy(path)
+
+				// Assert
+				require.NoError(t, err)
+				assert.True(t, loaded.Allows([]string{"auditor"}, "items.history"))
+				assert.False(t, loaded.Allows([]string{"auditor"}, "items.list"))
+				assert.False(t, loaded.Allows([]string{"viewer"}, "items.history"))
+			})
+		}
+	})
+
+	t.Run("It refuses policies it cannot enforce", func(t *testing.T) {
+		for name, policy := range map[string]string{
+			"not YAML":           `roles: [`,
+			"no roles":           `roles: {}`,
+			"unknown permission": `{"roles": {"viewer": ["items.lsit"]}}`,
+			"unknown field":      `{"roles": {"viewer": ["items.list"]}, "routes": {}}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				// Act
+				_, err := auth.ParsePolicy([]byte(policy))
+
+				// Assert
+				assert.ErrorIs(t, err, auth.ErrInvalidPolicy)
+			})
+		}
+	})
+
+	t.Run("It grants every permission to a role holding *", func(t *testing.T) {
+		// Arrange
+		policy := auth.DefaultPolicy()
+
+		// Act
+		var refused []string
+		for _, permission := range auth.Permissions {
+			if !policy.Allows([]string{"admin"}, permission) {
+				refused = append(refused, permission)
+			}
+		}
+
+		// Assert
+		assert.Empty(t, refused)
+	})
+}
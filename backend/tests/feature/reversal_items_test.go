@@ -0,0 +1,212 @@
+package feature
+
+import (
+	"context"
+	"encoding/json"
+	"go-test/backend/domain/dto"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemReversals(t *testing.T) {
+	r, s := tests.SetupReadRouter()
+
+	john := models.Party{FirstName: "John", LastName: "Doe", Account: models.Account{SortCode: "12-34-56", AccountNumber: "12345678"}}
+	jane := models.Party{FirstName: "Jane", LastName: "Smith", Account: models.Account{SortCode: "87-65-43", AccountNumber: "87654321"}}
+
+	newOriginal := func(t *testing.T, guid string, status enums.ItemStatus) *models.Item {
+		item := &models.Item{
+			GUID:       guid,
+			Amount:     models.NewMoney(10000, models.DefaultCurrency),
+			Type:       enums.ADMISSION,
+			Status:     status,
+			Attributes: models.Attributes{Debtor: john, Beneficiary: jane},
+		}
+		require.NoError(t, s.Create(context.Background(), item))
+		return item
+	}
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	// reversalPayload reverses an original paid by John to Jane, so the parties are swapped
+	reversalPayload := func(originalGUID, amount string) string {
+		return `{
+			"amount": "` + amount + `",
+			"type": "REVERSAL",
+			"original_guid": "` + originalGUID + `",
+			"attributes": {
+				"debtor": {"first_name": "Jane", "last_name": "Smith", "account": {"sort_code": "87-65-43", "account_number": "87654321"}},
+				"beneficiary": {"first_name": "John", "last_name": "Doe", "account": {"sort_code": "12-34-56", "account_number": "12345678"}}
+			}
+		}`
+	}
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) models.Item {
+		var item models.Item
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+		return item
+	}
+
+	t.Run("It links a reversal to its original and nets it off", func(t *testing.T) {
+		// Arrange
+		original := newOriginal(t, "reversal-original-1", enums.ACCEPTED)
+
+		// Act
+		created := send(http.MethodPost, "/items", reversalPayload(original.GUID, "40.00"))
+		get := send(http.MethodGet, "/items/"+original.GUID, "")
+		list := send(http.MethodGet, "/items/"+original.GUID+"/reversals", "")
+
+		// Assert
+		require.Equal(t, http.StatusCreated, created.Code)
+		reversal := decode(t, created)
+		assert.Equal(t, original.GUID, reversal.OriginalGUID)
+		assert.Nil(t, reversal.NetAmount)
+		assert.NotContains(t, created.Body.String(), "net_amount")
+
+		assert.Equal(t, http.StatusOK, get.Code)
+		assert.Contains(t, get.Body.String(), `"net_amount":"60.00"`)
+
+		assert.Equal(t, http.StatusOK, list.Code)
+		var body dto.ItemListResponse
+		require.NoError(t, json.Unmarshal(list.Body.Bytes(), &body))
+		require.Len(t, body.Data, 1)
+		assert.Equal(t, reversal.GUID, body.Data[0].GUID)
+		assert.Equal(t, 1, body.Total)
+		assert.Equal(t, "40.00", body.Totals[0].Amount.String())
+	})
+
+	t.Run("It reports the net amount of items without reversals as their amount", func(t *testing.T) {
+		// Arrange
+		original := newOriginal(t, "reversal-original-2", enums.ACCEPTED)
+
+		// Act
+		w := send(http.MethodGet, "/items?query="+original.GUID, "")
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"net_amount":"100.00"`)
+	})
+
+	t.Run("It requires original_guid on reversals and only on reversals", func(t *testing.T) {
+		// Arrange
+		withoutOriginal := strings.Replace(reversalPayload("", "10.00"), `"original_guid": "",`, "", 1)
+		onAdmission := strings.Replace(createValidCreatePayload(), `"type":   "ADMISSION",`, `"type": "ADMISSION", "original_guid": "reversal-original-1",`, 1)
+
+		// Act
+		missing := send(http.MethodPost, "/items", withoutOriginal)
+		unexpected := send(http.MethodPost, "/items", onAdmission)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, missing.Code)
+		assert.Contains(t, missing.Body.String(), `"originalguid":"This field is required"`)
+		assert.Equal(t, http.StatusBadRequest, unexpected.Code)
+		assert.Contains(t, unexpected.Body.String(), `"originalguid":"Only REVERSAL items may reference an original item"`)
+	})
+
+	t.Run("It rejects reversals that do not mirror a reversible original", func(t *testing.T) {
+		// Arrange
+		original := newOriginal(t, "reversal-original-3", enums.ACCEPTED)
+		declined := newOriginal(t, "reversal-original-4", enums.DECLINED)
+		reversal := decode(t, send(http.MethodPost, "/items", reversalPayload(original.GUID, "10.00")))
+
+		cases := []struct {
+			name    string
+			payload string
+			message string
+		}{
+			{"missing original", reversalPayload("nonexistent-guid", "10.00"), "Original item not found"},
+			{"reversal of a reversal", reversalPayload(reversal.GUID, "10.00"), "A reversal cannot be reversed"},
+			{"declined original", reversalPayload(declined.GUID, "10.00"), "Original item was declined or returned"},
+			{"unmirrored parties", strings.Replace(reversalPayload(original.GUID, "10.00"), `"first_name": "Jane"`, `"first_name": "Janet"`, 1), "must be the original item's beneficiary and debtor"},
+			{"different currency", strings.Replace(reversalPayload(original.GUID, "10.00"), `"type"`, `"currency": "EUR", "type"`, 1), "Reversal currency must match the original item"},
+			{"more than remains", reversalPayload(original.GUID, "90.01"), "Reversal amount exceeds the unreversed 90.00 GBP of the original item"},
+		}
+
+		for _, tc := range cases {
+			// Act
+			w := send(http.MethodPost, "/items", tc.payload)
+
+			// Assert
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, tc.name)
+			assert.Contains(t, w.Body.String(), tc.message, tc.name)
+		}
+	})
+
+	t.Run("It returns 409 once the original is fully reversed", func(t *testing.T) {
+		// Arrange
+		original := newOriginal(t, "reversal-original-5", enums.ACCEPTED)
+		require.Equal(t, http.StatusCreated, send(http.MethodPost, "/items", reversalPayload(original.GUID, "100.00")).Code)
+
+		// Act
+		w := send(http.MethodPost, "/items", reversalPayload(original.GUID, "0.01"))
+
+		// Assert
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "Original item has already been fully reversed")
+	})
+
+	t.Run("It stops counting reversals that are declined", func(t *testing.T) {
+		// Arrange
+		original := newOriginal(t, "reversal-original-6", enums.ACCEPTED)
+		reversal := decode(t, send(http.MethodPost, "/items", reversalPayload(original.GUID, "100.00")))
+
+		// Act
+		declined := send(http.MethodPost, "/items/"+reversal.GUID+"/transitions", `{"status": "DECLINED", "reason": "Rejected by the bank"}`)
+		get := send(http.MethodGet, "/items/"+original.GUID, "")
+		again := send(http.MethodPost, "/items", reversalPayload(original.GUID, "100.00"))
+
+		// Assert
+		assert.Equal(t, http.StatusOK, declined.Code)
+		assert.Contains(t, get.Body.String(), `"net_amount":"100.00"`)
+		assert.Equal(t, http.StatusCreated, again.Code)
+	})
+
+	t.Run("It keeps reversals consistent with their original on update and delete", func(t *testing.T) {
+		// Arrange
+		original := newOriginal(t, "reversal-original-7", enums.ACCEPTED)
+		unreversed := newOriginal(t, "reversal-original-8", enums.ACCEPTED)
+		reversal := decode(t, send(http.MethodPost, "/items", reversalPayload(original.GUID, "60.00")))
+
+		// Act
+		retype := send(http.MethodPut, "/items/"+unreversed.GUID, `{"type": "REVERSAL"}`)
+		untype := send(http.MethodPut, "/items/"+reversal.GUID, `{"type": "ADMISSION"}`)
+		reprice := send(http.MethodPut, "/items/"+original.GUID, `{"amount": "50.00"}`)
+		overReverse := send(http.MethodPut, "/items/"+reversal.GUID, `{"amount": "100.01"}`)
+		reduce := send(http.MethodPut, "/items/"+reversal.GUID, `{"amount": "100.00"}`)
+		deleted := send(http.MethodDelete, "/items/"+original.GUID, "")
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, retype.Code)
+		assert.Contains(t, retype.Body.String(), "Items cannot be changed to or from REVERSAL")
+		assert.Equal(t, http.StatusBadRequest, untype.Code)
+		assert.Equal(t, http.StatusConflict, reprice.Code)
+		assert.Contains(t, reprice.Body.String(), "Cannot change the amount, currency or parties of an item that has been reversed")
+		assert.Equal(t, http.StatusUnprocessableEntity, overReverse.Code)
+		assert.Contains(t, overReverse.Body.String(), "unreversed 100.00 GBP")
+		assert.Equal(t, http.StatusOK, reduce.Code)
+		assert.Equal(t, http.StatusConflict, deleted.Code)
+		assert.Contains(t, deleted.Body.String(), "Cannot delete an item that has reversals")
+
+		stored, err := s.GetByGUID(context.Background(), original.GUID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), stored.NetAmount.Minor)
+	})
+
+	t.Run("It returns 404 listing the reversals of a missing item", func(t *testing.T) {
+		// Act
+		w := send(http.MethodGet, "/items/nonexistent-guid/reversals", "")
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,283 @@
+package feature
+
+import (
+	"context"
+	"encoding/json"
+	"go-test/backend/domain/dto"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"go-test/backend/repository"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoftDelete(t *testing.T) {
+	r, s := tests.SetupReadRouter()
+
+	john := models.Party{FirstName: "John", LastName: "Doe", Account: models.Account{SortCode: "12-34-56", AccountNumber: "12345678"}}
+	jane := models.Party{FirstName: "Jane", LastName: "Smith", Account: models.Account{SortCode: "87-65-43", AccountNumber: "87654321"}}
+
+	newItem := func(t *testing.T, guid string) *models.Item {
+		item := &models.Item{
+			GUID:       guid,
+			Amount:     models.NewMoney(10000, models.DefaultCurrency),
+			Type:       enums.ADMISSION,
+			Status:     enums.ACCEPTED,
+			Attributes: models.Attributes{Debtor: john, Beneficiary: jane},
+		}
+		require.NoError(t, s.Create(context.Background(), item))
+		return item
+	}
+	newReversal := func(t *testing.T, guid, originalGUID string, minor int64) *models.Item {
+		item := &models.Item{
+			GUID:         guid,
+			Amount:       models.NewMoney(minor, models.DefaultCurrency),
+			Type:         enums.REVERSAL,
+			Status:       enums.PENDING,
+			OriginalGUID: originalGUID,
+			Attributes:   models.Attributes{Debtor: jane, Beneficiary: john},
+		}
+		require.NoError(t, s.Create(context.Background(), item))
+		return item
+	}
+	send := func(method, path, ifMatch, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	listed := func(t *testing.T, query string) []string {
+		w := send(http.MethodGet, "/items?limit=0&"+query, "", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var body dto.ItemListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		guids := make([]string, 0, len(body.Data))
+		for _, item := range body.Data {
+			guids = append(guids, item.GUID)
+		}
+		return guids
+	}
+	// deleteAt soft deletes an item as if it had been deleted at the given time
+	deleteAt := func(t *testing.T, guid string, at time.Time) {
+		ctx := repository.WithChange(context.Background(), repository.Change{Actor: "alice", At: at})
+		require.NoError(t, s.Delete(ctx, guid, 0))
+	}
+
+	t.Run("It hides deleted items unless include_deleted=true", func(t *testing.T) {
+		// Arrange
+		kept := newItem(t, "soft-kept-guid")
+		deleted := newItem(t, "soft-deleted-guid")
+
+		// Act
+		w := send(http.MethodDelete, "/items/"+deleted.GUID, `"1"`, "")
+		get := send(http.MethodGet, "/items/"+deleted.GUID, "", "")
+		getDeleted := send(http.MethodGet, "/items/"+deleted.GUID+"?include_deleted=true", "", "")
+
+		// Assert
+		require.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, http.StatusNotFound, get.Code)
+		require.Equal(t, http.StatusOK, getDeleted.Code)
+		assert.Equal(t, `"2"`, getDeleted.Header().Get("ETag"))
+
+		var item models.Item
+		require.NoError(t, json.Unmarshal(getDeleted.Body.Bytes(), &item))
+		require.NotNil(t, item.DeletedAt)
+		assert.WithinDuration(t, time.Now(), *item.DeletedAt, time.Minute)
+
+		assert.Contains(t, listed(t, "query=soft-"), kept.GUID)
+		assert.NotContains(t, listed(t, "query=soft-"), deleted.GUID)
+		assert.Equal(t, []string{kept.GUID, deleted.GUID}, listed(t, "query=soft-&include_deleted=true"))
+
+		count, err := s.Count(context.Background())
+		require.NoError(t, err)
+		all, err := s.GetAll(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, all, count)
+	})
+
+	t.Run("It rejects an include_deleted value that is not a boolean", func(t *testing.T) {
+		// Act
+		list := send(http.MethodGet, "/items?include_deleted=maybe", "", "")
+		get := send(http.MethodGet, "/items/soft-kept-guid?include_deleted=maybe", "", "")
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, list.Code)
+		assert.JSONEq(t, `{"errors": {"includedeleted": "Must be true or false"}}`, list.Body.String())
+		assert.Equal(t, http.StatusBadRequest, get.Code)
+		assert.Contains(t, get.Body.String(), "invalid include_deleted parameter")
+	})
+
+	t.Run("It refuses to change a deleted item", func(t *testing.T) {
+		// Arrange
+		item := newItem(t, "soft-frozen-guid")
+		deleteAt(t, item.GUID, time.Now())
+
+		// Act
+		update := send(http.MethodPut, "/items/"+item.GUID, "", createUpdatePayload())
+		transition := send(http.MethodPost, "/items/"+item.GUID+"/transitions", "", `{"status": "SETTLED", "reason": "Settled"}`)
+		deleteAgain := send(http.MethodDelete, "/items/"+item.GUID, "", "")
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, update.Code)
+		assert.Equal(t, http.StatusNotFound, transition.Code)
+		assert.Equal(t, http.StatusNotFound, deleteAgain.Code)
+	})
+
+	t.Run("It restores a deleted item", func(t *testing.T) {
+		// Arrange
+		item := newItem(t, "soft-restored-guid")
+		require.Equal(t, http.StatusNoContent, send(http.MethodDelete, "/items/"+item.GUID, "", "").Code)
+
+		// Act
+		stale := send(http.MethodPost, "/items/"+item.GUID+"/restore", `"1"`, "")
+		restored := send(http.MethodPost, "/items/"+item.GUID+"/restore", `"2"`, "")
+		again := send(http.MethodPost, "/items/"+item.GUID+"/restore", "", "")
+		unknown := send(http.MethodPost, "/items/no-such-guid/restore", "", "")
+
+		// Assert
+		assert.Equal(t, http.StatusPreconditionFailed, stale.Code)
+		require.Equal(t, http.StatusOK, restored.Code)
+		assert.Equal(t, `"3"`, restored.Header().Get("ETag"))
+		assert.NotContains(t, restored.Body.String(), "deleted_at")
+		assert.Equal(t, http.StatusConflict, again.Code)
+		assert.Contains(t, again.Body.String(), "Item is not deleted")
+		assert.Equal(t, http.StatusNotFound, unknown.Code)
+
+		assert.Equal(t, http.StatusOK, send(http.MethodGet, "/items/"+item.GUID, "", "").Code)
+		entries, err := s.History(context.Background(), item.GUID)
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		assert.Equal(t, enums.AuditDelete, entries[1].Action)
+		assert.Equal(t, enums.AuditRestore, entries[2].Action)
+	})
+
+	t.Run("It stops counting a deleted reversal against its original, and rechecks it on restore", func(t *testing.T) {
+		// Arrange
+		original := newItem(t, "soft-original-guid")
+		reversal := newReversal(t, "soft-reversal-guid", original.GUID, 6000)
+		require.Equal(t, http.StatusConflict, send(http.MethodDelete, "/items/"+original.GUID, "", "").Code)
+
+		// Act
+		deleted := send(http.MethodDelete, "/items/"+reversal.GUID, "", "")
+		get := send(http.MethodGet, "/items/"+original.GUID, "", "")
+		newReversal(t, "soft-reversal-guid-2", original.GUID, 5000)
+		restore := send(http.MethodPost, "/items/"+reversal.GUID+"/restore", "", "")
+
+		// Assert
+		require.Equal(t, http.StatusNoContent, deleted.Code)
+		assert.Contains(t, get.Body.String(), `"net_amount":"100.00"`)
+		assert.Equal(t, http.StatusUnprocessableEntity, restore.Code)
+		assert.Contains(t, restore.Body.String(), "Reversal amount exceeds the unreversed 50.00 GBP of the original item")
+	})
+
+	t.Run("It purges items deleted longer ago than the retention period", func(t *testing.T) {
+		// Arrange
+		old := newItem(t, "soft-purged-guid")
+		recent := newItem(t, "soft-retained-guid")
+		deleteAt(t, old.GUID, time.Now().AddDate(0, 0, -31))
+		deleteAt(t, recent.GUID, time.Now().AddDate(0, 0, -29))
+
+		// Act
+		w := send(http.MethodPost, "/admin/items/purge", "", `{"retention_days": 30}`)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		var body dto.ItemPurgeResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, 1, body.Purged)
+		assert.Equal(t, []string{old.GUID}, body.GUIDs)
+		assert.WithinDuration(t, time.Now().AddDate(0, 0, -30), body.DeletedBefore, time.Minute)
+
+		assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/items/"+old.GUID+"?include_deleted=true", "", "").Code)
+		assert.Equal(t, http.StatusNotFound, send(http.MethodPost, "/items/"+old.GUID+"/restore", "", "").Code)
+		assert.Equal(t, http.StatusOK, send(http.MethodGet, "/items/"+recent.GUID+"?include_deleted=true", "", "").Code)
+
+		entries, err := s.History(context.Background(), old.GUID)
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		purge := entries[2]
+		assert.Equal(t, enums.AuditPurge, purge.Action)
+		firstName, ok := changeOf(purge, "attributes.debtor.first_name")
+		require.True(t, ok)
+		assert.JSONEq(t, `"John"`, string(firstName.Before))
+		assert.JSONEq(t, `null`, string(firstName.After))
+	})
+
+	t.Run("It purges every deleted item with a retention period of zero", func(t *testing.T) {
+		// Act
+		w := send(http.MethodPost, "/admin/items/purge", "", `{"retention_days": 0}`)
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, listed(t, "include_deleted=true&query=soft-retained"))
+		assert.Equal(t, listed(t, ""), listed(t, "include_deleted=true"))
+	})
+
+	t.Run("It validates the retention period", func(t *testing.T) {
+		// Act
+		missing := send(http.MethodPost, "/admin/items/purge", "", `{}`)
+		negative := send(http.MethodPost, "/admin/items/purge", "", `{"retention_days": -1}`)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, missing.Code)
+		assert.JSONEq(t, `{"errors": {"retentiondays": "This field is required"}}`, missing.Body.String())
+		assert.Equal(t, http.StatusBadRequest, negative.Code)
+		assert.JSONEq(t, `{"errors": {"retentiondays": "Value is too small"}}`, negative.Body.String())
+	})
+}
+
+func TestSoftDeleteIsDurable(t *testing.T) {
+	ctx := context.Background()
+	newItem := func(guid string) *models.Item {
+		return &models.Item{GUID: guid, Amount: models.NewMoney(5000, models.DefaultCurrency), Type: enums.SUBMISSION, Status: enums.PENDING}
+	}
+
+	t.Run("It replays soft deletes, restores and purges from the journal", func(t *testing.T) {
+		// Arrange
+		dir := t.TempDir()
+		s, err := repository.NewDurableStore(dir, 0)
+		require.NoError(t, err)
+		for _, guid := range []string{"durable-deleted", "durable-restored", "durable-purged"} {
+			require.NoError(t, s.Create(ctx, newItem(guid)))
+			require.NoError(t, s.Delete(ctx, guid, 0))
+		}
+		_, err = s.Restore(ctx, "durable-restored", 2)
+		require.NoError(t, err)
+		purged, err := s.Purge(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, []string{"durable-deleted", "durable-purged"}, purged)
+		require.NoError(t, s.Create(ctx, newItem("durable-deleted-after")))
+		require.NoError(t, s.Delete(ctx, "durable-deleted-after", 0))
+		require.NoError(t, s.Close())
+
+		// Act
+		reopened, err := repository.NewDurableStore(dir, 0)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		// Assert
+		restored, err := reopened.GetByGUID(context.Background(), "durable-restored")
+		require.NoError(t, err)
+		assert.Equal(t, 3, restored.Version)
+		deleted, err := reopened.GetDeletedByGUID(context.Background(), "durable-deleted-after")
+		require.NoError(t, err)
+		assert.NotNil(t, deleted.DeletedAt)
+		_, err = reopened.GetDeletedByGUID(context.Background(), "durable-purged")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		entries, err := reopened.History(context.Background(), "durable-purged")
+		require.NoError(t, err)
+		assert.Len(t, entries, 3)
+	})
+}
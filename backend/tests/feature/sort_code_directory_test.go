@@ -0,0 +1,143 @@
+package feature
+
+import (
+	"context"
+	"encoding/json"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"go-test/backend/domain/sortcodes"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortCodeDirectory(t *testing.T) {
+	r, s := tests.SetupReadRouter()
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) models.Item {
+		var item models.Item
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+		return item
+	}
+
+	t.Run("It enriches accounts with their bank on create", func(t *testing.T) {
+		// Arrange
+		created := decode(t, send(http.MethodPost, "/items", createValidCreatePayload()))
+
+		// Act
+		w := send(http.MethodGet, "/items/"+created.GUID, "")
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"bank":{"name":"Example Bank plc","branch":"London City","schemes":["BACS","FPS","CHAPS"]}`)
+
+		item := decode(t, w)
+		require.NotNil(t, item.Attributes.Beneficiary.Account.Bank)
+		assert.Equal(t, "Manchester Piccadilly", item.Attributes.Beneficiary.Account.Bank.Branch)
+	})
+
+	t.Run("It replaces bank details sent by the client", func(t *testing.T) {
+		// Arrange
+		payload := strings.Replace(createValidCreatePayload(), `"account_number": "12345678"`, `"account_number": "12345678", "bank": {"name": "Made Up Bank", "branch": "Nowhere", "schemes": ["CHAPS"]}`, 1)
+
+		// Act
+		w := send(http.MethodPost, "/items", payload)
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.NotContains(t, w.Body.String(), "Made Up Bank")
+		assert.Contains(t, w.Body.String(), `"name":"Example Bank plc"`)
+	})
+
+	t.Run("It rejects sort codes that are not in the directory", func(t *testing.T) {
+		// Arrange
+		payload := strings.Replace(createValidCreatePayload(), `"sort_code": "87-65-43"`, `"sort_code": "99-99-99"`, 1)
+
+		// Act
+		w := send(http.MethodPost, "/items", payload)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"sortcode":"Sort code is not in the sort code directory"`)
+	})
+
+	t.Run("It re-enriches accounts on update", func(t *testing.T) {
+		// Arrange
+		created := decode(t, send(http.MethodPost, "/items", createValidCreatePayload()))
+		payload := `{"attributes": {
+			"debtor": {"first_name": "John", "last_name": "Doe", "account": {"sort_code": "12-34-56", "account_number": "12345678"}},
+			"beneficiary": {"first_name": "Jane", "last_name": "Smith", "account": {"sort_code": "08-60-90", "account_number": "06774744"}}
+		}}`
+
+		// Act
+		w := send(http.MethodPut, "/items/"+created.GUID, payload)
+		unknown := send(http.MethodPut, "/items/"+created.GUID, strings.Replace(payload, "08-60-90", "99-99-99", 1))
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+		stored, err := s.GetByGUID(context.Background(), created.GUID)
+		require.NoError(t, err)
+		require.NotNil(t, stored.Attributes.Beneficiary.Account.Bank)
+		assert.Equal(t, models.Bank{Name: "Sample Mutual", Branch: "Manchester", Schemes: []enums.PaymentScheme{enums.BACS}}, *stored.Attributes.Beneficiary.Account.Bank)
+
+		assert.Equal(t, http.StatusBadRequest, unknown.Code)
+		assert.Contains(t, unknown.Body.String(), "Sort code is not in the sort code directory")
+	})
+
+	t.Run("It looks accounts up in a directory loaded from a file", func(t *testing.T) {
+		// Arrange
+		path := filepath.Join(t.TempDir(), "eiscd.csv")
+		contents := "sort_code,bic,bank_name,branch_name,bacs,fps,chaps\n" +
+			"12-34-56,EXAMGB2L,Loaded Bank,Loaded Branch,M,N,\n" +
+			"87-65-43,EXAMGB2L,Loaded Bank,Other Branch,M,M,M\n"
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+
+		previous := sortcodes.Default()
+		defer sortcodes.SetDefault(previous)
+
+		// Act
+		directory, err := sortcodes.Load(path)
+		require.NoError(t, err)
+		sortcodes.SetDefault(directory)
+		w := send(http.MethodPost, "/items", createValidCreatePayload())
+
+		// Assert
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"bank":{"name":"Loaded Bank","branch":"Loaded Branch","schemes":["BACS"]}`)
+		_, known := directory.Lookup("08-99-99")
+		assert.False(t, known)
+	})
+
+	t.Run("It rejects malformed directories", func(t *testing.T) {
+		cases := []struct {
+			name     string
+			contents string
+			message  string
+		}{
+			{"missing column", "sort_code,bank_name,branch_name,bacs,fps\n", "missing chaps column"},
+			{"invalid sort code", "sort_code,bank_name,branch_name,bacs,fps,chaps\n12-34,Bank,Branch,M,M,M\n", "invalid sort code"},
+			{"duplicate sort code", "sort_code,bank_name,branch_name,bacs,fps,chaps\n123456,Bank,Branch,M,M,M\n12-34-56,Bank,Branch,M,M,M\n", "duplicate sort code"},
+		}
+
+		for _, tc := range cases {
+			// Act
+			_, err := sortcodes.Parse(strings.NewReader(tc.contents))
+
+			// Assert
+			assert.ErrorContains(t, err, tc.message, tc.name)
+		}
+	})
+}
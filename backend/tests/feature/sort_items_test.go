@@ -0,0 +1,213 @@
+package feature
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-test/backend/domain/dto"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"go-test/backend/repository"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemsSorting(t *testing.T) {
+	r, s := tests.SetupReadRouter()
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	fixtures := []struct {
+		amount  int64
+		typ     enums.ItemType
+		debtor  string
+		created time.Duration
+	}{
+		{100, enums.SUBMISSION, "Zoe", 3 * time.Hour},
+		{50, enums.ADMISSION, "Adam", 1 * time.Hour},
+		{100, enums.REVERSAL, "Mia", 1 * time.Hour},
+		{200, enums.ADMISSION, "Ben", 2 * time.Hour},
+		{50, enums.SUBMISSION, "Adam", 5 * time.Hour},
+	}
+	for i, f := range fixtures {
+		s.Create(context.Background(), &models.Item{
+			GUID:    fmt.Sprintf("sort-guid-%d", i+1),
+			Amount:  models.NewMoney(f.amount*100, models.DefaultCurrency),
+			Type:    f.typ,
+			Status:  enums.ACCEPTED,
+			Created: base.Add(f.created),
+			Attributes: models.Attributes{
+				Debtor: models.Party{FirstName: "Sam", LastName: f.debtor},
+			},
+		})
+	}
+
+	list := func(t *testing.T, path string) dto.ItemListResponse {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var page dto.ItemListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page
+	}
+	guids := func(page dto.ItemListResponse) []string {
+		out := make([]string, 0, len(page.Data))
+		for _, item := range page.Data {
+			out = append(out, item.GUID)
+		}
+		return out
+	}
+
+	t.Run("It sorts by multiple keys with direction", func(t *testing.T) {
+		// Act
+		page := list(t, "/items?sort=-amount,created")
+
+		// Assert
+		assert.Equal(t, []string{"sort-guid-4", "sort-guid-3", "sort-guid-1", "sort-guid-2", "sort-guid-5"}, guids(page))
+	})
+
+	t.Run("It breaks ties by index", func(t *testing.T) {
+		// Act
+		page := list(t, "/items?sort=amount")
+
+		// Assert
+		assert.Equal(t, []string{"sort-guid-2", "sort-guid-5", "sort-guid-1", "sort-guid-3", "sort-guid-4"}, guids(page))
+	})
+
+	t.Run("It sorts by type and party name", func(t *testing.T) {
+		assert.Equal(t, []string{"sort-guid-4", "sort-guid-2", "sort-guid-3", "sort-guid-5", "sort-guid-1"}, guids(list(t, "/items?sort=type,-created")))
+		assert.Equal(t, []string{"sort-guid-1", "sort-guid-3", "sort-guid-4", "sort-guid-2", "sort-guid-5"}, guids(list(t, "/items?sort=-debtor_name")))
+	})
+
+	t.Run("It pages through a sorted list in both directions", func(t *testing.T) {
+		// Arrange
+		all := guids(list(t, "/items?sort=-amount,created"))
+
+		// Act
+		var forward []string
+		var last dto.ItemListResponse
+		cursor := ""
+		for {
+			last = list(t, "/items?limit=2&sort=-amount,created&cursor="+url.QueryEscape(cursor))
+			forward = append(forward, guids(last)...)
+			if last.NextCursor == nil {
+				break
+			}
+			cursor = *last.NextCursor
+		}
+
+		var backward []string
+		page := last
+		for page.PrevCursor != nil {
+			page = list(t, "/items?limit=2&sort=-amount,created&cursor="+url.QueryEscape(*page.PrevCursor))
+			backward = append(guids(page), backward...)
+		}
+
+		// Assert
+		assert.Equal(t, all, forward)
+		assert.Equal(t, all[:len(all)-len(guids(last))], backward)
+	})
+
+	t.Run("It rejects a cursor issued for a different sort", func(t *testing.T) {
+		// Arrange
+		first := list(t, "/items?limit=2&sort=amount")
+		req := httptest.NewRequest(http.MethodGet, "/items?limit=2&sort=-created&cursor="+*first.NextCursor, nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid cursor parameter")
+	})
+
+	t.Run("It returns 400 error when the sort is invalid", func(t *testing.T) {
+		for _, sort := range []string{"bogus", "amount,-amount", "amount,,created"} {
+			// Arrange
+			req := httptest.NewRequest(http.MethodGet, "/items?sort="+sort, nil)
+			w := httptest.NewRecorder()
+
+			// Act
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, http.StatusBadRequest, w.Code, sort)
+			assert.Contains(t, w.Body.String(), `"sort":"Invalid sort.`, sort)
+		}
+	})
+}
+
+func TestItemsSortingAcrossStores(t *testing.T) {
+	stores := map[string]repository.ItemsStorage{"memory": repository.NewStore()}
+	sqlite, err := repository.NewSQLiteStore(filepath.Join(t.TempDir(), "items.db"))
+	require.NoError(t, err)
+	defer sqlite.Close()
+	stores["sqlite"] = sqlite
+
+	routers := make(map[string]*gin.Engine, len(stores))
+	for name, s := range stores {
+		for i, debtor := range []string{"émile", "Zoe", "Øst", "adam", "Émile", "Ärger"} {
+			require.NoError(t, s.Create(context.Background(), &models.Item{
+				GUID:       fmt.Sprintf("fold-guid-%d", i+1),
+				Amount:     models.NewMoney(100, models.DefaultCurrency),
+				Type:       enums.ADMISSION,
+				Status:     enums.ACCEPTED,
+				Attributes: models.Attributes{Debtor: models.Party{FirstName: "Sam", LastName: debtor}},
+			}))
+		}
+		routers[name] = tests.SetupRouterWithStore(s)
+	}
+
+	list := func(t *testing.T, store, path string) dto.ItemListResponse {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		routers[store].ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var page dto.ItemListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page
+	}
+	guids := func(page dto.ItemListResponse) []string {
+		out := make([]string, 0, len(page.Data))
+		for _, item := range page.Data {
+			out = append(out, item.GUID)
+		}
+		return out
+	}
+
+	t.Run("It orders non-ASCII names alike in every store", func(t *testing.T) {
+		for name := range stores {
+			t.Run(name, func(t *testing.T) {
+				// Act
+				page := list(t, name, "/items?sort=debtor_name")
+
+				// Assert
+				assert.Equal(t, []string{"fold-guid-4", "fold-guid-2", "fold-guid-6", "fold-guid-5", "fold-guid-3", "fold-guid-1"}, guids(page))
+			})
+		}
+	})
+
+	t.Run("It continues a name-sorted list from a cursor issued by the other store", func(t *testing.T) {
+		// Arrange
+		first := list(t, "memory", "/items?limit=3&sort=debtor_name")
+		require.NotNil(t, first.NextCursor)
+
+		// Act
+		rest := list(t, "sqlite", "/items?limit=3&sort=debtor_name&cursor="+url.QueryEscape(*first.NextCursor))
+
+		// Assert
+		assert.Equal(t, []string{"fold-guid-5", "fold-guid-3", "fold-guid-1"}, guids(rest))
+	})
+}
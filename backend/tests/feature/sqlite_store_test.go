@@ -0,0 +1,122 @@
+package feature
+
+import (
+	"context"
+	"encoding/json"
+	"go-test/backend/domain/models"
+	"go-test/backend/repository"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteStorePersistsItems(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "items.db")
+
+	s, err := repository.NewSQLiteStore(path)
+	require.NoError(t, err)
+	r := tests.SetupRouterWithStore(s)
+
+	req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(createValidCreatePayload()))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created models.Item
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.NoError(t, s.Close())
+
+	t.Run("It keeps items across a restart", func(t *testing.T) {
+		// Arrange
+		reopened, err := repository.NewSQLiteStore(path)
+		require.NoError(t, err)
+		defer reopened.Close()
+		r := tests.SetupRouterWithStore(reopened)
+
+		req := httptest.NewRequest(http.MethodGet, "/items/"+created.GUID, nil)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var item models.Item
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+		assert.Equal(t, created.GUID, item.GUID)
+		assert.Equal(t, created.Index, item.Index)
+		assert.Equal(t, created.Amount, item.Amount)
+		assert.Equal(t, created.Attributes, item.Attributes)
+		assert.True(t, created.Created.Equal(item.Created))
+	})
+
+	t.Run("It continues the index sequence after a restart", func(t *testing.T) {
+		// Arrange
+		reopened, err := repository.NewSQLiteStore(path)
+		require.NoError(t, err)
+		defer reopened.Close()
+		item := &models.Item{GUID: "sqlite-sequence-guid"}
+
+		// Act
+		err = reopened.Create(context.Background(), item)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, created.Index+1, item.Index)
+		require.NoError(t, reopened.Delete(context.Background(), item.GUID, 0))
+	})
+
+	t.Run("It keeps status transitions across a restart", func(t *testing.T) {
+		// Arrange
+		reopened, err := repository.NewSQLiteStore(path)
+		require.NoError(t, err)
+		r := tests.SetupRouterWithStore(reopened)
+
+		req := httptest.NewRequest(http.MethodPost, "/items/"+created.GUID+"/transitions", strings.NewReader(`{"status": "SETTLED", "reason": "Settled"}`))
+		req.Header.Set("X-Actor", "alice")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, reopened.Close())
+
+		// Act
+		reopened, err = repository.NewSQLiteStore(path)
+		require.NoError(t, err)
+		defer reopened.Close()
+		item, err := reopened.GetByGUID(context.Background(), created.GUID)
+
+		// Assert
+		require.NoError(t, err)
+		require.Len(t, item.Transitions, 1)
+		assert.Equal(t, created.Status, item.Transitions[0].From)
+		assert.Equal(t, "SETTLED", string(item.Transitions[0].To))
+		assert.Equal(t, "alice", item.Transitions[0].Actor)
+		assert.Equal(t, "Settled", item.Transitions[0].Reason)
+	})
+
+	t.Run("It removes nested attributes when an item is deleted", func(t *testing.T) {
+		// Arrange
+		reopened, err := repository.NewSQLiteStore(path)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		// Act
+		err = reopened.Delete(context.Background(), created.GUID, 0)
+
+		// Assert
+		assert.NoError(t, err)
+		_, err = reopened.GetByGUID(context.Background(), created.GUID)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+
+		// Re-creating the same GUID must not collide with orphaned party rows
+		assert.NoError(t, reopened.Create(context.Background(), &created))
+	})
+}
@@ -0,0 +1,311 @@
+package feature
+
+import (
+	"context"
+	"encoding/json"
+	"go-test/backend/domain/dto"
+	"go-test/backend/domain/enums"
+	"go-test/backend/domain/models"
+	"go-test/backend/repository"
+	"go-test/backend/tests"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantIsolation(t *testing.T) {
+	r, _ := tests.SetupReadRouter()
+
+	send := func(method, path, body, tenant string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if tenant != "" {
+			req.Header.Set("X-Tenant-ID", tenant)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	create := func(t *testing.T, tenant string) models.Item {
+		w := send(http.MethodPost, "/items", createValidCreatePayload(), tenant)
+		require.Equal(t, http.StatusCreated, w.Code)
+		var item models.Item
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+		return item
+	}
+	list := func(t *testing.T, tenant string) dto.ItemListResponse {
+		w := send(http.MethodGet, "/items?limit=100", "", tenant)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response dto.ItemListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	t.Run("It lists only the items of the tenant named by X-Tenant-ID", func(t *testing.T) {
+		// Arrange
+		first := create(t, "list-acme")
+		second := create(t, "list-acme")
+		other := create(t, "list-globex")
+
+		// Act
+		acme := list(t, "list-acme")
+		globex := list(t, "list-globex")
+
+		// Assert
+		require.Equal(t, 2, acme.Total)
+		assert.Equal(t, []string{first.GUID, second.GUID}, []string{acme.Data[0].GUID, acme.Data[1].GUID})
+		assert.Equal(t, "list-acme", acme.Data[0].Tenant)
+		require.Equal(t, 1, globex.Total)
+		assert.Equal(t, other.GUID, globex.Data[0].GUID)
+		assert.Equal(t, "list-globex", globex.Data[0].Tenant)
+	})
+
+	t.Run("It numbers each tenant's items from its own sequence", func(t *testing.T) {
+		// Act
+		acmeFirst := create(t, "sequence-acme")
+		globexFirst := create(t, "sequence-globex")
+		acmeSecond := create(t, "sequence-acme")
+
+		// Assert
+		assert.Equal(t, 1, acmeFirst.Index)
+		assert.Equal(t, 2, acmeSecond.Index)
+		assert.Equal(t, 1, globexFirst.Index)
+	})
+
+	t.Run("It acts for the default tenant when no tenant is named", func(t *testing.T) {
+		// Arrange
+		item := create(t, "")
+
+		// Act
+		w := send(http.MethodGet, "/items/"+item.GUID, "", repository.DefaultTenant)
+
+		// Assert
+		assert.Equal(t, repository.DefaultTenant, item.Tenant)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/items/"+item.GUID, "", "someone-else").Code)
+	})
+
+	t.Run("It does not let a tenant read or change another tenant's items", func(t *testing.T) {
+		// Arrange
+		item := create(t, "owner-acme")
+		path := "/items/" + item.GUID
+
+		routes := []struct{ method, path, body string }{
+			{http.MethodGet, path + "?include_deleted=true", ""},
+			{http.MethodPut, path, createUpdatePayload()},
+			{http.MethodPost, path + "/transitions", `{"status": "SETTLED"}`},
+			{http.MethodDelete, path, ""},
+			{http.MethodPost, path + "/restore", ""},
+			{http.MethodGet, path + "/history", ""},
+			{http.MethodGet, path + "/reversals", ""},
+		}
+
+		// Act
+		responses := make([]*httptest.ResponseRecorder, len(routes))
+		for i, route := range routes {
+			responses[i] = send(route.method, route.path, route.body, "intruder-globex")
+		}
+		w := send(http.MethodGet, path, "", "owner-acme")
+
+		// Assert
+		for i, route := range routes {
+			assert.Equal(t, http.StatusNotFound, responses[i].Code, route.method+" "+route.path)
+			assert.JSONEq(t, `{"error": "Item not found"}`, responses[i].Body.String(), route.method+" "+route.path)
+		}
+		require.Equal(t, http.StatusOK, w.Code)
+		var stored models.Item
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stored))
+		assert.Equal(t, 1, stored.Version)
+	})
+
+	t.Run("It does not put another tenant's items in a payment file", func(t *testing.T) {
+		// Arrange
+		item := create(t, "bacs-acme")
+		body := `{"guids": ["` + item.GUID + `"], "service_user_number": "123456", "service_user_name": "ACME PAYROLL", "serial_number": "000001", "processing_date": "` + time.Now().UTC().AddDate(0, 0, 1).Format(time.DateOnly) + `"}`
+
+		// Act
+		w := send(http.MethodPost, "/items:bacs", body, "bacs-globex")
+
+		// Assert
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.JSONEq(t, `{"errors": {"`+item.GUID+`": "Item not found"}}`, w.Body.String())
+	})
+
+	t.Run("It only purges the deleted items of the tenant purging", func(t *testing.T) {
+		// Arrange
+		mine := create(t, "purge-acme")
+		theirs := create(t, "purge-globex")
+		require.Equal(t, http.StatusNoContent, send(http.MethodDelete, "/items/"+mine.GUID, "", "purge-acme").Code)
+		require.Equal(t, http.StatusNoContent, send(http.MethodDelete, "/items/"+theirs.GUID, "", "purge-globex").Code)
+
+		// Act
+		w := send(http.MethodPost, "/admin/items/purge", `{"retention_days": 0}`, "purge-acme")
+
+		// Assert
+		require.Equal(t, http.StatusOK, w.Code)
+		var response dto.ItemPurgeResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, []string{mine.GUID}, response.GUIDs)
+		assert.Equal(t, http.StatusOK, send(http.MethodGet, "/items/"+theirs.GUID+"?include_deleted=true", "", "purge-globex").Code)
+	})
+
+	t.Run("It refuses an X-Tenant-ID header that is not a tenant ID", func(t *testing.T) {
+		for _, tenant := range []string{"acme corp", "acme/../globex", strings.Repeat("a", 65)} {
+			t.Run(tenant, func(t *testing.T) {
+				// Act
+				w := send(http.MethodGet, "/items", "", tenant)
+
+				// Assert
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.JSONEq(t, `{"error": "Invalid X-Tenant-ID header"}`, w.Body.String())
+			})
+		}
+	})
+}
+
+func TestTenantFromToken(t *testing.T) {
+	r, _ := tests.SetupAuthRouter()
+
+	token := func(tenant string, roles ...string) string {
+		claims := tests.TestClaims("alice", roles...)
+		claims.Tenant = tenant
+		return tests.SignToken(jwt.SigningMethodRS256, tests.TestRS256KeyID, claims)
+	}
+	send := func(method, path, body, token, tenant string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		if tenant != "" {
+			req.Header.Set("X-Tenant-ID", tenant)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(http.MethodPost, "/items", createPendingPayload("100"), token("acme", "operator"), "")
+	require.Equal(t, http.StatusCreated, w.Code)
+	var item models.Item
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+
+	t.Run("It scopes requests to the tenant named by the token", func(t *testing.T) {
+		// Act
+		acme := send(http.MethodGet, "/items/"+item.GUID, "", token("acme", "viewer"), "")
+		globex := send(http.MethodGet, "/items/"+item.GUID, "", token("globex", "viewer"), "")
+		untenanted := send(http.MethodGet, "/items/"+item.GUID, "", token("", "viewer"), "")
+
+		// Assert
+		assert.Equal(t, "acme", item.Tenant)
+		assert.Equal(t, http.StatusOK, acme.Code)
+		assert.Equal(t, http.StatusNotFound, globex.Code)
+		assert.Equal(t, http.StatusNotFound, untenanted.Code)
+	})
+
+	t.Run("It accepts an X-Tenant-ID header naming the token's tenant", func(t *testing.T) {
+		// Act
+		w := send(http.MethodGet, "/items/"+item.GUID, "", token("acme", "viewer"), "acme")
+
+		// Assert
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("It refuses an X-Tenant-ID header naming another tenant than the token", func(t *testing.T) {
+		for name, tok := range map[string]string{
+			"another tenant": token("globex", "admin"),
+			"no tenant":      token("", "admin"),
+		} {
+			t.Run(name, func(t *testing.T) {
+				// Act
+				w := send(http.MethodGet, "/items/"+item.GUID, "", tok, "acme")
+
+				// Assert
+				assert.Equal(t, http.StatusForbidden, w.Code)
+				assert.JSONEq(t, `{"error": "Token does not grant access to tenant acme"}`, w.Body.String())
+			})
+		}
+	})
+}
+
+func TestTenantStorage(t *testing.T) {
+	acme := repository.WithTenant(context.Background(), "acme")
+	globex := repository.WithTenant(context.Background(), "globex")
+	newItem := func(guid string) *models.Item {
+		return &models.Item{GUID: guid, Amount: models.NewMoney(10000, models.DefaultCurrency), Type: enums.ADMISSION, Status: enums.PENDING}
+	}
+
+	t.Run("It refuses to create an item with a GUID another tenant's item has", func(t *testing.T) {
+		// Arrange
+		s := tests.NewTestStore()
+		require.NoError(t, s.Create(acme, newItem("tenant-shared-guid")))
+
+		// Act
+		err := s.Create(globex, newItem("tenant-shared-guid"))
+		errAll := s.CreateAll(globex, []*models.Item{newItem("tenant-other-guid"), newItem("tenant-shared-guid")})
+
+		// Assert
+		assert.ErrorIs(t, err, repository.ErrGUIDInUse)
+		assert.ErrorIs(t, errAll, repository.ErrGUIDInUse)
+		count, err := s.Count(globex)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+		stored, err := s.GetByGUID(acme, "tenant-shared-guid")
+		require.NoError(t, err)
+		assert.Equal(t, "acme", stored.Tenant)
+	})
+
+	for name, open := range map[string]func(t *testing.T, dir string) repository.ItemsStorage{
+		"sqlite": func(t *testing.T, dir string) repository.ItemsStorage {
+			s, err := repository.NewSQLiteStore(filepath.Join(dir, "items.db"))
+			require.NoError(t, err)
+			return s
+		},
+		"journal": func(t *testing.T, dir string) repository.ItemsStorage {
+			s, err := repository.NewDurableStore(dir, 2)
+			require.NoError(t, err)
+			return s
+		},
+	} {
+		t.Run("It keeps tenants and their sequences apart across a restart of the "+name+" store", func(t *testing.T) {
+			// Arrange
+			dir := t.TempDir()
+			s := open(t, dir)
+			require.NoError(t, s.Create(acme, newItem("durable-acme-1")))
+			require.NoError(t, s.Create(acme, newItem("durable-acme-2")))
+			require.NoError(t, s.Create(globex, newItem("durable-globex-1")))
+			require.NoError(t, s.Delete(globex, "durable-globex-1", 0))
+			require.NoError(t, s.(interface{ Close() error }).Close())
+
+			// Act
+			reopened := open(t, dir)
+			defer reopened.(interface{ Close() error }).Close()
+			next := newItem("durable-globex-2")
+			require.NoError(t, reopened.Create(globex, next))
+
+			// Assert
+			assert.Equal(t, 2, next.Index)
+			page, err := reopened.GetAllFiltered(acme, repository.ItemQuery{IncludeDeleted: true})
+			require.NoError(t, err)
+			require.Equal(t, 2, page.Total)
+			assert.Equal(t, "durable-acme-1", page.Items[0].GUID)
+			assert.Equal(t, "durable-acme-2", page.Items[1].GUID)
+			_, err = reopened.GetDeletedByGUID(acme, "durable-globex-1")
+			assert.ErrorIs(t, err, repository.ErrNotFound)
+			deleted, err := reopened.GetDeletedByGUID(globex, "durable-globex-1")
+			require.NoError(t, err)
+			assert.Equal(t, "globex", deleted.Tenant)
+			history, err := reopened.History(acme, "durable-globex-1")
+			require.NoError(t, err)
+			assert.Empty(t, history)
+			history, err = reopened.History(globex, "durable-globex-1")
+			require.NoError(t, err)
+			assert.Len(t, history, 2)
+		})
+	}
+}
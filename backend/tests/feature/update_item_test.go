@@ -1,6 +1,7 @@
 package feature
 
 import (
+	"context"
 	"go-test/backend/domain/enums"
 	"go-test/backend/domain/models"
 	"go-test/backend/tests"
@@ -17,11 +18,11 @@ func TestItCanUpdateAnItem(t *testing.T) {
 
 	item := &models.Item{
 		GUID:   "test-guid-123",
-		Amount: 100,
+		Amount: models.NewMoney(10000, models.DefaultCurrency),
 		Type:   enums.ADMISSION,
-		Status: enums.ACCEPTED,
+		Status: enums.PENDING,
 	}
-	s.Create(item)
+	s.Create(context.Background(), item)
 
 	t.Run("It can successfully update an item", func(t *testing.T) {
 		// Arrange
@@ -34,7 +35,7 @@ func TestItCanUpdateAnItem(t *testing.T) {
 		// Assert
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.Contains(t, w.Body.String(), `"guid":"test-guid-123"`)
-		assert.Contains(t, w.Body.String(), `"amount":200`)
+		assert.Contains(t, w.Body.String(), `"amount":"200.00"`)
 		assert.Contains(t, w.Body.String(), `"type":"SUBMISSION"`)
 		assert.Contains(t, w.Body.String(), `"status":"DECLINED"`)
 		assert.Contains(t, w.Body.String(), `"first_name":"John"`)
@@ -75,7 +76,7 @@ func TestItCanUpdateAnItem(t *testing.T) {
 
 	t.Run("It returns 400 error when the status or type is invalid", func(t *testing.T) {
 		// Arrange
-		req := httptest.NewRequest(http.MethodPut, "/items/"+item.GUID, strings.NewReader(`{"type": "invalid", "status": "pending"}`))
+		req := httptest.NewRequest(http.MethodPut, "/items/"+item.GUID, strings.NewReader(`{"type": "invalid", "status": "unknown"}`))
 		w := httptest.NewRecorder()
 
 		// Act
@@ -89,7 +90,7 @@ func TestItCanUpdateAnItem(t *testing.T) {
 
 	t.Run("It returns error when updating with nil pointer", func(t *testing.T) {
 		// Act
-		err := s.Update(nil)
+		err := s.Update(context.Background(), nil)
 
 		// Assert
 		assert.Error(t, err)
@@ -100,13 +101,13 @@ func TestItCanUpdateAnItem(t *testing.T) {
 		// Arrange
 		item := &models.Item{
 			GUID:   "", // Empty GUID
-			Amount: 100,
+			Amount: models.NewMoney(10000, models.DefaultCurrency),
 			Type:   enums.ADMISSION,
 			Status: enums.ACCEPTED,
 		}
 
 		// Act
-		err := s.Update(item)
+		err := s.Update(context.Background(), item)
 
 		// Assert
 		assert.Error(t, err)
@@ -117,13 +118,13 @@ func TestItCanUpdateAnItem(t *testing.T) {
 		// Arrange
 		item := &models.Item{
 			GUID:   "non-existent-guid",
-			Amount: 100,
+			Amount: models.NewMoney(10000, models.DefaultCurrency),
 			Type:   enums.ADMISSION,
 			Status: enums.ACCEPTED,
 		}
 
 		// Act
-		err := s.Update(item)
+		err := s.Update(context.Background(), item)
 
 		// Assert
 		assert.Error(t, err)
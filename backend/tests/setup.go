@@ -1,9 +1,16 @@
 package tests
 
 import (
+	"go-test/backend/auth"
+	"go-test/backend/domain/approval"
+	"go-test/backend/domain/dto"
+	"go-test/backend/domain/models"
 	"go-test/backend/domain/validators"
 	"go-test/backend/handlers"
+	"go-test/backend/middleware"
 	"go-test/backend/repository"
+	"log"
+	"os"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
@@ -11,22 +18,100 @@ import (
 )
 
 func SetupReadRouter() (*gin.Engine, repository.ItemsStorage) {
+	s := NewTestStore()
+	return SetupRouterWithStore(s), s
+}
+
+// SetupAuthRouter is SetupReadRouter configured as the server is by default: the item routes
+// require a token from MintToken whose roles grant the route's permission under the default
+// policy, and new items need approval under the default approval rules
+func SetupAuthRouter() (*gin.Engine, repository.ItemsStorage) {
+	s := NewTestStore()
+	return setupRouter(s, approval.Default(), middleware.NewAuthorizer(auth.DefaultPolicy()), middleware.Authenticate(TestVerifier())), s
+}
+
+// SetupRouterWithStore wires the item routes against the given storage, without authentication,
+// and creates items that need no approval. Requests act for the tenant named by their X-Tenant-ID
+// header, or the default tenant.
+func SetupRouterWithStore(s repository.ItemsStorage) *gin.Engine {
+	return setupRouter(s, approval.None(), middleware.NewAuthorizer(nil))
+}
+
+// setupRouter wires the item routes against the given storage and approval rules behind the given
+// authentication, checking permissions with authorize and scoping every request to its tenant
+func setupRouter(s repository.ItemsStorage, approvals *approval.Rules, authorize *middleware.Authorizer, authentication ...gin.HandlerFunc) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
+	r.Use(middleware.RequestID())
 
 	// Register custom validators
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("itemtype", validators.ValidateItemType)
 		v.RegisterValidation("itemstatus", validators.ValidateItemStatus)
+		v.RegisterValidation("initialstatus", validators.ValidateInitialItemStatus)
 		v.RegisterValidation("sortcode", validators.ValidateSortCode)
+		v.RegisterValidation("knownsortcode", validators.ValidateKnownSortCode)
+		v.RegisterValidation("accounttype", validators.ValidateAccountType)
+		v.RegisterValidation("requiredfor", validators.ValidateRequiredFor)
+		v.RegisterValidation("onlyfor", validators.ValidateOnlyFor)
+		v.RegisterValidation("iban", validators.ValidateIBAN)
+		v.RegisterValidation("bic", validators.ValidateBIC)
+		v.RegisterValidation("currency", validators.ValidateCurrency)
+		v.RegisterValidation("money", validators.ValidateMoney)
+		v.RegisterValidation("positive", validators.ValidatePositive)
+		v.RegisterValidation("isodate", validators.ValidateISODate)
+		v.RegisterValidation("itemsort", validators.ValidateItemSort)
+		v.RegisterValidation("serviceusernumber", validators.ValidateServiceUserNumber)
+		v.RegisterValidation("bacsserial", validators.ValidateBacsSerial)
+		v.RegisterStructValidation(validators.ValidateItemFilter, dto.ItemFilterDTO{})
+		v.RegisterStructValidation(validators.ValidateItemCreate, dto.ItemCreateDTO{})
+		v.RegisterStructValidation(validators.ValidateAccount, models.Account{})
 	}
 
-	s := repository.NewStore()
-	handler := handlers.NewItemsHandler(s)
-	r.GET("/items", handler.GetAll)
-	r.GET("/items/:guid", handler.GetByGUID)
-	r.POST("/items", handler.Create)
-	r.PUT("/items/:guid", handler.Update)
-	r.DELETE("/items/:guid", handler.Delete)
-	return r, s
+	handler := handlers.NewItemsHandler(s, approvals)
+	r.GET("/healthz", handlers.Health)
+
+	api := r.Group("/", append(authentication, middleware.Tenant())...)
+	api.GET("/items", authorize.Require("items.list"), handler.GetAll)
+	api.GET("/items/export", authorize.Require("items.export"), handler.Export)
+	api.GET("/items/:guid", authorize.Require("items.get"), handler.GetByGUID)
+	api.POST("/items", authorize.Require("items.create"), handler.Create)
+	api.POST("/items:method", authorize.RequireCollectionMethod(), handler.CollectionMethod)
+	api.POST("/items/import", authorize.Require("items.import"), handler.Import)
+	api.PUT("/items/:guid", authorize.Require("items.update"), handler.Update)
+	api.DELETE("/items/:guid", authorize.Require("items.delete"), handler.Delete)
+	api.POST("/items/:guid/transitions", authorize.Require("items.transition"), handler.Transition)
+	api.POST("/items/:guid/approve", authorize.Require("items.approve"), handler.Approve)
+	api.POST("/items/:guid/reject", authorize.Require("items.reject"), handler.Reject)
+	api.GET("/items/:guid/reversals", authorize.Require("items.reversals"), handler.Reversals)
+	api.GET("/items/:guid/history", authorize.Require("items.history"), handler.History)
+	api.POST("/items/:guid/restore", authorize.Require("items.restore"), handler.Restore)
+	api.POST("/admin/items/purge", authorize.Require("items.purge"), handler.Purge)
+	return r
+}
+
+// NewTestStore returns an empty store for the driver named by ITEMS_STORE
+// ("memory" by default, "sqlite" for a private in-memory SQLite database,
+// or "journal" for a write-ahead logged store in a fresh temporary directory)
+func NewTestStore() repository.ItemsStorage {
+	switch os.Getenv("ITEMS_STORE") {
+	case "sqlite":
+		s, err := repository.NewSQLiteStore(":memory:")
+		if err != nil {
+			log.Fatal("Failed to open sqlite test store:", err)
+		}
+		return s
+	case "journal":
+		dir, err := os.MkdirTemp("", "items-journal-*")
+		if err != nil {
+			log.Fatal("Failed to create journal test directory:", err)
+		}
+		s, err := repository.NewDurableStore(dir, 0)
+		if err != nil {
+			log.Fatal("Failed to open journal test store:", err)
+		}
+		return s
+	default:
+		return repository.NewStore()
+	}
 }
@@ -27,7 +27,9 @@ describe('Items Store', () => {
         {
           guid: '1',
           index: 1,
-          amount: 100.50,
+          version: 1,
+          amount: '100.50',
+          currency: 'GBP',
           type: 'ADMISSION',
           status: 'ACCEPTED',
           created: '2024-01-15T10:30:00Z',
@@ -55,7 +57,7 @@ describe('Items Store', () => {
       // Mock successful response
       ;(global.fetch as any).mockResolvedValueOnce({
         ok: true,
-        json: async () => mockItems,
+        json: async () => ({ data: mockItems, total: mockItems.length, totals: [], next_cursor: null, prev_cursor: null }),
         headers: {
           get: vi.fn().mockReturnValue(null)
         }
@@ -72,7 +74,7 @@ describe('Items Store', () => {
 
       ;(global.fetch as any).mockResolvedValueOnce({
         ok: true,
-        json: async () => mockItems,
+        json: async () => ({ data: mockItems, total: mockItems.length, totals: [], next_cursor: null, prev_cursor: null }),
         headers: {
           get: vi.fn().mockReturnValue(null)
         }
@@ -116,7 +118,10 @@ describe('Items Store', () => {
       const createdItem: Item = {
         guid: 'new-guid',
         index: 1,
+        version: 1,
         ...newItemData,
+        amount: '200.75',
+        currency: 'GBP',
         created: '2024-01-15T10:30:00Z'
       }
 
@@ -142,7 +147,9 @@ describe('Items Store', () => {
       const existingItem: Item = {
         guid: 'test-guid',
         index: 1,
-        amount: 100.50,
+        version: 1,
+        amount: '100.50',
+        currency: 'GBP',
         type: 'ADMISSION',
         status: 'ACCEPTED',
         created: '2024-01-15T10:30:00Z',
@@ -175,7 +182,8 @@ describe('Items Store', () => {
 
       const updatedItem: Item = {
         ...existingItem,
-        amount: 150.75
+        version: 2,
+        amount: '150.75'
       }
 
       // Mock successful response
@@ -190,7 +198,13 @@ describe('Items Store', () => {
       const result = await store.updateItem('test-guid', updateData)
 
       expect(result).toEqual(updatedItem)
-      expect(store.items[0]?.amount).toBe(150.75)
+      expect(store.items[0]?.amount).toBe('150.75')
+      expect(global.fetch).toHaveBeenCalledWith(
+        expect.stringContaining('/items/test-guid'),
+        expect.objectContaining({
+          headers: expect.objectContaining({'If-Match': '"1"'})
+        })
+      )
     })
   })
 
@@ -199,7 +213,9 @@ describe('Items Store', () => {
       const itemToDelete: Item = {
         guid: 'test-guid',
         index: 1,
-        amount: 100.50,
+        version: 1,
+        amount: '100.50',
+        currency: 'GBP',
         type: 'ADMISSION',
         status: 'ACCEPTED',
         created: '2024-01-15T10:30:00Z',
@@ -240,6 +256,10 @@ describe('Items Store', () => {
 
       expect(result).toBe(true)
       expect(store.items).not.toContain(itemToDelete)
+      expect(global.fetch).toHaveBeenCalledWith(
+        expect.stringContaining('/items/test-guid'),
+        expect.objectContaining({headers: {'If-Match': '"1"'}})
+      )
     })
   })
 
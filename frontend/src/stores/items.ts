@@ -1,6 +1,6 @@
 import {defineStore} from 'pinia'
 import {ref} from 'vue'
-import type {Item, ItemCreateDTO, ItemUpdateDTO} from '@/types'
+import type {Item, ItemCreateDTO, ItemListResponse, ItemTransitionDTO, ItemUpdateDTO} from '@/types'
 import {request} from '@/utils/request'
 
 export const useItemsStore = defineStore(
@@ -27,7 +27,8 @@ export const useItemsStore = defineStore(
       items.value = []
 
       const endpoint = query?.trim() ? `/items?query=${encodeURIComponent(query.trim())}` : '/items'
-      items.value = await request<Item[]>(endpoint)
+      const page = await request<ItemListResponse>(endpoint)
+      items.value = page.data
     } catch (err) {
       items.value = []
       throw err
@@ -55,6 +56,12 @@ export const useItemsStore = defineStore(
     }
   }
 
+  // If-Match header for the version of an item we last saw, so stale edits fail with 412
+  const ifMatch = (guid: string): Record<string, string> => {
+    const item = items.value.find(item => item.guid === guid)
+    return item ? {'If-Match': `"${item.version}"`} : {}
+  }
+
   // Update item
   const updateItem = async (guid: string, updateData: ItemUpdateDTO): Promise<Item | null> => {
     try {
@@ -62,6 +69,7 @@ export const useItemsStore = defineStore(
         method: 'PUT',
         headers: {
           'Content-Type': 'application/json',
+          ...ifMatch(guid),
         },
         body: JSON.stringify(updateData),
       })
@@ -77,11 +85,35 @@ export const useItemsStore = defineStore(
     }
   }
 
+  // Move item to a new status
+  const transitionItem = async (guid: string, transition: ItemTransitionDTO): Promise<Item | null> => {
+    try {
+      const updatedItem = await request<Item>(`/items/${guid}/transitions`, {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+          ...ifMatch(guid),
+        },
+        body: JSON.stringify(transition),
+      })
+
+      items.value = items.value.map(item =>
+        item.guid === guid ? updatedItem : item
+      )
+
+      return updatedItem
+    } catch (err) {
+      console.error('Error transitioning item:', err)
+      return null
+    }
+  }
+
   // Delete item
   const deleteItem = async (guid: string): Promise<boolean> => {
     try {
       await request(`/items/${guid}`, {
         method: 'DELETE',
+        headers: ifMatch(guid),
       })
 
       items.value = items.value.filter(item => item.guid !== guid)
@@ -102,6 +134,7 @@ export const useItemsStore = defineStore(
     fetchItems,
     createItem,
     updateItem,
+    transitionItem,
     deleteItem,
     setSearchQuery,
   }
@@ -1,19 +1,41 @@
 import type { ItemType, ItemStatus } from './enums'
-import type { Attributes } from './entities'
+import type { Attributes, Item } from './entities'
 
 export interface ItemCreateDTO {
-  amount: number
+  amount: number | string // decimal string preferred; numbers are read exactly as written
+  currency?: string // ISO 4217 code, defaults to GBP
   type: ItemType
-  status: ItemStatus
+  status?: ItemStatus // defaults to PENDING; SETTLED and RETURNED are only reached by transitions, and items needing approval must be PENDING
   created?: string
   attributes: Attributes
+  original_guid?: string // required for REVERSAL items
+}
+
+export interface ItemTransitionDTO {
+  status: ItemStatus
+  reason: string
 }
 
 export interface ItemUpdateDTO {
-  amount?: number
+  amount?: number | string
+  currency?: string
   type?: ItemType
   status?: ItemStatus
+  reason?: string
   created?: string
   attributes?: Partial<Attributes>
 }
 
+export interface CurrencyTotal {
+  currency: string
+  count: number
+  amount: string
+}
+
+export interface ItemListResponse {
+  data: Item[]
+  total: number
+  totals: CurrencyTotal[]
+  next_cursor: string | null
+  prev_cursor: string | null
+}
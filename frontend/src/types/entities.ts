@@ -1,13 +1,39 @@
-import type { ItemType, ItemStatus } from './enums'
+import type { AccountType, ApprovalDecision, ItemType, ItemStatus, PaymentScheme } from './enums'
 
 export interface Item {
   guid: string
   index: number
-  amount: number
+  version: number
+  amount: string // exact decimal, e.g. "100.50"
+  currency: string // ISO 4217 code, e.g. "GBP"
   type: ItemType
   status: ItemStatus
   created: string
   attributes: Attributes
+  transitions?: StatusTransition[]
+  tenant?: string // the tenant the item belongs to
+  original_guid?: string // the item a REVERSAL reverses
+  net_amount?: string // amount less active reversals; absent on reversals
+  created_by?: string // who created the item, who cannot approve it
+  changed_by?: string // who last changed the amount, currency or parties, who cannot approve the change
+  required_approvals?: number // approvals needed to leave PENDING_APPROVAL
+  approvals?: Approval[]
+  deleted_at?: string // set on soft-deleted items, which are only listed with include_deleted=true
+}
+
+export interface Approval {
+  actor: string
+  decision: ApprovalDecision
+  reason?: string
+  at: string
+}
+
+export interface StatusTransition {
+  from: ItemStatus
+  to: ItemStatus
+  actor?: string
+  reason?: string
+  at: string
 }
 
 export interface Attributes {
@@ -22,6 +48,16 @@ export interface Party {
 }
 
 export interface Account {
-  sort_code: string
-  account_number: string
+  type?: AccountType // accounts without a type are UK accounts
+  sort_code: string // UK accounts only; empty for IBAN accounts
+  account_number: string // UK accounts only; empty for IBAN accounts
+  iban?: string // IBAN accounts only, in electronic format
+  bic?: string // optional on IBAN accounts
+  bank?: Bank // looked up from the sort code directory for UK accounts; set by the server
+}
+
+export interface Bank {
+  name: string
+  branch: string
+  schemes: PaymentScheme[]
 }
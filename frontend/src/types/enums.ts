@@ -1,2 +1,5 @@
 export type ItemType = 'ADMISSION' | 'SUBMISSION' | 'REVERSAL'
-export type ItemStatus = 'ACCEPTED' | 'DECLINED'
+export type ItemStatus = 'PENDING_APPROVAL' | 'PENDING' | 'ACCEPTED' | 'DECLINED' | 'SETTLED' | 'RETURNED'
+export type PaymentScheme = 'BACS' | 'FPS' | 'CHAPS'
+export type AccountType = 'UK' | 'IBAN'
+export type ApprovalDecision = 'APPROVED' | 'REJECTED'
@@ -1,13 +1,13 @@
 import type { ItemType, ItemStatus } from '@/types'
 
 /**
- * Format a number as currency with a pound symbol
+ * Format a number or decimal string as an amount of an ISO 4217 currency (pounds by default)
  */
-export const formatCurrency = (amount: number): string => {
+export const formatCurrency = (amount: number | string, currency = 'GBP'): string => {
   return new Intl.NumberFormat('en-GB', {
-    minimumFractionDigits: 2,
-    maximumFractionDigits: 2
-  }).format(amount)
+    style: 'currency',
+    currency
+  }).format(Number(amount))
 }
 
 /**
@@ -30,10 +30,18 @@ export const getStatusBadgeClass = (status: ItemStatus): string => {
   const baseClasses = 'inline-flex px-2 py-1 text-xs font-semibold rounded-full'
 
   switch (status) {
+    case 'PENDING_APPROVAL':
+      return `${baseClasses} bg-purple-100 text-purple-800`
+    case 'PENDING':
+      return `${baseClasses} bg-yellow-100 text-yellow-800`
     case 'ACCEPTED':
       return `${baseClasses} bg-green-100 text-green-800`
     case 'DECLINED':
       return `${baseClasses} bg-red-100 text-red-800`
+    case 'SETTLED':
+      return `${baseClasses} bg-indigo-100 text-indigo-800`
+    case 'RETURNED':
+      return `${baseClasses} bg-orange-100 text-orange-800`
     default:
       return `${baseClasses} bg-gray-100 text-gray-800`
   }
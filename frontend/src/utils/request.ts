@@ -1,7 +1,16 @@
 const API_BASE_URL = import.meta.env.VITE_API_BASE_URL || 'http://localhost:8080'
+const API_TOKEN = import.meta.env.VITE_API_TOKEN
+const TENANT_ID = import.meta.env.VITE_TENANT_ID
 
 // Request helper
 export async function request<T>(endpoint: string, options?: RequestInit): Promise<T> {
+  if (API_TOKEN || TENANT_ID) {
+    const headers = new Headers(options?.headers)
+    if (API_TOKEN) headers.set('Authorization', `Bearer ${API_TOKEN}`)
+    if (TENANT_ID) headers.set('X-Tenant-ID', TENANT_ID)
+    options = { ...options, headers }
+  }
+
   const response = await fetch(`${API_BASE_URL}${endpoint}`, options)
 
   if (!response.ok) {
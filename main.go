@@ -3,7 +3,7 @@ package main
 import (
 	"go-test/backend/bootstrap"
 	"go-test/backend/handlers"
-	"go-test/backend/repository"
+	"go-test/backend/middleware"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -18,23 +18,46 @@ func main() {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:3000"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "If-Match", "X-Request-ID", "X-Actor", "X-Tenant-ID"},
+		ExposeHeaders:    []string{"Content-Length", "ETag", "X-Request-ID"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
+	// Give every request an ID to trace and audit it by
+	r.Use(middleware.RequestID())
+
+	// Load the reference data accounts are validated against
+	bootstrap.LoadModulusTables()
+	bootstrap.LoadSortCodeDirectory()
+
 	// Register custom validators
 	bootstrap.RegisterCustomValidators()
 
-	s := repository.NewStore()
-	h := handlers.NewItemsHandler(s)
+	s := bootstrap.NewStorage()
+	h := handlers.NewItemsHandler(s, bootstrap.NewApprovalRules())
+
+	r.GET("/healthz", handlers.Health)
 
-	r.GET("/items", h.GetAll)
-	r.GET("/items/:guid", h.GetByGUID)
-	r.POST("/items", h.Create)
-	r.PUT("/items/:guid", h.Update)
-	r.DELETE("/items/:guid", h.Delete)
+	// Every item route needs a bearer token whose roles grant the route's permission, and only sees
+	// the items of the token's tenant
+	api := r.Group("/", bootstrap.NewAuthentication(), middleware.Tenant())
+	authorize := bootstrap.NewAuthorizer()
+	api.GET("/items", authorize.Require("items.list"), h.GetAll)
+	api.GET("/items/export", authorize.Require("items.export"), h.Export)
+	api.GET("/items/:guid", authorize.Require("items.get"), h.GetByGUID)
+	api.POST("/items", authorize.Require("items.create"), h.Create)
+	api.POST("/items:method", authorize.RequireCollectionMethod(), h.CollectionMethod)
+	api.POST("/items/import", authorize.Require("items.import"), h.Import)
+	api.PUT("/items/:guid", authorize.Require("items.update"), h.Update)
+	api.DELETE("/items/:guid", authorize.Require("items.delete"), h.Delete)
+	api.POST("/items/:guid/transitions", authorize.Require("items.transition"), h.Transition)
+	api.POST("/items/:guid/approve", authorize.Require("items.approve"), h.Approve)
+	api.POST("/items/:guid/reject", authorize.Require("items.reject"), h.Reject)
+	api.GET("/items/:guid/reversals", authorize.Require("items.reversals"), h.Reversals)
+	api.GET("/items/:guid/history", authorize.Require("items.history"), h.History)
+	api.POST("/items/:guid/restore", authorize.Require("items.restore"), h.Restore)
+	api.POST("/admin/items/purge", authorize.Require("items.purge"), h.Purge)
 
 	err := r.Run()
 	if err != nil {